@@ -2,8 +2,6 @@ package main
 
 import (
 	"context"
-	"database/sql"
-	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -13,13 +11,16 @@ import (
 	"webpage-analyzer/internal/application/usecases"
 	"webpage-analyzer/internal/domain/services"
 
+	"webpage-analyzer/internal/domain/repositories"
 	"webpage-analyzer/internal/infrastructure/persistence/postgres"
 	"webpage-analyzer/internal/infrastructure/persistence/redis"
+	"webpage-analyzer/internal/presentation/handlers"
 	"webpage-analyzer/internal/presentation/middleware"
 	"webpage-analyzer/internal/presentation/routes"
 	"webpage-analyzer/pkg/config"
 	"webpage-analyzer/pkg/logger"
 	"webpage-analyzer/pkg/migrate"
+	"webpage-analyzer/pkg/version"
 
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
@@ -36,16 +37,17 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to initialize logger: %v", err)
 	}
+	logger.SetURLRedaction(cfg.Logger.RedactURLCredentials)
 
 	appLogger.Info("Starting Web Page Analyzer API",
-		zap.String("version", "1.0.0"),
+		zap.String("version", version.Version),
+		zap.String("commit", version.Commit),
 		zap.String("port", cfg.Server.Port),
 	)
 
-	cacheRepo := redis.NewCacheRepository(&cfg.Redis)
+	cacheRepo := redis.NewCacheRepository(&cfg.Redis, appLogger)
 
-	db, err := sql.Open("postgres", fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Database.Host, cfg.Database.Port, cfg.Database.User, cfg.Database.Password, cfg.Database.Name, cfg.Database.SSLMode))
+	db, err := postgres.Open(&cfg.Database)
 	if err != nil {
 		appLogger.Fatal("Failed to connect to database", zap.Error(err))
 	}
@@ -56,49 +58,131 @@ func main() {
 		appLogger.Fatal("Failed to run migrations", zap.Error(err))
 	}
 
-	analysisRepo, err := postgres.NewAnalysisRepository(&cfg.Database)
-	if err != nil {
-		appLogger.Fatal("Failed to initialize database", zap.Error(err))
-	}
+	analysisRepo := postgres.NewInstrumentedRepository(postgres.NewAnalysisRepository(db, &cfg.Database), appLogger, cfg.Database.SlowQueryThreshold)
 
-	httpClient := &http.Client{
-		Timeout: 30 * time.Second,
-		Transport: &http.Transport{
-			MaxIdleConns:        100,
-			MaxIdleConnsPerHost: 10,
-			IdleConnTimeout:     90 * time.Second,
-			DisableKeepAlives:   false,
-		},
+	if err := services.ValidateDNSServer(cfg.Analysis.DNSServer); err != nil {
+		appLogger.Fatal("Invalid DNS configuration", zap.Error(err))
+	}
+	if err := services.ValidateMinTLSVersion(cfg.Analysis.MinTLSVersion); err != nil {
+		appLogger.Fatal("Invalid TLS configuration", zap.Error(err))
+	}
+	if err := services.ValidateAllowedSchemes(cfg.Analysis.AllowedSchemes); err != nil {
+		appLogger.Fatal("Invalid allowed schemes configuration", zap.Error(err))
 	}
-	wrappedClient := services.NewHTTPClient(httpClient)
-	parser := services.NewHTMLParser(wrappedClient)
 
 	analyzerConfig := &services.AnalyzerConfig{
-		LinkCheckTimeout:        cfg.Analysis.LinkCheckTimeout,
-		MaxLinksToCheck:         cfg.Analysis.MaxLinksToCheck,
-		MaxConcurrentLinkChecks: cfg.Analysis.MaxConcurrentLinkChecks,
-		MaxHTMLDepth:            cfg.Analysis.MaxHTMLDepth,
-		MaxURLLength:            cfg.Analysis.MaxURLLength,
+		LinkCheckTimeout:          cfg.Analysis.LinkCheckTimeout,
+		MaxLinksToCheck:           cfg.Analysis.MaxLinksToCheck,
+		MaxConcurrentLinkChecks:   cfg.Analysis.MaxConcurrentLinkChecks,
+		AdaptiveLinkChecks:        cfg.Analysis.AdaptiveLinkChecks,
+		MinConcurrentLinkChecks:   cfg.Analysis.MinConcurrentLinkChecks,
+		MaxHTMLDepth:              cfg.Analysis.MaxHTMLDepth,
+		MaxURLLength:              cfg.Analysis.MaxURLLength,
+		AllowedSchemes:            cfg.Analysis.AllowedSchemes,
+		MaxFetchRetries:           cfg.Analysis.MaxFetchRetries,
+		TreatSubdomainsAsInternal: cfg.Analysis.TreatSubdomainsAsInternal,
+		MaxHTMLNodes:              cfg.Analysis.MaxHTMLNodes,
+		MaxTitleLength:            cfg.Analysis.MaxTitleLength,
+		MaxHeadings:               cfg.Analysis.MaxHeadings,
+		LinkCheckSkipHosts:        cfg.Analysis.LinkCheckSkipHosts,
+		LinkCheckMaxRedirects:     cfg.Analysis.LinkCheckMaxRedirects,
+		AllowDataURLs:             cfg.Analysis.AllowDataURLs,
+		SourceCaptureMaxBytes:     cfg.Analysis.SourceCaptureMaxBytes,
+		ForceHTTP1:                cfg.Analysis.ForceHTTP1,
+		DNSServer:                 cfg.Analysis.DNSServer,
+		DNSTimeout:                cfg.Analysis.DNSTimeout,
+		MinTLSVersion:             cfg.Analysis.MinTLSVersion,
+		AcceptLanguage:            cfg.Analysis.AcceptLanguage,
+		LinkCheckAcceptLanguage:   cfg.Analysis.LinkCheckAcceptLanguage,
+		LinkCheckBudget:           cfg.Analysis.LinkCheckBudget,
+		CaptureResponseHeaders:    cfg.Analysis.CaptureResponseHeaders,
+		AllowedDomains:            cfg.Analysis.AllowedDomains,
+	}
+
+	// no client timeout: the page fetch is bounded by the analysis context,
+	// whose deadline clients may extend with X-Analysis-Timeout
+	wrappedClient := services.NewHTTPClient(services.NewSharedHTTPClient(0, analyzerConfig.Transport()))
+	parser, err := services.NewHTMLParserOfKind(cfg.Analysis.HTMLParser, wrappedClient)
+	if err != nil {
+		appLogger.Fatal("Invalid HTML parser configuration", zap.Error(err))
+	}
+
+	if cfg.Analysis.SharedLinkCache {
+		analyzerConfig.LinkCheckCache = redis.NewLinkCheckCache(cacheRepo, cfg.Analysis.LinkCacheTTL)
 	}
 	analyzer := services.NewAnalyzerService(wrappedClient, parser, analyzerConfig)
 
+	var sourceRepo repositories.SourceRepository
+	if cfg.Analysis.SourceCaptureMaxBytes > 0 {
+		sourceRepo = redis.NewSourceRepository(cacheRepo, cfg.Analysis.SourceCaptureTTL)
+	}
+
+	cacheTTLRules := make([]usecases.CacheTTLRule, 0, len(cfg.Analysis.CacheTTLOverrides))
+	for _, override := range cfg.Analysis.CacheTTLOverrides {
+		rule, err := usecases.NewCacheTTLRule(override.Host, override.Pattern, override.TTL)
+		if err != nil {
+			appLogger.Fatal("Invalid cache TTL override", zap.Error(err))
+		}
+		cacheTTLRules = append(cacheTTLRules, rule)
+	}
+
 	analysisUC := usecases.NewAnalysisUseCase(
 		analysisRepo,
 		cacheRepo,
 		analyzer,
 		appLogger,
 		int(cfg.Analysis.CacheTTL.Seconds()),
+		&usecases.AnalysisUseCaseConfig{
+			MaxConcurrentAnalyses: cfg.Analysis.MaxConcurrentJobs,
+			DefaultPriority:       cfg.Analysis.DefaultPriority,
+			MaxConcurrentPerUser:  cfg.Analysis.MaxConcurrentPerUser,
+			Sources:               sourceRepo,
+			MaxJobRetries:         cfg.Analysis.MaxJobRetries,
+			MaxResultListItems:    cfg.Analysis.MaxResultListItems,
+			CacheTTLRules:         cacheTTLRules,
+			MaxStoredPerUser:      cfg.Analysis.MaxStoredPerUser,
+			SyncQueueSize:         cfg.Analysis.SyncQueueSize,
+			SyncQueueWait:         cfg.Analysis.SyncQueueWait,
+		},
 	)
 
+	monitorRepo := postgres.NewMonitorRepository(db)
+	monitorUC := usecases.NewMonitorUseCase(monitorRepo, analysisUC, appLogger, cfg.Analysis.MonitorMinInterval)
+	monitorScheduler := usecases.NewMonitorScheduler(monitorRepo, analysisUC, appLogger, cfg.Analysis.MonitorPollInterval)
+	monitorScheduler.Start(context.Background())
+
+	var retentionCleaner *usecases.RetentionCleaner
+	if cfg.Analysis.Retention > 0 {
+		retentionCleaner = usecases.NewRetentionCleaner(analysisRepo, appLogger, cfg.Analysis.Retention, cfg.Analysis.RetentionCleanupInterval)
+		retentionCleaner.Start(context.Background())
+	}
+
 	if !cfg.Logger.Development {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	router := gin.New()
+	router, err := routes.NewRouter(cfg.Server.TrustedProxies)
+	if err != nil {
+		appLogger.Fatal("Failed to configure router", zap.Error(err))
+	}
 
 	rateLimiter := middleware.NewRateLimiter(cfg.Analysis.RateLimitPerIP, cfg.Analysis.RateLimitWindow)
 
-	routes.SetupRoutes(router, analysisUC, appLogger, rateLimiter, cfg.Analysis.MaxContentLength, int(cfg.Analysis.RequestTimeout.Seconds()))
+	routes.SetupRoutes(router, analysisUC, appLogger, rateLimiter, cfg.Analysis.MaxContentLength, int(cfg.Analysis.RequestTimeout.Seconds()), &routes.Options{
+		LogBodies:            cfg.Logger.LogBodies,
+		MaxBodyLogSize:       cfg.Logger.MaxBodyLogSize,
+		RateLimitExemptPaths: cfg.Analysis.RateLimitExemptPaths,
+		Database:             handlers.PingerFunc(db.PingContext),
+		Cache:                cacheRepo,
+		MetricsPath:          cfg.Server.MetricsPath,
+		SeparateMetrics:      cfg.Server.MetricsPort != "",
+		CorrelationIDHeader:  cfg.Server.CorrelationIDHeader,
+		Compression:          cfg.Server.CompressionEnabled,
+		CompressionMinSize:   cfg.Server.CompressionMinSize,
+		ReadRouteTimeout:     cfg.Server.ReadRouteTimeout,
+		MaxAnalysisTimeout:   cfg.Server.MaxAnalysisTimeout,
+		Monitors:             monitorUC,
+	})
 
 	server := &http.Server{
 		Addr:         ":" + cfg.Server.Port,
@@ -115,18 +199,72 @@ func main() {
 		}
 	}()
 
+	var metricsServer *http.Server
+	if cfg.Server.MetricsPort != "" {
+		metricsServer = &http.Server{
+			Addr:        ":" + cfg.Server.MetricsPort,
+			Handler:     routes.NewMetricsHandler(cfg.Server.MetricsPath),
+			ReadTimeout: cfg.Server.ReadTimeout,
+			IdleTimeout: cfg.Server.IdleTimeout,
+		}
+
+		go func() {
+			appLogger.Info("Metrics server starting",
+				zap.String("address", metricsServer.Addr),
+				zap.String("path", cfg.Server.MetricsPath),
+			)
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				appLogger.Fatal("Failed to start metrics server", zap.Error(err))
+			}
+		}()
+	}
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	appLogger.Info("Shutting down server...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	steps := []shutdownStep{{name: "http server", run: server.Shutdown}}
+	if metricsServer != nil {
+		steps = append(steps, shutdownStep{name: "metrics server", run: metricsServer.Shutdown})
+	}
+	steps = append(steps,
+		shutdownStep{name: "monitor scheduler", run: func(context.Context) error {
+			monitorScheduler.Stop()
+			return nil
+		}},
+		shutdownStep{name: "background analyses", run: analysisUC.Drain},
+	)
+	shutdown(appLogger, cfg.Server.ShutdownTimeout, steps...)
 
-	if err := server.Shutdown(ctx); err != nil {
-		appLogger.Error("Server forced to shutdown", zap.Error(err))
+	if retentionCleaner != nil {
+		retentionCleaner.Stop()
 	}
 
 	appLogger.Info("Server shutdown complete")
 }
+
+// defaultShutdownTimeout applies when server.shutdown_timeout is not positive.
+const defaultShutdownTimeout = 30 * time.Second
+
+type shutdownStep struct {
+	name string
+	run  func(context.Context) error
+}
+
+// shutdown runs steps in order under one shared timeout, so servers and the
+// drain of background analyses together fit the deployment's grace period.
+func shutdown(log logger.Logger, timeout time.Duration, steps ...shutdownStep) {
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	for _, step := range steps {
+		if err := step.run(ctx); err != nil {
+			log.Error("Shutdown step did not complete in time", zap.String("step", step.name), zap.Error(err))
+		}
+	}
+}
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
+	"webpage-analyzer/pkg/logger"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -17,3 +21,46 @@ func TestMainFunction(t *testing.T) {
 func TestApplicationStartup(t *testing.T) {
 	assert.NotNil(t, "application startup")
 }
+
+func TestShutdownUsesConfiguredTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		want    time.Duration
+	}{
+		{"configured", 5 * time.Second, 5 * time.Second},
+		{"unset", 0, defaultShutdownTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deadlines []time.Time
+			record := func(ctx context.Context) error {
+				deadline, ok := ctx.Deadline()
+				assert.True(t, ok)
+				deadlines = append(deadlines, deadline)
+				return nil
+			}
+
+			start := time.Now()
+			shutdown(logger.NewNop(), tt.timeout,
+				shutdownStep{name: "http server", run: record},
+				shutdownStep{name: "background analyses", run: record},
+			)
+
+			assert.Len(t, deadlines, 2)
+			assert.Equal(t, deadlines[0], deadlines[1])
+			assert.WithinDuration(t, start.Add(tt.want), deadlines[0], time.Second)
+		})
+	}
+}
+
+func TestShutdownContinuesAfterFailedStep(t *testing.T) {
+	ran := false
+	shutdown(logger.NewNop(), time.Second,
+		shutdownStep{name: "http server", run: func(context.Context) error { return errors.New("boom") }},
+		shutdownStep{name: "background analyses", run: func(context.Context) error { ran = true; return nil }},
+	)
+
+	assert.True(t, ran)
+}
@@ -2,12 +2,17 @@ package usecases
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 	"webpage-analyzer/internal/domain/entities"
 	"webpage-analyzer/internal/domain/repositories"
 	"webpage-analyzer/internal/domain/services"
+	"webpage-analyzer/internal/infrastructure/monitoring"
 	"webpage-analyzer/pkg/logger"
+	"webpage-analyzer/pkg/tracing"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -17,13 +22,124 @@ const (
 	DefaultCorrelationID = "unknown"
 )
 
+// ErrTooManyAnalyses is returned when the in-flight analysis cap is reached.
+var ErrTooManyAnalyses = errors.New("too many concurrent analyses")
+
+// SyncQueueName labels the sync analysis queue in the queue_length metric.
+const SyncQueueName = "sync_analysis"
+
+// busyRetryAttempts and busyRetryBackoff bound how long a finished analysis
+// waits out a busy database before its update is given up.
+const (
+	busyRetryAttempts = 3
+	busyRetryBackoff  = 100 * time.Millisecond
+)
+
+// ErrUserLimitExceeded is returned when a single user already has the
+// maximum number of analyses in flight.
+var ErrUserLimitExceeded = errors.New("too many concurrent analyses for user")
+
+// ErrAnalysisNotRetryable is returned by RetryAnalysis for an analysis that
+// did not fail; completed analyses are re-run with a new analysis instead.
+var ErrAnalysisNotRetryable = errors.New("only failed analyses can be retried")
+
+// ErrRetriesExhausted is returned by RetryAnalysis once an analysis has been
+// retried the configured maximum number of times.
+var ErrRetriesExhausted = errors.New("analysis has no retries left")
+
+// StatsCacheKey caches the aggregate statistics for StatsCacheTTL seconds,
+// so dashboards polling them do not rerun the aggregation.
+const (
+	StatsCacheKey = "analysis_stats"
+	StatsCacheTTL = 30
+)
+
+// AnalysisCacheKey is the cache key for a URL's latest result. The cache
+// repository adds the configured namespace prefix.
+func AnalysisCacheKey(url string) string {
+	return "analysis:" + url
+}
+
+type sourceCaptureKey struct{}
+
+// WithSourceCapture marks ctx so the analysis it starts stores its raw HTML
+// in the source repository. It has no effect unless capture is configured.
+func WithSourceCapture(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sourceCaptureKey{}, true)
+}
+
+// SourceCaptureRequested reports whether ctx was marked by WithSourceCapture.
+func SourceCaptureRequested(ctx context.Context) bool {
+	requested, _ := ctx.Value(sourceCaptureKey{}).(bool)
+	return requested
+}
+
+type reanalysisKey struct{}
+
+// WithReanalysis marks ctx so SubmitAnalysisJob always enqueues a new
+// analysis instead of returning a fresh existing one.
+func WithReanalysis(ctx context.Context) context.Context {
+	return context.WithValue(ctx, reanalysisKey{}, true)
+}
+
+// ReanalysisRequested reports whether ctx was marked by WithReanalysis.
+func ReanalysisRequested(ctx context.Context) bool {
+	requested, _ := ctx.Value(reanalysisKey{}).(bool)
+	return requested
+}
+
+type AnalysisUseCaseConfig struct {
+	// MaxConcurrentAnalyses caps in-flight sync and async analyses; <= 0 disables the cap.
+	MaxConcurrentAnalyses int
+	// MaxConcurrentPerUser caps in-flight analyses per user ID; <= 0 disables it.
+	MaxConcurrentPerUser int
+	// DefaultPriority is assigned to sync analyses and to jobs submitted
+	// without a priority; <= 0 falls back to entities.DefaultPriority.
+	DefaultPriority int
+	// Sources stores captured raw HTML; nil disables source capture.
+	Sources repositories.SourceRepository
+	// MaxJobRetries is set on every submitted job; <= 0 falls back to
+	// entities.DefaultMaxJobRetries.
+	MaxJobRetries int
+	// MaxResultListItems caps the broken links and external hosts kept in
+	// each result before it is stored; <= 0 keeps them all.
+	MaxResultListItems int
+	// CacheTTLRules override the cache TTL for matching URLs, first match
+	// wins; other URLs use the global TTL.
+	CacheTTLRules []CacheTTLRule
+	// MaxStoredPerUser caps the analyses kept per user ID; creating one
+	// beyond the cap deletes that user's oldest finished ones. In-flight
+	// analyses and those of entities.AnonymousUserID are never pruned.
+	// <= 0 disables the cap.
+	MaxStoredPerUser int
+	// SyncQueueSize lets that many sync analyses wait up to SyncQueueWait
+	// for a slot when MaxConcurrentAnalyses is reached, instead of failing
+	// at once. Either <= 0 disables queueing.
+	SyncQueueSize int
+	SyncQueueWait time.Duration
+}
+
 type AnalysisUseCase interface {
-	AnalyzeURL(ctx context.Context, url, userID string) (*entities.Analysis, error)
+	AnalyzeURL(ctx context.Context, url, userID string, metadata map[string]string) (*entities.Analysis, error)
 	GetAnalysis(ctx context.Context, id uuid.UUID) (*entities.Analysis, error)
+	GetAnalysesByIDs(ctx context.Context, ids []uuid.UUID) ([]*entities.Analysis, error)
 	GetAnalysisByURL(ctx context.Context, url string) (*entities.Analysis, error)
-	SubmitAnalysisJob(ctx context.Context, url, userID string, priority int) (*entities.AnalysisJob, *entities.Analysis, error)
+	// SubmitAnalysisJob enqueues an analysis of url. If a completed analysis
+	// younger than the cache TTL exists, it is returned with a nil job and
+	// nothing is enqueued, unless ctx was marked by WithReanalysis.
+	SubmitAnalysisJob(ctx context.Context, url, userID string, priority int, metadata map[string]string) (*entities.AnalysisJob, *entities.Analysis, error)
 	ProcessAnalysisAsync(ctx context.Context, analysis *entities.Analysis)
+	// RetryAnalysis re-runs a failed analysis in place, incrementing its
+	// retry count. When async is set it returns once the retry is started.
+	RetryAnalysis(ctx context.Context, analysis *entities.Analysis, async bool) (*entities.Analysis, error)
 	ListAnalyses(ctx context.Context, filters repositories.AnalysisFilters) ([]*entities.Analysis, error)
+	ValidateURL(ctx context.Context, url string) error
+	GetAnalysisSource(ctx context.Context, id uuid.UUID) (*entities.AnalysisSource, error)
+	// GetStats aggregates the stored analyses, serving a cached copy for up
+	// to StatsCacheTTL seconds.
+	GetStats(ctx context.Context) (*entities.AnalysisStats, error)
+	// Drain waits for background analyses to finish, or for ctx to end.
+	Drain(ctx context.Context) error
 }
 
 type analysisUseCase struct {
@@ -32,86 +148,437 @@ type analysisUseCase struct {
 	analyzer     services.AnalyzerService
 	logger       logger.Logger
 	cacheTTL     int
+	admission    chan struct{}
+
+	// syncQueued counts sync analyses waiting for an admission slot
+	syncQueued    atomic.Int64
+	syncQueueSize int64
+	syncQueueWait time.Duration
+
+	// cacheTTLRules override cacheTTL per URL; see cacheTTLFor
+	cacheTTLRules []CacheTTLRule
+
+	defaultPriority int
+	maxJobRetries   int
+	maxListItems    int
+	// flights coalesces concurrent sync analyses of the same URL, see
+	// joinFlight
+	flightMu sync.Mutex
+	flights  map[string]*flight
+
+	maxPerUser int
+	userMu     sync.Mutex
+	userActive map[string]int
+
+	maxStoredPerUser int
+
+	sources repositories.SourceRepository
+
+	// background tracks async analyses so shutdown can drain them
+	background sync.WaitGroup
 }
 
 func NewAnalysisUseCase(
 	analysisRepo repositories.AnalysisRepository,
 	cacheRepo repositories.CacheRepository,
 	analyzer services.AnalyzerService,
-	logger logger.Logger,
+	log logger.Logger,
 	cacheTTL int,
+	config *AnalysisUseCaseConfig,
 ) AnalysisUseCase {
+	if config == nil {
+		config = &AnalysisUseCaseConfig{}
+	}
+	if log == nil {
+		log = logger.NewNop()
+	}
+
+	var admission chan struct{}
+	if config.MaxConcurrentAnalyses > 0 {
+		admission = make(chan struct{}, config.MaxConcurrentAnalyses)
+	}
+
+	defaultPriority := config.DefaultPriority
+	if defaultPriority <= 0 {
+		defaultPriority = entities.DefaultPriority
+	}
+
+	maxJobRetries := config.MaxJobRetries
+	if maxJobRetries <= 0 {
+		maxJobRetries = entities.DefaultMaxJobRetries
+	}
+
 	return &analysisUseCase{
-		analysisRepo: analysisRepo,
-		cacheRepo:    cacheRepo,
-		analyzer:     analyzer,
-		logger:       logger,
-		cacheTTL:     cacheTTL,
+		analysisRepo:    analysisRepo,
+		cacheRepo:       cacheRepo,
+		analyzer:        analyzer,
+		logger:          log,
+		cacheTTL:        cacheTTL,
+		cacheTTLRules:   config.CacheTTLRules,
+		admission:       admission,
+		defaultPriority: defaultPriority,
+		maxJobRetries:   maxJobRetries,
+		maxListItems:    config.MaxResultListItems,
+		maxPerUser:      config.MaxConcurrentPerUser,
+		userActive:      make(map[string]int),
+		sources:         config.Sources,
+
+		maxStoredPerUser: config.MaxStoredPerUser,
+		syncQueueSize:    int64(config.SyncQueueSize),
+		syncQueueWait:    config.SyncQueueWait,
+	}
+}
+
+// tryAcquireUser reserves one of userID's in-flight slots.
+func (uc *analysisUseCase) tryAcquireUser(userID string) bool {
+	if uc.maxPerUser <= 0 {
+		return true
+	}
+
+	uc.userMu.Lock()
+	defer uc.userMu.Unlock()
+
+	if uc.userActive[userID] >= uc.maxPerUser {
+		return false
+	}
+	uc.userActive[userID]++
+	return true
+}
+
+func (uc *analysisUseCase) releaseUser(userID string) {
+	if uc.maxPerUser <= 0 {
+		return
+	}
+
+	uc.userMu.Lock()
+	defer uc.userMu.Unlock()
+
+	if uc.userActive[userID] <= 1 {
+		delete(uc.userActive, userID)
+		return
+	}
+	uc.userActive[userID]--
+}
+
+func (uc *analysisUseCase) newAnalysis(url, userID, correlationID string, metadata map[string]string, priority int) *entities.Analysis {
+	analysis := entities.NewAnalysis(url, userID, correlationID)
+	analysis.Metadata = metadata
+	analysis.Priority = priority
+	return analysis
+}
+
+// tryAdmit reserves an analysis slot without blocking.
+func (uc *analysisUseCase) tryAdmit() bool {
+	if uc.admission == nil {
+		return true
+	}
+
+	select {
+	case uc.admission <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// admit waits for an analysis slot until ctx is done.
+func (uc *analysisUseCase) admit(ctx context.Context) error {
+	if uc.admission == nil {
+		return nil
+	}
+
+	select {
+	case uc.admission <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// admitQueued reserves an analysis slot for a sync analysis, waiting in the
+// bounded sync queue for up to syncQueueWait when none is free. A full queue
+// or an expired wait fails with ErrTooManyAnalyses.
+func (uc *analysisUseCase) admitQueued(ctx context.Context) error {
+	if uc.tryAdmit() {
+		return nil
+	}
+	if uc.syncQueueSize <= 0 || uc.syncQueueWait <= 0 {
+		return ErrTooManyAnalyses
+	}
+
+	depth := uc.syncQueued.Add(1)
+	defer func() {
+		monitoring.SetQueueLength(SyncQueueName, uc.syncQueued.Add(-1))
+	}()
+	if depth > uc.syncQueueSize {
+		return ErrTooManyAnalyses
+	}
+	monitoring.SetQueueLength(SyncQueueName, depth)
+
+	waitCtx, cancel := context.WithTimeout(ctx, uc.syncQueueWait)
+	defer cancel()
+	if err := uc.admit(waitCtx); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return ErrTooManyAnalyses
+	}
+	return nil
+}
+
+func (uc *analysisUseCase) release() {
+	if uc.admission != nil {
+		<-uc.admission
 	}
 }
 
-func (uc *analysisUseCase) AnalyzeURL(ctx context.Context, url, userID string) (*entities.Analysis, error) {
+func (uc *analysisUseCase) AnalyzeURL(ctx context.Context, url, userID string, metadata map[string]string) (*entities.Analysis, error) {
 	correlationID, ok := ctx.Value(logger.CorrelationIDKey).(string)
 	if !ok {
 		correlationID = DefaultCorrelationID
 	}
 	log := uc.logger.WithContext(ctx).With(
-		zap.String(string(logger.URLKey), url),
+		logger.URL(url),
 		zap.String(string(logger.UserIDKey), userID),
 	)
 
 	log.Info("Starting URL analysis")
 
-	cacheKey := fmt.Sprintf("analysis:%s", url)
+	if fresh := uc.freshAnalysis(ctx, log, url, userID, correlationID, metadata, uc.defaultPriority); fresh != nil {
+		return fresh, nil
+	}
+
+	// concurrent callers for the same URL share one fetch. The row is
+	// created for the caller that started it, with its correlation ID and
+	// metadata; every other user gets a row of their own holding the shared
+	// result. The work runs detached so one caller leaving does not fail
+	// the others; it is cancelled only once every caller has gone.
+	f, started, err := uc.joinFlight(ctx, url, userID)
+	if err != nil {
+		log.Warn("Rejecting analysis, per-user concurrency limit reached")
+		return nil, err
+	}
+	if started {
+		go func() {
+			analysis, err := uc.runAnalysis(f.ctx, log, url, userID, correlationID, metadata)
+			uc.finishFlight(url, userID, f, analysis, err)
+		}()
+	}
+
+	select {
+	case <-f.done:
+		uc.leaveFlight(f)
+	case <-ctx.Done():
+		if !uc.leaveFlight(f) {
+			log.Info("Request cancelled, leaving shared analysis to other callers")
+			return nil, ctx.Err()
+		}
+		// the analysis was cancelled with us; wait for it to record that
+		<-f.done
+	}
+
+	analysis, err := f.analysis, f.err
+	if err != nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if !started {
+		log.Info("Shared result of concurrent analysis for the same URL")
+		if analysis != nil && analysis.UserID != userID {
+			if err != nil {
+				return nil, err
+			}
+			return uc.copyForUser(ctx, log, analysis, userID, correlationID, metadata, uc.defaultPriority)
+		}
+	}
+
+	if analysis != nil {
+		copied := *analysis
+		copied.Metadata = metadata
+		analysis = &copied
+		uc.storeSource(ctx, log, analysis)
+	}
+
+	return analysis, err
+}
+
+// copyForUser saves a new completed row for userID holding the result of
+// another user's analysis, so each user only ever receives rows they own.
+// The copy keeps the original's creation time and so expires with it.
+func (uc *analysisUseCase) copyForUser(ctx context.Context, log logger.Logger, original *entities.Analysis, userID, correlationID string, metadata map[string]string, priority int) (*entities.Analysis, error) {
+	analysis := uc.newAnalysis(original.URL, userID, correlationID, metadata, priority)
+	analysis.CreatedAt = original.CreatedAt
+	analysis.MarkAsCompleted(original.Result)
+	if err := uc.createAnalysis(ctx, log, analysis); err != nil {
+		log.Error("Failed to create analysis record", zap.Error(err))
+		return nil, fmt.Errorf("failed to create analysis: %w", err)
+	}
+	return analysis, nil
+}
+
+// freshAnalysis returns a completed analysis of url that is still within the
+// cache TTL, from the cache or failing that the database, or nil if url
+// needs analysing. A cache hit is wrapped in a new, unsaved analysis; a
+// stored one is copied. Either way it carries the caller's metadata, not
+// that of the request that produced it.
+func (uc *analysisUseCase) freshAnalysis(ctx context.Context, log logger.Logger, url, userID, correlationID string, metadata map[string]string, priority int) *entities.Analysis {
 	var cachedResult entities.AnalysisResult
-	if err := uc.cacheRepo.Get(ctx, cacheKey, &cachedResult); err == nil {
+	if err := uc.cacheRepo.Get(ctx, AnalysisCacheKey(url), &cachedResult); err == nil {
 		log.Info("Analysis result found in cache")
-		analysis := entities.NewAnalysis(url, userID, correlationID)
+		analysis := uc.newAnalysis(url, userID, correlationID, metadata, priority)
 		analysis.MarkAsCompleted(&cachedResult)
-		return analysis, nil
+		return analysis
 	}
 
 	if existing, err := uc.analysisRepo.GetByURL(ctx, url); err == nil {
 		if existing.Status == entities.StatusCompleted && existing.Result != nil {
 			// check if the analysis is still fresh (within cache TTL)
-			if time.Since(existing.CreatedAt) < time.Duration(uc.cacheTTL)*time.Second {
+			if time.Since(existing.CreatedAt) < time.Duration(uc.cacheTTLFor(url))*time.Second {
 				log.Info("Analysis already completed and still fresh",
 					zap.String("analysis_id", existing.ID.String()),
 					zap.Duration("age", time.Since(existing.CreatedAt)))
-				return existing, nil
-			} else {
-				log.Info("Analysis exists but expired, will re-analyze",
-					zap.String("analysis_id", existing.ID.String()),
-					zap.Duration("age", time.Since(existing.CreatedAt)))
+				reused := *existing
+				reused.Metadata = metadata
+				return &reused
 			}
+			log.Info("Analysis exists but expired, will re-analyze",
+				zap.String("analysis_id", existing.ID.String()),
+				zap.Duration("age", time.Since(existing.CreatedAt)))
 		}
 	}
 
-	analysis := entities.NewAnalysis(url, userID, correlationID)
-	if err := uc.analysisRepo.Create(ctx, analysis); err != nil {
+	return nil
+}
+
+// storedFreshAnalysis is freshAnalysis for async submissions, whose callers
+// poll the returned ID, so it only answers from the database. A fresh row
+// owned by userID is returned as is; one owned by another user is copied into
+// a new row for userID, keeping its creation time so the copy expires with
+// it. Only the cache holding a result is not enough: nil sends the caller to
+// the queue.
+func (uc *analysisUseCase) storedFreshAnalysis(ctx context.Context, log logger.Logger, url, userID, correlationID string, metadata map[string]string, priority int) *entities.Analysis {
+	existing, err := uc.analysisRepo.GetByURL(ctx, url)
+	if err != nil || existing.Status != entities.StatusCompleted || existing.Result == nil ||
+		time.Since(existing.CreatedAt) >= time.Duration(uc.cacheTTLFor(url))*time.Second {
+		return nil
+	}
+
+	if existing.UserID == userID {
+		reused := *existing
+		reused.Metadata = metadata
+		return &reused
+	}
+
+	analysis, err := uc.copyForUser(ctx, log, existing, userID, correlationID, metadata, priority)
+	if err != nil {
+		return nil
+	}
+	return analysis
+}
+
+// storeSource saves the raw HTML attached to a completed analysis when the
+// caller asked for it. Failures only cost the debug copy, so they are logged.
+func (uc *analysisUseCase) storeSource(ctx context.Context, log logger.Logger, analysis *entities.Analysis) {
+	if uc.sources == nil || !SourceCaptureRequested(ctx) || analysis.Result == nil || analysis.Result.Source == nil {
+		return
+	}
+
+	source := &entities.AnalysisSource{
+		AnalysisID: analysis.ID,
+		URL:        analysis.URL,
+		Content:    analysis.Result.Source,
+		Truncated:  analysis.Result.SourceTruncated,
+		CapturedAt: time.Now(),
+	}
+	if err := uc.sources.SaveSource(ctx, source); err != nil {
+		log.Warn("Failed to store analysis source", zap.Error(err))
+	}
+}
+
+func (uc *analysisUseCase) runAnalysis(ctx context.Context, log logger.Logger, url, userID, correlationID string, metadata map[string]string) (*entities.Analysis, error) {
+	if err := uc.admitQueued(ctx); err != nil {
+		if errors.Is(err, ErrTooManyAnalyses) {
+			log.Warn("Rejecting analysis, concurrency limit reached")
+		}
+		return nil, err
+	}
+	defer uc.release()
+
+	if err := ctx.Err(); err != nil {
+		log.Info("Request cancelled before analysis started", zap.Error(err))
+		return nil, err
+	}
+
+	previous := uc.previousResult(ctx, url)
+	analysis := uc.newAnalysis(url, userID, correlationID, metadata, uc.defaultPriority)
+	if err := uc.createAnalysis(ctx, log, analysis); err != nil {
 		log.Error("Failed to create analysis record", zap.Error(err))
 		return nil, fmt.Errorf("failed to create analysis: %w", err)
 	}
 
+	return uc.execute(ctx, log, analysis, previous)
+}
+
+// createAnalysis stores a new analysis and then enforces the per-user cap
+// by deleting the owner's oldest finished analyses. The anonymous user is
+// shared by every client without a user ID, so it is not pruned: one client
+// must not be able to delete another's results. Pruning failures are only
+// logged; the next create prunes again.
+func (uc *analysisUseCase) createAnalysis(ctx context.Context, log logger.Logger, analysis *entities.Analysis) error {
+	if err := uc.analysisRepo.Create(ctx, analysis); err != nil {
+		return err
+	}
+
+	if uc.maxStoredPerUser <= 0 || analysis.UserID == entities.AnonymousUserID {
+		return nil
+	}
+	deleted, err := uc.analysisRepo.DeleteOldestForUser(ctx, analysis.UserID, uc.maxStoredPerUser)
+	if err != nil {
+		log.Warn("Failed to prune oldest analyses for user", zap.Error(err))
+		return nil
+	}
+	if deleted > 0 {
+		log.Info("Pruned oldest analyses for user", zap.Int64("deleted", deleted))
+	}
+	return nil
+}
+
+// execute runs a stored analysis synchronously and records its outcome. The
+// caller must hold an admission slot. previous, if set, makes the page fetch
+// conditional; see analyzeURL.
+func (uc *analysisUseCase) execute(ctx context.Context, log logger.Logger, analysis *entities.Analysis, previous *entities.AnalysisResult) (*entities.Analysis, error) {
+	url := analysis.URL
+	cacheKey := AnalysisCacheKey(url)
+
 	analysis.MarkAsProcessing()
 	if err := uc.analysisRepo.Update(ctx, analysis); err != nil {
 		log.Error("Failed to update analysis status", zap.Error(err))
 	}
 
-	result, err := uc.analyzer.AnalyzeURL(ctx, url)
+	result, err := uc.analyzeURL(ctx, log, url, previous)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		// the caller has gone away; record the row as cancelled so it does
+		// not sit in processing, but skip the result and cache writes
+		log.Info("Request cancelled", zap.String("analysis_id", analysis.ID.String()))
+		analysis.MarkAsCancelled("request cancelled")
+		_ = uc.analysisRepo.Update(context.WithoutCancel(ctx), analysis)
+		return analysis, ctxErr
+	}
 	if err != nil {
 		log.Error("Analysis failed", zap.Error(err))
+		recordValidationFailure(err)
 		analysis.MarkAsFailed(err.Error())
 		_ = uc.analysisRepo.Update(ctx, analysis)
 		return analysis, fmt.Errorf("analysis failed: %w", err)
 	}
 
+	monitoring.RecordPageSize(result.ContentLength)
+	result.Links.TrimLists(uc.maxListItems)
 	analysis.MarkAsCompleted(result)
-	if err := uc.analysisRepo.Update(ctx, analysis); err != nil {
+	if err := uc.saveOutcome(ctx, analysis); err != nil {
 		log.Error("Failed to update analysis result", zap.Error(err))
 	}
 
-	if err := uc.cacheRepo.Set(ctx, cacheKey, result, uc.cacheTTL); err != nil {
+	if err := uc.cacheRepo.Set(ctx, cacheKey, result, uc.cacheTTLFor(url)); err != nil {
 		log.Warn("Failed to cache analysis result", zap.Error(err))
 	}
 
@@ -123,33 +590,80 @@ func (uc *analysisUseCase) AnalyzeURL(ctx context.Context, url, userID string) (
 	return analysis, nil
 }
 
-func (uc *analysisUseCase) SubmitAnalysisJob(ctx context.Context, url, userID string, priority int) (*entities.AnalysisJob, *entities.Analysis, error) {
+func (uc *analysisUseCase) SubmitAnalysisJob(ctx context.Context, url, userID string, priority int, metadata map[string]string) (*entities.AnalysisJob, *entities.Analysis, error) {
 	correlationID, ok := ctx.Value(logger.CorrelationIDKey).(string)
 	if !ok {
 		correlationID = DefaultCorrelationID
 	}
+	if priority <= 0 {
+		priority = uc.defaultPriority
+	}
 	log := uc.logger.WithContext(ctx).With(
-		zap.String(string(logger.URLKey), url),
+		logger.URL(url),
 		zap.String(string(logger.UserIDKey), userID),
 		zap.Int("priority", priority),
 	)
 
 	log.Info("Submitting analysis job")
 
-	if err := uc.analyzer.ValidateURL(url); err != nil {
+	if err := uc.validateURL(url); err != nil {
 		log.Error("Invalid URL", zap.Error(err))
 		return nil, nil, fmt.Errorf("invalid URL: %w", err)
 	}
 
-	analysis := entities.NewAnalysis(url, userID, correlationID)
-	if err := uc.analysisRepo.Create(ctx, analysis); err != nil {
+	if !ReanalysisRequested(ctx) {
+		if fresh := uc.storedFreshAnalysis(ctx, log, url, userID, correlationID, metadata, priority); fresh != nil {
+			log.Info("Reusing fresh analysis instead of submitting a job",
+				zap.String("analysis_id", fresh.ID.String()),
+			)
+			return nil, fresh, nil
+		}
+	}
+
+	if !uc.tryAcquireUser(userID) {
+		log.Warn("Rejecting analysis job, per-user concurrency limit reached")
+		return nil, nil, ErrUserLimitExceeded
+	}
+
+	if !uc.tryAdmit() {
+		uc.releaseUser(userID)
+		log.Warn("Rejecting analysis job, concurrency limit reached")
+		return nil, nil, ErrTooManyAnalyses
+	}
+
+	previous := uc.previousResult(ctx, url)
+	analysis := uc.newAnalysis(url, userID, correlationID, metadata, priority)
+	if err := uc.createAnalysis(ctx, log, analysis); err != nil {
+		uc.release()
+		uc.releaseUser(userID)
 		log.Error("Failed to create analysis record", zap.Error(err))
 		return nil, nil, fmt.Errorf("failed to create analysis: %w", err)
 	}
 
-	uc.ProcessAnalysisAsync(ctx, analysis)
+	captureSource := SourceCaptureRequested(ctx)
+	traceparent, traced := tracing.FromContext(ctx)
+
+	// the admitted slot is handed over to the background worker
+	uc.background.Add(1)
+	go func() {
+		defer uc.background.Done()
+		asyncCtx, cancel := uc.newAsyncContext(analysis)
+		defer cancel()
+		if captureSource {
+			asyncCtx = WithSourceCapture(asyncCtx)
+		}
+		if traced {
+			asyncCtx = tracing.WithTraceparent(asyncCtx, traceparent)
+			asyncCtx = context.WithValue(asyncCtx, logger.TraceIDKey, traceparent.TraceID)
+		}
+		defer uc.release()
+		defer uc.releaseUser(userID)
+
+		uc.processAnalysis(asyncCtx, analysis, previous)
+	}()
 
 	job := entities.NewAnalysisJob(url, userID, correlationID, priority)
+	job.MaxRetries = uc.maxJobRetries
 
 	log.Info("Analysis job submitted successfully",
 		zap.String("job_id", job.ID.String()),
@@ -158,49 +672,200 @@ func (uc *analysisUseCase) SubmitAnalysisJob(ctx context.Context, url, userID st
 	return job, analysis, nil
 }
 
+func (uc *analysisUseCase) RetryAnalysis(ctx context.Context, analysis *entities.Analysis, async bool) (*entities.Analysis, error) {
+	log := uc.logger.WithContext(ctx).With(
+		logger.URL(analysis.URL),
+		zap.String("analysis_id", analysis.ID.String()),
+		zap.Int("retry_count", analysis.RetryCount),
+		zap.Bool("async", async),
+	)
+
+	if analysis.Status != entities.StatusFailed {
+		return nil, ErrAnalysisNotRetryable
+	}
+	if !analysis.CanRetry(uc.maxJobRetries) {
+		return nil, ErrRetriesExhausted
+	}
+
+	if !uc.tryAcquireUser(analysis.UserID) {
+		log.Warn("Rejecting retry, per-user concurrency limit reached")
+		return nil, ErrUserLimitExceeded
+	}
+	if !uc.tryAdmit() {
+		uc.releaseUser(analysis.UserID)
+		log.Warn("Rejecting retry, concurrency limit reached")
+		return nil, ErrTooManyAnalyses
+	}
+
+	previous := uc.previousResult(ctx, analysis.URL)
+
+	// the versioned update makes concurrent retries of the same analysis
+	// fail with ErrVersionConflict instead of running twice
+	retried := *analysis
+	retried.MarkAsRetrying()
+	retried.Error = ""
+	if err := uc.analysisRepo.Update(ctx, &retried); err != nil {
+		uc.release()
+		uc.releaseUser(analysis.UserID)
+		log.Error("Failed to mark analysis as retrying", zap.Error(err))
+		return nil, fmt.Errorf("failed to retry analysis: %w", err)
+	}
+
+	log.Info("Retrying failed analysis")
+
+	if async {
+		background := retried
+		uc.background.Add(1)
+		go func() {
+			defer uc.background.Done()
+			asyncCtx, cancel := uc.newAsyncContext(&background)
+			defer cancel()
+			defer uc.release()
+			defer uc.releaseUser(background.UserID)
+
+			uc.processAnalysis(asyncCtx, &background, previous)
+		}()
+		return &retried, nil
+	}
+
+	defer uc.release()
+	defer uc.releaseUser(analysis.UserID)
+	return uc.execute(ctx, log, &retried, previous)
+}
+
 func (uc *analysisUseCase) ProcessAnalysisAsync(ctx context.Context, analysis *entities.Analysis) {
+	uc.background.Add(1)
 	go func() {
-		asyncCtx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer uc.background.Done()
+		asyncCtx, cancel := uc.newAsyncContext(analysis)
 		defer cancel()
-		asyncCtx = context.WithValue(asyncCtx, logger.CorrelationIDKey, analysis.CorrelationID)
-		asyncCtx = context.WithValue(asyncCtx, logger.UserIDKey, analysis.UserID)
 
-		log := uc.logger.WithContext(asyncCtx).With(
-			zap.String(string(logger.URLKey), analysis.URL),
-			zap.String(string(logger.UserIDKey), analysis.UserID),
-			zap.String("analysis_id", analysis.ID.String()),
-		)
+		if err := uc.admit(asyncCtx); err != nil {
+			uc.logger.WithContext(asyncCtx).Error("Timed out waiting for analysis slot",
+				zap.String("analysis_id", analysis.ID.String()),
+				zap.Error(err),
+			)
+			return
+		}
+		defer uc.release()
+
+		uc.processAnalysis(asyncCtx, analysis, nil)
+	}()
+}
+
+func (uc *analysisUseCase) Drain(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		uc.background.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (uc *analysisUseCase) newAsyncContext(analysis *entities.Analysis) (context.Context, context.CancelFunc) {
+	asyncCtx, cancel := context.WithTimeout(context.Background(), AnalysisTimeout)
+	asyncCtx = context.WithValue(asyncCtx, logger.CorrelationIDKey, analysis.CorrelationID)
+	asyncCtx = context.WithValue(asyncCtx, logger.UserIDKey, analysis.UserID)
+	return asyncCtx, cancel
+}
+
+// processAnalysis runs analysis in the background; previous, if set, makes
+// the page fetch conditional as in execute.
+func (uc *analysisUseCase) processAnalysis(asyncCtx context.Context, analysis *entities.Analysis, previous *entities.AnalysisResult) {
+	log := uc.logger.WithContext(asyncCtx).With(
+		logger.URL(analysis.URL),
+		zap.String(string(logger.UserIDKey), analysis.UserID),
+		zap.String("analysis_id", analysis.ID.String()),
+	)
 
-		log.Info("Starting async analysis processing")
+	log.Info("Starting async analysis processing")
 
-		cacheKey := fmt.Sprintf("analysis:%s", analysis.URL)
-		var cachedResult entities.AnalysisResult
-		if err := uc.cacheRepo.Get(asyncCtx, cacheKey, &cachedResult); err == nil {
-			log.Info("Analysis result found in cache")
-			analysis.MarkAsCompleted(&cachedResult)
+	cacheKey := AnalysisCacheKey(analysis.URL)
+	var cachedResult entities.AnalysisResult
+	if err := uc.cacheRepo.Get(asyncCtx, cacheKey, &cachedResult); err == nil {
+		log.Info("Analysis result found in cache")
+		analysis.MarkAsCompleted(&cachedResult)
+	} else {
+		result, err := uc.analyzeURL(asyncCtx, log, analysis.URL, previous)
+		if err != nil {
+			log.Error("Analysis failed", zap.Error(err))
+			analysis.MarkAsFailed(err.Error())
 		} else {
-			result, err := uc.analyzer.AnalyzeURL(asyncCtx, analysis.URL)
-			if err != nil {
-				log.Error("Analysis failed", zap.Error(err))
-				analysis.MarkAsFailed(err.Error())
-			} else {
-				log.Info("Analysis completed successfully")
-				analysis.MarkAsCompleted(result)
-
-				if err := uc.cacheRepo.Set(asyncCtx, cacheKey, result, uc.cacheTTL); err != nil {
-					log.Warn("Failed to cache analysis result", zap.Error(err))
-				}
+			log.Info("Analysis completed successfully")
+			monitoring.RecordPageSize(result.ContentLength)
+			result.Links.TrimLists(uc.maxListItems)
+			analysis.MarkAsCompleted(result)
+			uc.storeSource(asyncCtx, log, analysis)
+
+			if err := uc.cacheRepo.Set(asyncCtx, cacheKey, result, uc.cacheTTLFor(analysis.URL)); err != nil {
+				log.Warn("Failed to cache analysis result", zap.Error(err))
 			}
 		}
+	}
+
+	if err := uc.saveOutcome(asyncCtx, analysis); err != nil {
+		log.Error("Failed to update analysis in database", zap.Error(err))
+	}
+
+	log.Info("Async analysis processing completed",
+		zap.String("status", string(analysis.Status)),
+	)
+}
+
+// saveOutcome persists a finished analysis. On a version conflict it re-reads
+// the row: if another writer already finished it, that outcome is kept;
+// otherwise the update is retried once against the fresh version.
+func (uc *analysisUseCase) saveOutcome(ctx context.Context, analysis *entities.Analysis) error {
+	err := uc.updateWhenNotBusy(ctx, analysis)
+	if !errors.Is(err, repositories.ErrVersionConflict) {
+		return err
+	}
 
-		if err := uc.analysisRepo.Update(asyncCtx, analysis); err != nil {
-			log.Error("Failed to update analysis in database", zap.Error(err))
+	current, getErr := uc.analysisRepo.GetByID(ctx, analysis.ID)
+	if getErr != nil {
+		return fmt.Errorf("failed to re-read analysis after conflict: %w", getErr)
+	}
+
+	if current.Status.IsTerminal() {
+		uc.logger.WithContext(ctx).Warn("Analysis already finished by another writer",
+			zap.String("analysis_id", analysis.ID.String()),
+			zap.String("status", string(current.Status)),
+		)
+		return nil
+	}
+
+	analysis.Version = current.Version
+	return uc.updateWhenNotBusy(ctx, analysis)
+}
+
+// updateWhenNotBusy retries an update that failed because the database was
+// at write capacity, backing off between attempts, so a burst of finishing
+// jobs does not lose results.
+func (uc *analysisUseCase) updateWhenNotBusy(ctx context.Context, analysis *entities.Analysis) error {
+	backoff := busyRetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := uc.analysisRepo.Update(ctx, analysis)
+		if !errors.Is(err, repositories.ErrDatabaseBusy) || attempt == busyRetryAttempts {
+			return err
 		}
 
-		log.Info("Async analysis processing completed",
-			zap.String("status", string(analysis.Status)),
+		uc.logger.WithContext(ctx).Warn("Database busy, retrying analysis update",
+			zap.String("analysis_id", analysis.ID.String()),
+			zap.Int("attempt", attempt),
 		)
-	}()
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
 }
 
 func (uc *analysisUseCase) GetAnalysis(ctx context.Context, id uuid.UUID) (*entities.Analysis, error) {
@@ -216,8 +881,61 @@ func (uc *analysisUseCase) GetAnalysis(ctx context.Context, id uuid.UUID) (*enti
 	return analysis, nil
 }
 
+func (uc *analysisUseCase) GetAnalysisSource(ctx context.Context, id uuid.UUID) (*entities.AnalysisSource, error) {
+	if uc.sources == nil {
+		return nil, repositories.ErrSourceNotFound
+	}
+
+	source, err := uc.sources.GetSource(ctx, id)
+	if err != nil {
+		if !errors.Is(err, repositories.ErrSourceNotFound) {
+			uc.logger.WithContext(ctx).Error("Failed to retrieve analysis source",
+				zap.String("analysis_id", id.String()),
+				zap.Error(err),
+			)
+		}
+		return nil, fmt.Errorf("failed to get analysis source: %w", err)
+	}
+
+	return source, nil
+}
+
+func (uc *analysisUseCase) GetStats(ctx context.Context) (*entities.AnalysisStats, error) {
+	log := uc.logger.WithContext(ctx)
+
+	var cached entities.AnalysisStats
+	if err := uc.cacheRepo.Get(ctx, StatsCacheKey, &cached); err == nil {
+		return &cached, nil
+	}
+
+	stats, err := uc.analysisRepo.Stats(ctx, 0)
+	if err != nil {
+		log.Error("Failed to aggregate analyses", zap.Error(err))
+		return nil, fmt.Errorf("failed to get stats: %w", err)
+	}
+
+	if err := uc.cacheRepo.Set(ctx, StatsCacheKey, stats, StatsCacheTTL); err != nil {
+		log.Warn("Failed to cache stats", zap.Error(err))
+	}
+	return stats, nil
+}
+
+func (uc *analysisUseCase) GetAnalysesByIDs(ctx context.Context, ids []uuid.UUID) ([]*entities.Analysis, error) {
+	log := uc.logger.WithContext(ctx).With(zap.Int("requested", len(ids)))
+	log.Debug("Retrieving analyses by IDs")
+
+	analyses, err := uc.analysisRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		log.Error("Failed to retrieve analyses", zap.Error(err))
+		return nil, fmt.Errorf("failed to get analyses: %w", err)
+	}
+
+	log.Debug("Retrieved analyses", zap.Int("count", len(analyses)))
+	return analyses, nil
+}
+
 func (uc *analysisUseCase) GetAnalysisByURL(ctx context.Context, url string) (*entities.Analysis, error) {
-	log := uc.logger.WithContext(ctx).With(zap.String(string(logger.URLKey), url))
+	log := uc.logger.WithContext(ctx).With(logger.URL(url))
 	log.Debug("Retrieving analysis by URL")
 
 	analysis, err := uc.analysisRepo.GetByURL(ctx, url)
@@ -247,3 +965,28 @@ func (uc *analysisUseCase) ListAnalyses(ctx context.Context, filters repositorie
 	log.Debug("Retrieved analyses", zap.Int("count", len(analyses)))
 	return analyses, nil
 }
+
+func (uc *analysisUseCase) ValidateURL(ctx context.Context, url string) error {
+	log := uc.logger.WithContext(ctx).With(logger.URL(url))
+	log.Debug("Validating URL")
+
+	if err := uc.validateURL(url); err != nil {
+		log.Debug("URL failed validation", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// validateURL runs the analyzer's validation and counts failures by reason.
+func (uc *analysisUseCase) validateURL(url string) error {
+	err := uc.analyzer.ValidateURL(url)
+	recordValidationFailure(err)
+	return err
+}
+
+func recordValidationFailure(err error) {
+	if reason, ok := services.ValidationFailureReason(err); ok {
+		monitoring.RecordValidationFailure(string(reason))
+	}
+}
@@ -0,0 +1,62 @@
+package usecases
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// CacheTTLRule overrides the global cache TTL for the URLs it matches.
+// Build one with NewCacheTTLRule.
+type CacheTTLRule struct {
+	host    string
+	pattern *regexp.Regexp
+	ttl     int
+}
+
+// NewCacheTTLRule matches URLs on host or any of its subdomains, or URLs
+// matching the pattern regular expression; exactly one must be given.
+func NewCacheTTLRule(host, pattern string, ttl time.Duration) (CacheTTLRule, error) {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if (host == "") == (pattern == "") {
+		return CacheTTLRule{}, fmt.Errorf("cache TTL rule needs exactly one of host or pattern")
+	}
+	if ttl <= 0 {
+		return CacheTTLRule{}, fmt.Errorf("cache TTL rule for %q must have a positive TTL", host+pattern)
+	}
+
+	rule := CacheTTLRule{host: host, ttl: int(ttl.Seconds())}
+	if pattern != "" {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return CacheTTLRule{}, fmt.Errorf("invalid cache TTL pattern %q: %w", pattern, err)
+		}
+		rule.pattern = compiled
+	}
+	return rule, nil
+}
+
+func (r CacheTTLRule) matches(targetURL string) bool {
+	if r.pattern != nil {
+		return r.pattern.MatchString(targetURL)
+	}
+	u, err := url.Parse(targetURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	return host == r.host || strings.HasSuffix(host, "."+r.host)
+}
+
+// cacheTTLFor returns the TTL, in seconds, of the first rule matching url,
+// or the global cache TTL.
+func (uc *analysisUseCase) cacheTTLFor(url string) int {
+	for _, rule := range uc.cacheTTLRules {
+		if rule.matches(url) {
+			return rule.ttl
+		}
+	}
+	return uc.cacheTTL
+}
@@ -0,0 +1,106 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+	"time"
+	"webpage-analyzer/internal/domain/entities"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCacheTTLRuleValidation(t *testing.T) {
+	_, err := NewCacheTTLRule("", "", time.Minute)
+	assert.Error(t, err)
+
+	_, err = NewCacheTTLRule("example.com", "^https://", time.Minute)
+	assert.Error(t, err)
+
+	_, err = NewCacheTTLRule("example.com", "", 0)
+	assert.Error(t, err)
+
+	_, err = NewCacheTTLRule("", "([", time.Minute)
+	assert.Error(t, err)
+}
+
+func TestCacheTTLForMatchesRules(t *testing.T) {
+	news, err := NewCacheTTLRule("News.example.com", "", 10*time.Minute)
+	require.NoError(t, err)
+	docs, err := NewCacheTTLRule("", `^https://[^/]+/docs/`, 24*time.Hour)
+	require.NoError(t, err)
+
+	uc := NewAnalysisUseCase(nil, nil, nil, nil, 3600, &AnalysisUseCaseConfig{
+		CacheTTLRules: []CacheTTLRule{news, docs},
+	}).(*analysisUseCase)
+
+	tests := []struct {
+		url  string
+		want int
+	}{
+		{"https://news.example.com/today", 600},
+		{"https://eu.news.example.com/", 600},
+		{"https://news.example.com/docs/first-rule-wins", 600},
+		{"https://example.org/docs/install", 86400},
+		{"https://badnews.example.com/", 3600},
+		{"https://example.org/blog", 3600},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, uc.cacheTTLFor(tt.url), tt.url)
+	}
+}
+
+func TestAnalyzeURLCachesWithOverrideTTL(t *testing.T) {
+	rule, err := NewCacheTTLRule("news.example.com", "", 10*time.Minute)
+	require.NoError(t, err)
+	cache := newJSONCache()
+	uc := NewAnalysisUseCase(newFakeAnalysisRepository(), cache, &fakeAnalyzer{}, newTestLogger(t), 3600, &AnalysisUseCaseConfig{
+		CacheTTLRules: []CacheTTLRule{rule},
+	})
+
+	_, err = uc.AnalyzeURL(context.Background(), "https://news.example.com/", "user1", nil)
+	require.NoError(t, err)
+	_, err = uc.AnalyzeURL(context.Background(), "https://docs.example.org/", "user1", nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, 600, cache.ttls[AnalysisCacheKey("https://news.example.com/")])
+	assert.Equal(t, 3600, cache.ttls[AnalysisCacheKey("https://docs.example.org/")])
+}
+
+func TestProcessAnalysisAsyncCachesWithOverrideTTL(t *testing.T) {
+	rule, err := NewCacheTTLRule("", `/changelog$`, 5*time.Minute)
+	require.NoError(t, err)
+	cache := newJSONCache()
+	uc := NewAnalysisUseCase(newFakeAnalysisRepository(), cache, &fakeAnalyzer{}, newTestLogger(t), 3600, &AnalysisUseCaseConfig{
+		CacheTTLRules: []CacheTTLRule{rule},
+	})
+
+	url := "https://example.com/changelog"
+	job, _, err := uc.SubmitAnalysisJob(context.Background(), url, "user1", 1, nil)
+	require.NoError(t, err)
+	require.NotNil(t, job)
+	require.NoError(t, uc.Drain(context.Background()))
+
+	assert.Equal(t, 300, cache.ttls[AnalysisCacheKey(url)])
+}
+
+func TestFreshAnalysisHonoursOverrideTTL(t *testing.T) {
+	rule, err := NewCacheTTLRule("news.example.com", "", time.Minute)
+	require.NoError(t, err)
+	repo := newFakeAnalysisRepository()
+	uc := NewAnalysisUseCase(repo, &fakeCacheRepository{}, &fakeAnalyzer{}, newTestLogger(t), 3600, &AnalysisUseCaseConfig{
+		CacheTTLRules: []CacheTTLRule{rule},
+	})
+
+	// ten minutes old: fresh under the global hour, stale under the rule
+	existing := entities.NewAnalysis("https://news.example.com/", "user1", "corr1")
+	existing.MarkAsCompleted(&entities.AnalysisResult{Title: "Earlier"})
+	existing.CreatedAt = time.Now().Add(-10 * time.Minute)
+	require.NoError(t, repo.Create(context.Background(), existing))
+
+	job, analysis, err := uc.SubmitAnalysisJob(context.Background(), "https://news.example.com/", "user1", 1, nil)
+	require.NoError(t, err)
+	require.NoError(t, uc.Drain(context.Background()))
+	assert.NotNil(t, job)
+	assert.NotEqual(t, existing.ID, analysis.ID)
+}
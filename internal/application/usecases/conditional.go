@@ -0,0 +1,49 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"webpage-analyzer/internal/domain/entities"
+	"webpage-analyzer/internal/domain/services"
+	"webpage-analyzer/pkg/logger"
+)
+
+// previousResult returns the latest stored result for url if it recorded
+// cache validators, so the next fetch can be made conditional. It must be
+// called before the new analysis row is created.
+func (uc *analysisUseCase) previousResult(ctx context.Context, url string) *entities.AnalysisResult {
+	existing, err := uc.analysisRepo.GetByURL(ctx, url)
+	if err != nil || existing.Status != entities.StatusCompleted || existing.Result == nil {
+		return nil
+	}
+	if existing.Result.ETag == "" && existing.Result.LastModified == "" {
+		return nil
+	}
+	return existing.Result
+}
+
+// analyzeURL runs the analyzer, sending previous's validators with the page
+// fetch. When the page answers 304, previous is reused with the new fetch
+// timings instead of parsing the page again.
+func (uc *analysisUseCase) analyzeURL(ctx context.Context, log logger.Logger, url string, previous *entities.AnalysisResult) (*entities.AnalysisResult, error) {
+	if previous != nil {
+		ctx = services.WithValidators(ctx, services.Validators{
+			ETag:         previous.ETag,
+			LastModified: previous.LastModified,
+		})
+	}
+
+	result, err := uc.analyzer.AnalyzeURL(ctx, url)
+	if !errors.Is(err, services.ErrNotModified) || previous == nil {
+		return result, err
+	}
+
+	log.Info("Page not modified, reusing previous result")
+	reused := *previous
+	reused.LoadTime = result.LoadTime
+	reused.FetchTime = result.FetchTime
+	reused.ParseTime = 0
+	reused.LinkCheckTime = 0
+	reused.NotModified = true
+	return &reused, nil
+}
@@ -0,0 +1,83 @@
+package usecases
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"webpage-analyzer/internal/domain/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newNotModifiedServer serves a page with an ETag and answers matching
+// conditional requests with 304, counting both kinds of response.
+func newNotModifiedServer(t *testing.T) (*httptest.Server, *atomic.Int32, *atomic.Int32) {
+	var full, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full.Add(1)
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte("<html><head><title>Unchanged</title></head><body><h1>Hi</h1></body></html>"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &full, &notModified
+}
+
+func newRealAnalyzer() services.AnalyzerService {
+	httpClient := services.NewHTTPClient(services.NewSharedHTTPClient(services.DefaultRequestTimeout, services.TransportConfig{}))
+	return services.NewAnalyzerService(httpClient, services.NewHTMLParser(httpClient), &services.AnalyzerConfig{
+		LinkCheckTimeout: services.DefaultRequestTimeout,
+		MaxLinksToCheck:  10,
+		MaxHTMLDepth:     100,
+		MaxURLLength:     2048,
+	})
+}
+
+func TestAnalyzeURLReusesResultWhenNotModified(t *testing.T) {
+	server, full, notModified := newNotModifiedServer(t)
+	repo := newFakeAnalysisRepository()
+	// a zero cache TTL makes every stored analysis stale, forcing a refetch
+	uc := NewAnalysisUseCase(repo, &fakeCacheRepository{}, newRealAnalyzer(), newTestLogger(t), 0, nil)
+
+	first, err := uc.AnalyzeURL(context.Background(), server.URL, "user1", nil)
+	require.NoError(t, err)
+	require.False(t, first.Result.NotModified)
+
+	second, err := uc.AnalyzeURL(context.Background(), server.URL, "user1", nil)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, first.ID, second.ID)
+	assert.True(t, second.Result.NotModified)
+	assert.Equal(t, "Unchanged", second.Result.Title)
+	assert.Equal(t, `"v1"`, second.Result.ETag)
+	assert.True(t, second.CompletedAt.After(*first.CompletedAt))
+	assert.Equal(t, int32(1), full.Load())
+	assert.Equal(t, int32(1), notModified.Load())
+}
+
+func TestSubmitAnalysisJobReusesResultWhenNotModified(t *testing.T) {
+	server, full, notModified := newNotModifiedServer(t)
+	repo := newFakeAnalysisRepository()
+	uc := NewAnalysisUseCase(repo, &fakeCacheRepository{}, newRealAnalyzer(), newTestLogger(t), 0, nil)
+
+	_, err := uc.AnalyzeURL(context.Background(), server.URL, "user1", nil)
+	require.NoError(t, err)
+
+	_, queued, err := uc.SubmitAnalysisJob(context.Background(), server.URL, "user1", 1, nil)
+	require.NoError(t, err)
+	require.NoError(t, uc.Drain(context.Background()))
+
+	stored, err := repo.GetByID(context.Background(), queued.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.Result.NotModified)
+	assert.Equal(t, "Unchanged", stored.Result.Title)
+	assert.Equal(t, int32(1), full.Load())
+	assert.Equal(t, int32(1), notModified.Load())
+}
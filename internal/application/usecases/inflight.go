@@ -0,0 +1,83 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"webpage-analyzer/internal/domain/entities"
+)
+
+// AnalysisTimeout bounds an analysis that runs detached from the request
+// that started it: background jobs and shared sync analyses.
+const AnalysisTimeout = 5 * time.Minute
+
+// flight is a sync analysis of one URL shared by every caller that asks for
+// it while it runs. It runs detached from those callers, is charged to the
+// per-user slot of the one that started it, and is cancelled once no caller
+// is left waiting.
+type flight struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	waiters int
+
+	// done is closed once analysis and err are set
+	done     chan struct{}
+	analysis *entities.Analysis
+	err      error
+}
+
+// joinFlight registers a caller for url and returns the running flight, or
+// starts one detached from ctx if none is running; started reports the
+// latter. Values such as the correlation ID carry over; cancellation does
+// not. Only starting a flight takes one of userID's in-flight slots, so a
+// caller over its limit may still join a flight that is already running.
+func (uc *analysisUseCase) joinFlight(ctx context.Context, url, userID string) (f *flight, started bool, err error) {
+	uc.flightMu.Lock()
+	defer uc.flightMu.Unlock()
+
+	if f, ok := uc.flights[url]; ok {
+		f.waiters++
+		return f, false, nil
+	}
+
+	if !uc.tryAcquireUser(userID) {
+		return nil, false, ErrUserLimitExceeded
+	}
+	if uc.flights == nil {
+		uc.flights = make(map[string]*flight)
+	}
+	f = &flight{waiters: 1, done: make(chan struct{})}
+	f.ctx, f.cancel = context.WithTimeout(context.WithoutCancel(ctx), AnalysisTimeout)
+	uc.flights[url] = f
+	return f, true, nil
+}
+
+// finishFlight records the outcome of f, started by userID, and releases
+// its slot. Later callers start a new flight.
+func (uc *analysisUseCase) finishFlight(url, userID string, f *flight, analysis *entities.Analysis, err error) {
+	uc.flightMu.Lock()
+	if uc.flights[url] == f {
+		delete(uc.flights, url)
+	}
+	uc.flightMu.Unlock()
+
+	uc.releaseUser(userID)
+	f.analysis, f.err = analysis, err
+	f.cancel()
+	close(f.done)
+}
+
+// leaveFlight unregisters a caller and reports whether it was the last one.
+// The last caller out cancels the flight's context, which stops the shared
+// analysis if it is still running.
+func (uc *analysisUseCase) leaveFlight(f *flight) bool {
+	uc.flightMu.Lock()
+	defer uc.flightMu.Unlock()
+
+	f.waiters--
+	if f.waiters > 0 {
+		return false
+	}
+	f.cancel()
+	return true
+}
@@ -0,0 +1,165 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+	"webpage-analyzer/internal/domain/entities"
+	"webpage-analyzer/internal/domain/repositories"
+	"webpage-analyzer/pkg/logger"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultMonitorPollInterval = time.Minute
+	// monitorBatchSize bounds how many due monitors one poll enqueues.
+	monitorBatchSize = 100
+	// monitorClaimLease is how long a claimed monitor is held by the
+	// replica that claimed it; if that replica dies before recording the
+	// run, the monitor falls due again afterwards.
+	monitorClaimLease = 5 * time.Minute
+)
+
+// MonitorMetadataKey tags analyses started by the scheduler with the ID of
+// the monitor that requested them.
+const MonitorMetadataKey = "monitor_id"
+
+// MonitorScheduler polls for due monitors and submits an async analysis for
+// each, recording the analysis ID on the monitor.
+type MonitorScheduler struct {
+	monitorRepo repositories.MonitorRepository
+	analysisUC  AnalysisUseCase
+	logger      logger.Logger
+	interval    time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+func NewMonitorScheduler(
+	monitorRepo repositories.MonitorRepository,
+	analysisUC AnalysisUseCase,
+	log logger.Logger,
+	interval time.Duration,
+) *MonitorScheduler {
+	if log == nil {
+		log = logger.NewNop()
+	}
+	if interval <= 0 {
+		interval = DefaultMonitorPollInterval
+	}
+
+	return &MonitorScheduler{
+		monitorRepo: monitorRepo,
+		analysisUC:  analysisUC,
+		logger:      log,
+		interval:    interval,
+	}
+}
+
+// Start polls immediately and then once per interval until Stop is called
+// or ctx is cancelled.
+func (s *MonitorScheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.RunOnce(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop cancels any in-flight poll and waits for the loop to exit.
+func (s *MonitorScheduler) Stop() {
+	s.once.Do(func() {
+		if s.cancel == nil {
+			return
+		}
+		s.cancel()
+		<-s.done
+	})
+}
+
+// RunOnce claims due monitors, submits an analysis for each and returns how
+// many were enqueued. Claiming keeps schedulers on other replicas from
+// enqueuing the same monitor. Monitors rejected for capacity are released
+// due again and retried on the next poll; other failures skip to the
+// following interval.
+func (s *MonitorScheduler) RunOnce(ctx context.Context) int {
+	now := time.Now()
+
+	monitors, err := s.monitorRepo.ClaimDue(ctx, now, now.Add(monitorClaimLease), monitorBatchSize)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.Error("Failed to claim due monitors", zap.Error(err))
+		}
+		return 0
+	}
+
+	enqueued := 0
+	for i, monitor := range monitors {
+		log := s.logger.With(
+			zap.String("monitor_id", monitor.ID.String()),
+			logger.URL(monitor.URL),
+		)
+
+		// a monitor run must fetch the page again even if a recent
+		// analysis of the URL exists
+		jobCtx := WithReanalysis(context.WithValue(ctx, logger.CorrelationIDKey, uuid.NewString()))
+		_, analysis, err := s.analysisUC.SubmitAnalysisJob(jobCtx, monitor.URL, monitor.UserID, 0,
+			map[string]string{MonitorMetadataKey: monitor.ID.String()})
+
+		switch {
+		case errors.Is(err, ErrTooManyAnalyses):
+			log.Warn("Analysis capacity reached, deferring remaining monitors")
+			for _, deferred := range monitors[i:] {
+				s.release(ctx, deferred, now)
+			}
+			return enqueued
+		case errors.Is(err, ErrUserLimitExceeded):
+			log.Warn("User has too many analyses in flight, monitor stays due")
+			s.release(ctx, monitor, now)
+			continue
+		case err != nil:
+			log.Error("Failed to submit monitor analysis", zap.Error(err))
+			monitor.Postpone(now)
+		default:
+			monitor.RecordRun(analysis.ID, now)
+			enqueued++
+		}
+
+		if err := s.monitorRepo.Update(ctx, monitor); err != nil {
+			log.Error("Failed to update monitor schedule", zap.Error(err))
+		}
+	}
+
+	if enqueued > 0 {
+		s.logger.Info("Monitor analyses enqueued", zap.Int("enqueued", enqueued))
+	}
+
+	return enqueued
+}
+
+// release makes a claimed monitor that was not run due again at now.
+func (s *MonitorScheduler) release(ctx context.Context, monitor *entities.MonitoredURL, now time.Time) {
+	monitor.NextRunAt = now
+	if err := s.monitorRepo.Update(ctx, monitor); err != nil {
+		s.logger.Error("Failed to release monitor", zap.String("monitor_id", monitor.ID.String()), zap.Error(err))
+	}
+}
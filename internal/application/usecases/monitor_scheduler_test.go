@@ -0,0 +1,144 @@
+package usecases
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+	"webpage-analyzer/internal/domain/entities"
+	"webpage-analyzer/internal/domain/repositories"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMonitorSchedulerEnqueuesDueMonitors(t *testing.T) {
+	analysisRepo := newFakeAnalysisRepository()
+	analysisUC := NewAnalysisUseCase(analysisRepo, &fakeCacheRepository{}, &fakeAnalyzer{}, newTestLogger(t), 300, nil)
+	monitorRepo := newFakeMonitorRepository()
+
+	due := entities.NewMonitoredURL("https://example.com/due", "user1", time.Hour)
+	notDue := entities.NewMonitoredURL("https://example.com/later", "user1", time.Hour)
+	notDue.NextRunAt = time.Now().Add(time.Hour)
+	_ = monitorRepo.Create(context.Background(), due)
+	_ = monitorRepo.Create(context.Background(), notDue)
+
+	scheduler := NewMonitorScheduler(monitorRepo, analysisUC, newTestLogger(t), time.Hour)
+	enqueued := scheduler.RunOnce(context.Background())
+	assert.NoError(t, analysisUC.Drain(context.Background()))
+
+	assert.Equal(t, 1, enqueued)
+
+	stored, err := monitorRepo.GetByID(context.Background(), due.ID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, stored.LastAnalysisID) {
+		analysis, err := analysisRepo.GetByID(context.Background(), *stored.LastAnalysisID)
+		assert.NoError(t, err)
+		assert.Equal(t, "https://example.com/due", analysis.URL)
+		assert.Equal(t, due.ID.String(), analysis.Metadata[MonitorMetadataKey])
+	}
+	assert.False(t, stored.IsDue(time.Now()))
+
+	later, err := monitorRepo.GetByID(context.Background(), notDue.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, later.LastAnalysisID)
+
+	assert.Equal(t, 0, scheduler.RunOnce(context.Background()))
+}
+
+func TestMonitorSchedulerReanalysesFreshURLs(t *testing.T) {
+	analysisRepo := newFakeAnalysisRepository()
+	analysisUC := NewAnalysisUseCase(analysisRepo, &fakeCacheRepository{}, &fakeAnalyzer{}, newTestLogger(t), 300, nil)
+	monitorRepo := newFakeMonitorRepository()
+
+	earlier := entities.NewAnalysis("https://example.com/due", "user1", "corr1")
+	earlier.MarkAsCompleted(&entities.AnalysisResult{Title: "Earlier"})
+	_ = analysisRepo.Create(context.Background(), earlier)
+
+	monitor := entities.NewMonitoredURL("https://example.com/due", "user1", time.Hour)
+	_ = monitorRepo.Create(context.Background(), monitor)
+
+	scheduler := NewMonitorScheduler(monitorRepo, analysisUC, newTestLogger(t), time.Hour)
+	assert.Equal(t, 1, scheduler.RunOnce(context.Background()))
+	assert.NoError(t, analysisUC.Drain(context.Background()))
+
+	stored, err := monitorRepo.GetByID(context.Background(), monitor.ID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, stored.LastAnalysisID) {
+		assert.NotEqual(t, earlier.ID, *stored.LastAnalysisID)
+	}
+}
+
+func TestMonitorSchedulerLeavesMonitorsDueAtCapacity(t *testing.T) {
+	analyzer, started, unblock := newBlockingAnalyzer()
+	analysisUC := NewAnalysisUseCase(newFakeAnalysisRepository(), &fakeCacheRepository{}, analyzer, newTestLogger(t), 300,
+		&AnalysisUseCaseConfig{MaxConcurrentAnalyses: 1})
+	monitorRepo := newFakeMonitorRepository()
+
+	first := entities.NewMonitoredURL("https://example.com/one", "user1", time.Hour)
+	second := entities.NewMonitoredURL("https://example.com/two", "user1", time.Hour)
+	first.NextRunAt = time.Now().Add(-2 * time.Minute)
+	second.NextRunAt = time.Now().Add(-time.Minute)
+	_ = monitorRepo.Create(context.Background(), first)
+	_ = monitorRepo.Create(context.Background(), second)
+
+	scheduler := NewMonitorScheduler(monitorRepo, analysisUC, newTestLogger(t), time.Hour)
+	assert.Equal(t, 1, scheduler.RunOnce(context.Background()))
+	<-started
+
+	stored, err := monitorRepo.GetByID(context.Background(), second.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, stored.LastAnalysisID)
+	assert.True(t, stored.IsDue(time.Now()))
+
+	close(unblock)
+	assert.NoError(t, analysisUC.Drain(context.Background()))
+}
+
+func TestMonitorSchedulersOnReplicasEnqueueOnce(t *testing.T) {
+	analysisRepo := newFakeAnalysisRepository()
+	monitorRepo := newFakeMonitorRepository()
+	monitor := entities.NewMonitoredURL("https://example.com", "user1", time.Hour)
+	_ = monitorRepo.Create(context.Background(), monitor)
+
+	// each replica runs its own use case and scheduler against shared stores
+	replicas := make([]AnalysisUseCase, 2)
+	enqueued := make([]int, len(replicas))
+	var wg sync.WaitGroup
+	for i := range replicas {
+		replicas[i] = NewAnalysisUseCase(analysisRepo, &fakeCacheRepository{}, &fakeAnalyzer{}, newTestLogger(t), 300, nil)
+		scheduler := NewMonitorScheduler(monitorRepo, replicas[i], newTestLogger(t), time.Hour)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			enqueued[i] = scheduler.RunOnce(context.Background())
+		}(i)
+	}
+	wg.Wait()
+	for _, uc := range replicas {
+		assert.NoError(t, uc.Drain(context.Background()))
+	}
+
+	assert.Equal(t, 1, enqueued[0]+enqueued[1])
+	analyses, err := analysisRepo.List(context.Background(), repositories.AnalysisFilters{})
+	assert.NoError(t, err)
+	assert.Len(t, analyses, 1)
+}
+
+func TestMonitorSchedulerStartStop(t *testing.T) {
+	analysisUC := NewAnalysisUseCase(newFakeAnalysisRepository(), &fakeCacheRepository{}, &fakeAnalyzer{}, newTestLogger(t), 300, nil)
+	monitorRepo := newFakeMonitorRepository()
+	monitor := entities.NewMonitoredURL("https://example.com", "user1", time.Hour)
+	_ = monitorRepo.Create(context.Background(), monitor)
+
+	scheduler := NewMonitorScheduler(monitorRepo, analysisUC, newTestLogger(t), 10*time.Millisecond)
+	scheduler.Start(context.Background())
+
+	assert.Eventually(t, func() bool {
+		stored, err := monitorRepo.GetByID(context.Background(), monitor.ID)
+		return err == nil && stored.LastAnalysisID != nil
+	}, time.Second, 5*time.Millisecond)
+
+	scheduler.Stop()
+	scheduler.Stop()
+	assert.NoError(t, analysisUC.Drain(context.Background()))
+}
@@ -0,0 +1,88 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"webpage-analyzer/internal/domain/entities"
+	"webpage-analyzer/internal/domain/repositories"
+	"webpage-analyzer/pkg/logger"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// DefaultMinMonitorInterval is the shortest re-analysis interval accepted
+// when none is configured.
+const DefaultMinMonitorInterval = 5 * time.Minute
+
+// ErrInvalidMonitorInterval is returned when a monitor's interval is below
+// the configured minimum.
+var ErrInvalidMonitorInterval = errors.New("monitor interval is too short")
+
+type MonitorUseCase interface {
+	CreateMonitor(ctx context.Context, url, userID string, interval time.Duration) (*entities.MonitoredURL, error)
+	GetMonitor(ctx context.Context, id uuid.UUID) (*entities.MonitoredURL, error)
+}
+
+type monitorUseCase struct {
+	monitorRepo repositories.MonitorRepository
+	analysisUC  AnalysisUseCase
+	logger      logger.Logger
+	minInterval time.Duration
+}
+
+func NewMonitorUseCase(
+	monitorRepo repositories.MonitorRepository,
+	analysisUC AnalysisUseCase,
+	log logger.Logger,
+	minInterval time.Duration,
+) MonitorUseCase {
+	if log == nil {
+		log = logger.NewNop()
+	}
+	if minInterval <= 0 {
+		minInterval = DefaultMinMonitorInterval
+	}
+
+	return &monitorUseCase{
+		monitorRepo: monitorRepo,
+		analysisUC:  analysisUC,
+		logger:      log,
+		minInterval: minInterval,
+	}
+}
+
+func (uc *monitorUseCase) CreateMonitor(ctx context.Context, url, userID string, interval time.Duration) (*entities.MonitoredURL, error) {
+	log := uc.logger.WithContext(ctx).With(
+		logger.URL(url),
+		zap.String(string(logger.UserIDKey), userID),
+		zap.Duration("interval", interval),
+	)
+
+	if interval < uc.minInterval {
+		return nil, fmt.Errorf("%w: minimum is %s", ErrInvalidMonitorInterval, uc.minInterval)
+	}
+
+	if err := uc.analysisUC.ValidateURL(ctx, url); err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	monitor := entities.NewMonitoredURL(url, userID, interval)
+	if err := uc.monitorRepo.Create(ctx, monitor); err != nil {
+		log.Error("Failed to create monitor", zap.Error(err))
+		return nil, fmt.Errorf("failed to create monitor: %w", err)
+	}
+
+	log.Info("Monitor created", zap.String("monitor_id", monitor.ID.String()))
+	return monitor, nil
+}
+
+func (uc *monitorUseCase) GetMonitor(ctx context.Context, id uuid.UUID) (*entities.MonitoredURL, error) {
+	monitor, err := uc.monitorRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get monitor: %w", err)
+	}
+	return monitor, nil
+}
@@ -0,0 +1,111 @@
+package usecases
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+	"webpage-analyzer/internal/domain/entities"
+	"webpage-analyzer/internal/domain/repositories"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeMonitorRepository struct {
+	mu       sync.Mutex
+	monitors map[uuid.UUID]entities.MonitoredURL
+}
+
+func newFakeMonitorRepository() *fakeMonitorRepository {
+	return &fakeMonitorRepository{monitors: make(map[uuid.UUID]entities.MonitoredURL)}
+}
+
+func (r *fakeMonitorRepository) Create(ctx context.Context, monitor *entities.MonitoredURL) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.monitors[monitor.ID] = *monitor
+	return nil
+}
+
+func (r *fakeMonitorRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.MonitoredURL, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	monitor, ok := r.monitors[id]
+	if !ok {
+		return nil, repositories.ErrMonitorNotFound
+	}
+	return &monitor, nil
+}
+
+func (r *fakeMonitorRepository) ClaimDue(ctx context.Context, now, until time.Time, limit int) ([]*entities.MonitoredURL, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	due := make([]*entities.MonitoredURL, 0)
+	for _, monitor := range r.monitors {
+		if monitor.IsDue(now) {
+			m := monitor
+			due = append(due, &m)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].NextRunAt.Before(due[j].NextRunAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	for _, monitor := range due {
+		monitor.NextRunAt = until
+		r.monitors[monitor.ID] = *monitor
+	}
+	return due, nil
+}
+
+func (r *fakeMonitorRepository) Update(ctx context.Context, monitor *entities.MonitoredURL) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.monitors[monitor.ID]; !ok {
+		return repositories.ErrMonitorNotFound
+	}
+	r.monitors[monitor.ID] = *monitor
+	return nil
+}
+
+func newTestMonitorUseCase(t *testing.T, repo *fakeMonitorRepository) MonitorUseCase {
+	analysisUC := NewAnalysisUseCase(newFakeAnalysisRepository(), &fakeCacheRepository{}, &fakeAnalyzer{}, newTestLogger(t), 300, nil)
+	return NewMonitorUseCase(repo, analysisUC, newTestLogger(t), time.Hour)
+}
+
+func TestCreateMonitor(t *testing.T) {
+	repo := newFakeMonitorRepository()
+	uc := newTestMonitorUseCase(t, repo)
+
+	monitor, err := uc.CreateMonitor(context.Background(), "https://example.com", "user1", 24*time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com", monitor.URL)
+	assert.Equal(t, 24*time.Hour, monitor.Interval)
+
+	stored, err := uc.GetMonitor(context.Background(), monitor.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, monitor.ID, stored.ID)
+	assert.True(t, stored.IsDue(time.Now()))
+}
+
+func TestCreateMonitorRejectsInvalidInput(t *testing.T) {
+	repo := newFakeMonitorRepository()
+	uc := newTestMonitorUseCase(t, repo)
+
+	_, err := uc.CreateMonitor(context.Background(), "https://example.com", "user1", time.Minute)
+	assert.ErrorIs(t, err, ErrInvalidMonitorInterval)
+
+	_, err = uc.CreateMonitor(context.Background(), "", "user1", 24*time.Hour)
+	assert.Error(t, err)
+
+	assert.Empty(t, repo.monitors)
+}
+
+func TestGetMonitorNotFound(t *testing.T) {
+	uc := newTestMonitorUseCase(t, newFakeMonitorRepository())
+
+	_, err := uc.GetMonitor(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, repositories.ErrMonitorNotFound)
+}
@@ -0,0 +1,109 @@
+package usecases
+
+import (
+	"context"
+	"sync"
+	"time"
+	"webpage-analyzer/internal/domain/repositories"
+	"webpage-analyzer/internal/infrastructure/monitoring"
+	"webpage-analyzer/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+const DefaultRetentionCleanupInterval = time.Hour
+
+// RetentionCleaner periodically deletes analyses older than the retention
+// period. Cached results expire on their own through the cache TTL.
+type RetentionCleaner struct {
+	analysisRepo repositories.AnalysisRepository
+	logger       logger.Logger
+	retention    time.Duration
+	interval     time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+func NewRetentionCleaner(
+	analysisRepo repositories.AnalysisRepository,
+	log logger.Logger,
+	retention time.Duration,
+	interval time.Duration,
+) *RetentionCleaner {
+	if log == nil {
+		log = logger.NewNop()
+	}
+	if interval <= 0 {
+		interval = DefaultRetentionCleanupInterval
+	}
+
+	return &RetentionCleaner{
+		analysisRepo: analysisRepo,
+		logger:       log,
+		retention:    retention,
+		interval:     interval,
+	}
+}
+
+// Start runs a cleanup immediately and then once per interval until Stop is
+// called or ctx is cancelled.
+func (c *RetentionCleaner) Start(ctx context.Context) {
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.done = make(chan struct{})
+
+	go func() {
+		defer close(c.done)
+
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			c.RunOnce(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop cancels any in-flight cleanup and waits for the loop to exit.
+func (c *RetentionCleaner) Stop() {
+	c.once.Do(func() {
+		if c.cancel == nil {
+			return
+		}
+		c.cancel()
+		<-c.done
+	})
+}
+
+// RunOnce deletes expired analyses and returns the number removed.
+func (c *RetentionCleaner) RunOnce(ctx context.Context) int64 {
+	start := time.Now()
+
+	deleted, err := c.analysisRepo.DeleteOlderThan(ctx, c.retention)
+	monitoring.AnalysesDeletedTotal.Add(float64(deleted))
+
+	if err != nil {
+		if ctx.Err() == nil {
+			c.logger.Error("Retention cleanup failed",
+				zap.Error(err),
+				zap.Int64("deleted", deleted),
+			)
+		}
+		return deleted
+	}
+
+	c.logger.Info("Retention cleanup completed",
+		zap.Int64("deleted", deleted),
+		zap.Duration("retention", c.retention),
+		zap.Duration("duration", time.Since(start)),
+	)
+
+	return deleted
+}
@@ -0,0 +1,48 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+	"time"
+	"webpage-analyzer/internal/domain/entities"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetentionCleanerRunOnce(t *testing.T) {
+	repo := newFakeAnalysisRepository()
+
+	old := entities.NewAnalysis("https://example.com/old", "user1", "corr1")
+	old.CreatedAt = time.Now().Add(-48 * time.Hour)
+	recent := entities.NewAnalysis("https://example.com/recent", "user1", "corr2")
+
+	_ = repo.Create(context.Background(), old)
+	_ = repo.Create(context.Background(), recent)
+
+	cleaner := NewRetentionCleaner(repo, newTestLogger(t), 24*time.Hour, time.Hour)
+	deleted := cleaner.RunOnce(context.Background())
+
+	assert.Equal(t, int64(1), deleted)
+	_, err := repo.GetByID(context.Background(), old.ID)
+	assert.Error(t, err)
+	_, err = repo.GetByID(context.Background(), recent.ID)
+	assert.NoError(t, err)
+}
+
+func TestRetentionCleanerStartStop(t *testing.T) {
+	repo := newFakeAnalysisRepository()
+	old := entities.NewAnalysis("https://example.com/old", "user1", "corr1")
+	old.CreatedAt = time.Now().Add(-48 * time.Hour)
+	_ = repo.Create(context.Background(), old)
+
+	cleaner := NewRetentionCleaner(repo, newTestLogger(t), 24*time.Hour, 10*time.Millisecond)
+	cleaner.Start(context.Background())
+
+	assert.Eventually(t, func() bool {
+		_, err := repo.GetByID(context.Background(), old.ID)
+		return err != nil
+	}, time.Second, 5*time.Millisecond)
+
+	cleaner.Stop()
+	cleaner.Stop()
+}
@@ -1,6 +1,7 @@
 package entities
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,33 +15,174 @@ const (
 	StatusCompleted  AnalysisStatus = "completed"
 	StatusFailed     AnalysisStatus = "failed"
 	StatusRetrying   AnalysisStatus = "retrying"
+	// StatusCancelled means the analysis was stopped before it finished,
+	// e.g. because the requesting client went away.
+	StatusCancelled AnalysisStatus = "cancelled"
+	// StatusExpired means the analysis was not run before it stopped being
+	// useful, e.g. it waited too long in the queue.
+	StatusExpired AnalysisStatus = "expired"
 )
 
+// IsValid reports whether s is a known status.
+func (s AnalysisStatus) IsValid() bool {
+	switch s {
+	case StatusPending, StatusProcessing, StatusCompleted, StatusFailed,
+		StatusRetrying, StatusCancelled, StatusExpired:
+		return true
+	}
+	return false
+}
+
+// IsTerminal reports whether an analysis in status s will not change again.
+func (s AnalysisStatus) IsTerminal() bool {
+	switch s {
+	case StatusCompleted, StatusFailed, StatusCancelled, StatusExpired:
+		return true
+	}
+	return false
+}
+
+const (
+	MaxMetadataKeys        = 20
+	MaxMetadataKeyLength   = 64
+	MaxMetadataValueLength = 256
+)
+
+// DefaultPriority applies when neither the request nor the config sets one.
+// Higher values are more urgent. Jobs run as soon as an admission slot is
+// free, so today priority only affects ordering of sort_by=priority listings.
+const DefaultPriority = 1
+
+// DefaultMaxJobRetries is how many times a failed job may be retried when
+// analysis.max_job_retries is not set.
+const DefaultMaxJobRetries = 3
+
+// AnonymousUserID owns analyses requested without a user ID. It is shared by
+// every such client, so per-user limits do not apply to it.
+const AnonymousUserID = "anonymous"
+
 type Analysis struct {
-	ID            uuid.UUID       `json:"id" db:"id"`
-	URL           string          `json:"url" db:"url"`
-	Status        AnalysisStatus  `json:"status" db:"status"`
-	Result        *AnalysisResult `json:"result,omitempty" db:"result"`
-	Error         string          `json:"error,omitempty" db:"error"`
-	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
-	UpdatedAt     time.Time       `json:"updated_at" db:"updated_at"`
-	CompletedAt   *time.Time      `json:"completed_at,omitempty" db:"completed_at"`
-	RetryCount    int             `json:"retry_count" db:"retry_count"`
-	Priority      int             `json:"priority" db:"priority"`
-	UserID        string          `json:"user_id,omitempty" db:"user_id"`
-	CorrelationID string          `json:"correlation_id" db:"correlation_id"`
+	ID            uuid.UUID         `json:"id" db:"id"`
+	URL           string            `json:"url" db:"url"`
+	Status        AnalysisStatus    `json:"status" db:"status"`
+	Result        *AnalysisResult   `json:"result,omitempty" db:"result"`
+	Error         string            `json:"error,omitempty" db:"error"`
+	CreatedAt     time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at" db:"updated_at"`
+	CompletedAt   *time.Time        `json:"completed_at,omitempty" db:"completed_at"`
+	RetryCount    int               `json:"retry_count" db:"retry_count"`
+	Priority      int               `json:"priority" db:"priority"`
+	UserID        string            `json:"user_id,omitempty" db:"user_id"`
+	CorrelationID string            `json:"correlation_id" db:"correlation_id"`
+	Metadata      map[string]string `json:"metadata,omitempty" db:"metadata"`
+	// Version is bumped on every update and guards against lost updates.
+	Version int `json:"version" db:"version"`
 }
 
+// AnalysisResult is both cached and returned by the API as-is, so its JSON
+// form is the single wire format; LoadTime and the phase times are encoded as
+// integer nanoseconds.
 type AnalysisResult struct {
-	HTMLVersion   string            `json:"html_version"`
-	Title         string            `json:"title"`
-	Headings      map[string]int    `json:"headings"`
-	Links         LinkAnalysis      `json:"links"`
-	HasLoginForm  bool              `json:"has_login_form"`
-	LoadTime      time.Duration     `json:"load_time"`
-	ContentLength int64             `json:"content_length"`
-	StatusCode    int               `json:"status_code"`
-	Metadata      map[string]string `json:"metadata,omitempty"`
+	HTMLVersion          string         `json:"html_version"`
+	Title                string         `json:"title"`
+	TitleTruncated       bool           `json:"title_truncated,omitempty"`
+	Description          string         `json:"description,omitempty"`
+	DescriptionTruncated bool           `json:"description_truncated,omitempty"`
+	Headings             map[string]int `json:"headings"`
+	HeadingOutline       []HeadingNode  `json:"heading_outline,omitempty"`
+	Links                LinkAnalysis   `json:"links"`
+	HasLoginForm         bool           `json:"has_login_form"`
+	Forms                []FormInfo     `json:"forms,omitempty"`
+	DeprecatedElements   []string       `json:"deprecated_elements,omitempty"`
+	// RenderBlockingResources counts the external scripts and stylesheets
+	// in <head> that delay first paint.
+	RenderBlockingResources int `json:"render_blocking_resources"`
+	// Trackers names the analytics and tracking services the page loads.
+	Trackers []string `json:"trackers,omitempty"`
+	// DetectedLanguages lists the distinct lang attribute values in the
+	// document, lower-cased, starting with the root element's.
+	DetectedLanguages []string `json:"detected_languages,omitempty"`
+	// SecurityHeaders grades the security headers on the page response; it
+	// is omitted for content that was not fetched over HTTP.
+	SecurityHeaders *SecurityHeaders `json:"security_headers,omitempty"`
+	// ETag and LastModified are the page's cache validators, sent back on
+	// the next analysis of the URL so an unchanged page is not re-fetched.
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	// NotModified is set when the page answered 304 and this result was
+	// carried over from the previous analysis.
+	NotModified bool `json:"not_modified,omitempty"`
+	// LowConfidence is set when the content yielded no recognizable HTML.
+	LowConfidence bool `json:"low_confidence,omitempty"`
+	// StructuredData lists the schema.org @type values found in JSON-LD.
+	StructuredData []string `json:"structured_data,omitempty"`
+	// LoadTime is the whole analysis. FetchTime, ParseTime and LinkCheckTime
+	// break it down by phase; bookkeeping between phases is not counted, so
+	// they add up to slightly less.
+	LoadTime      time.Duration `json:"load_time"`
+	FetchTime     time.Duration `json:"fetch_time"`
+	ParseTime     time.Duration `json:"parse_time"`
+	LinkCheckTime time.Duration `json:"link_check_time"`
+	ContentLength int64         `json:"content_length"`
+	ContentHash   string        `json:"content_hash,omitempty"`
+	StatusCode    int           `json:"status_code"`
+	Warnings      []string      `json:"warnings,omitempty"`
+	// Note explains an unusual result, such as an empty response body.
+	Note string `json:"note,omitempty"`
+	// ResponseHeaders holds the configured page response headers, keyed by
+	// lower-cased name. Client tags live on Analysis.Metadata.
+	ResponseHeaders map[string]string `json:"response_headers,omitempty"`
+	// Source is the raw fetched HTML when source capture is enabled. It is
+	// kept out of the wire format and stored separately.
+	Source          []byte `json:"-"`
+	SourceTruncated bool   `json:"-"`
+}
+
+// SecurityHeaders is a pass/fail snapshot of the recommended security
+// response headers; Passed counts the checks that passed.
+type SecurityHeaders struct {
+	Passed int                   `json:"passed"`
+	Checks []SecurityHeaderCheck `json:"checks"`
+}
+
+// SecurityHeaderCheck is the outcome for one header. A header can be
+// present yet fail, e.g. X-Frame-Options set to ALLOW-FROM.
+type SecurityHeaderCheck struct {
+	Header  string `json:"header"`
+	Present bool   `json:"present"`
+	Pass    bool   `json:"pass"`
+	Value   string `json:"value,omitempty"`
+}
+
+// AnalysisSource is the raw HTML captured for an analysis, for debugging.
+type AnalysisSource struct {
+	AnalysisID uuid.UUID `json:"analysis_id"`
+	URL        string    `json:"url"`
+	Content    []byte    `json:"content"`
+	Truncated  bool      `json:"truncated"`
+	CapturedAt time.Time `json:"captured_at"`
+}
+
+// HeadingNode is one heading in document order, used to build an outline.
+type HeadingNode struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+}
+
+// FormInfo describes one <form> and the fields it submits.
+type FormInfo struct {
+	Method string      `json:"method"`
+	Action string      `json:"action,omitempty"`
+	Inputs []FormInput `json:"inputs"`
+}
+
+// FormInput is a single input, select or textarea inside a form. Type is the
+// input's type attribute, or the element name for select and textarea.
+// Autocomplete is the effective value, inherited from the form when unset.
+type FormInput struct {
+	Name         string `json:"name,omitempty"`
+	Type         string `json:"type"`
+	Autocomplete string `json:"autocomplete,omitempty"`
 }
 
 type LinkAnalysis struct {
@@ -49,6 +191,22 @@ type LinkAnalysis struct {
 	Inaccessible  int      `json:"inaccessible"`
 	BrokenLinks   []string `json:"broken_links,omitempty"`
 	ExternalHosts []string `json:"external_hosts,omitempty"`
+	// BrokenLinksOmitted and ExternalHostsOmitted count entries dropped by
+	// TrimLists to keep stored results bounded.
+	BrokenLinksOmitted   int `json:"broken_links_omitted,omitempty"`
+	ExternalHostsOmitted int `json:"external_hosts_omitted,omitempty"`
+	// Skipped links point at skip-listed hosts and were never fetched.
+	Skipped      int      `json:"skipped"`
+	SkippedLinks []string `json:"skipped_links,omitempty"`
+	// LinksFound counts every link on the page; LinksChecked counts those
+	// that were judged, which is fewer when the link-check budget runs out.
+	LinksFound      int      `json:"links_found"`
+	LinksChecked    int      `json:"links_checked"`
+	NotCheckedLinks []string `json:"not_checked_links,omitempty"`
+	// StatusCodes holds the final HTTP status of each link that was checked.
+	StatusCodes map[string]int `json:"status_codes,omitempty"`
+	// BrokenLinkReasons explains broken links that were not found by an HTTP check.
+	BrokenLinkReasons map[string]string `json:"broken_link_reasons,omitempty"`
 }
 
 type AnalysisJob struct {
@@ -71,7 +229,8 @@ func NewAnalysis(url, userID, correlationID string) *Analysis {
 		UpdatedAt:     time.Now(),
 		UserID:        userID,
 		CorrelationID: correlationID,
-		Priority:      1,
+		Priority:      DefaultPriority,
+		Version:       1,
 	}
 }
 
@@ -83,7 +242,7 @@ func NewAnalysisJob(url, userID, correlationID string, priority int) *AnalysisJo
 		UserID:        userID,
 		CorrelationID: correlationID,
 		CreatedAt:     time.Now(),
-		MaxRetries:    3,
+		MaxRetries:    DefaultMaxJobRetries,
 	}
 }
 
@@ -106,6 +265,18 @@ func (a *Analysis) MarkAsFailed(err string) {
 	a.UpdatedAt = time.Now()
 }
 
+func (a *Analysis) MarkAsCancelled(reason string) {
+	a.Status = StatusCancelled
+	a.Error = reason
+	a.UpdatedAt = time.Now()
+}
+
+func (a *Analysis) MarkAsExpired(reason string) {
+	a.Status = StatusExpired
+	a.Error = reason
+	a.UpdatedAt = time.Now()
+}
+
 func (a *Analysis) MarkAsRetrying() {
 	a.Status = StatusRetrying
 	a.RetryCount++
@@ -115,3 +286,46 @@ func (a *Analysis) MarkAsRetrying() {
 func (a *Analysis) CanRetry(maxRetries int) bool {
 	return a.RetryCount < maxRetries
 }
+
+// TrimLists caps BrokenLinks and ExternalHosts at maxItems entries each,
+// recording how many were dropped. Reasons for dropped broken links go too.
+// maxItems <= 0 leaves the lists untouched.
+func (l *LinkAnalysis) TrimLists(maxItems int) {
+	if maxItems <= 0 {
+		return
+	}
+
+	if len(l.BrokenLinks) > maxItems {
+		for _, link := range l.BrokenLinks[maxItems:] {
+			delete(l.BrokenLinkReasons, link)
+		}
+		l.BrokenLinksOmitted += len(l.BrokenLinks) - maxItems
+		l.BrokenLinks = l.BrokenLinks[:maxItems:maxItems]
+	}
+
+	if len(l.ExternalHosts) > maxItems {
+		l.ExternalHostsOmitted += len(l.ExternalHosts) - maxItems
+		l.ExternalHosts = l.ExternalHosts[:maxItems:maxItems]
+	}
+}
+
+// ValidateMetadata enforces the limits on client-supplied analysis tags.
+func ValidateMetadata(metadata map[string]string) error {
+	if len(metadata) > MaxMetadataKeys {
+		return fmt.Errorf("too many metadata keys (max %d)", MaxMetadataKeys)
+	}
+
+	for key, value := range metadata {
+		if key == "" {
+			return fmt.Errorf("metadata keys cannot be empty")
+		}
+		if len(key) > MaxMetadataKeyLength {
+			return fmt.Errorf("metadata key %q too long (max %d characters)", key, MaxMetadataKeyLength)
+		}
+		if len(value) > MaxMetadataValueLength {
+			return fmt.Errorf("metadata value for %q too long (max %d characters)", key, MaxMetadataValueLength)
+		}
+	}
+
+	return nil
+}
@@ -1,6 +1,9 @@
 package entities
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -51,6 +54,27 @@ func TestMarkAsFailed(t *testing.T) {
 	assert.Equal(t, "test error", analysis.Error)
 }
 
+func TestMarkAsCancelled(t *testing.T) {
+	analysis := NewAnalysis("https://example.com", "test-user", "test-correlation-id")
+	analysis.MarkAsProcessing()
+
+	analysis.MarkAsCancelled("request cancelled")
+	assert.Equal(t, StatusCancelled, analysis.Status)
+	assert.Equal(t, "request cancelled", analysis.Error)
+	assert.Nil(t, analysis.CompletedAt)
+	assert.True(t, analysis.Status.IsTerminal())
+}
+
+func TestMarkAsExpired(t *testing.T) {
+	analysis := NewAnalysis("https://example.com", "test-user", "test-correlation-id")
+
+	analysis.MarkAsExpired("not processed in time")
+	assert.Equal(t, StatusExpired, analysis.Status)
+	assert.Equal(t, "not processed in time", analysis.Error)
+	assert.Nil(t, analysis.Result)
+	assert.True(t, analysis.Status.IsTerminal())
+}
+
 func TestCanRetry(t *testing.T) {
 	analysis := NewAnalysis("https://example.com", "test-user", "test-correlation-id")
 
@@ -86,6 +110,25 @@ func TestLinkAnalysis(t *testing.T) {
 	assert.Equal(t, 1, links.Inaccessible)
 }
 
+func TestLinkAnalysisTrimLists(t *testing.T) {
+	links := LinkAnalysis{
+		BrokenLinks:       []string{"https://a.test", "https://b.test", "https://c.test"},
+		BrokenLinkReasons: map[string]string{"https://a.test": "404", "https://c.test": "timeout"},
+		ExternalHosts:     []string{"a.test", "b.test", "c.test", "d.test"},
+	}
+
+	links.TrimLists(2)
+	assert.Equal(t, []string{"https://a.test", "https://b.test"}, links.BrokenLinks)
+	assert.Equal(t, map[string]string{"https://a.test": "404"}, links.BrokenLinkReasons)
+	assert.Equal(t, 1, links.BrokenLinksOmitted)
+	assert.Equal(t, []string{"a.test", "b.test"}, links.ExternalHosts)
+	assert.Equal(t, 2, links.ExternalHostsOmitted)
+
+	links.TrimLists(0)
+	assert.Len(t, links.BrokenLinks, 2)
+	assert.Equal(t, 1, links.BrokenLinksOmitted)
+}
+
 func TestNewAnalysisJob(t *testing.T) {
 	url := "https://example.com"
 	userID := "test-user"
@@ -105,6 +148,31 @@ func TestAnalysisStatus(t *testing.T) {
 	assert.Equal(t, "processing", string(StatusProcessing))
 	assert.Equal(t, "completed", string(StatusCompleted))
 	assert.Equal(t, "failed", string(StatusFailed))
+	assert.Equal(t, "cancelled", string(StatusCancelled))
+	assert.Equal(t, "expired", string(StatusExpired))
+}
+
+func TestAnalysisStatusClassification(t *testing.T) {
+	tests := []struct {
+		status   AnalysisStatus
+		valid    bool
+		terminal bool
+	}{
+		{StatusPending, true, false},
+		{StatusProcessing, true, false},
+		{StatusRetrying, true, false},
+		{StatusCompleted, true, true},
+		{StatusFailed, true, true},
+		{StatusCancelled, true, true},
+		{StatusExpired, true, true},
+		{AnalysisStatus("archived"), false, false},
+		{AnalysisStatus(""), false, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.valid, tt.status.IsValid(), tt.status)
+		assert.Equal(t, tt.terminal, tt.status.IsTerminal(), tt.status)
+	}
 }
 
 func TestAnalysisFields(t *testing.T) {
@@ -156,3 +224,34 @@ func TestAnalysisJobFields(t *testing.T) {
 	assert.Equal(t, "corr1", job.CorrelationID)
 	assert.Equal(t, 1, job.Priority)
 }
+
+func TestValidateMetadata(t *testing.T) {
+	assert.NoError(t, ValidateMetadata(nil))
+	assert.NoError(t, ValidateMetadata(map[string]string{"campaign": "spring", "batch": "42"}))
+
+	tooMany := make(map[string]string)
+	for i := 0; i <= MaxMetadataKeys; i++ {
+		tooMany[fmt.Sprintf("key%d", i)] = "value"
+	}
+	assert.Error(t, ValidateMetadata(tooMany))
+
+	assert.Error(t, ValidateMetadata(map[string]string{"": "value"}))
+	assert.Error(t, ValidateMetadata(map[string]string{strings.Repeat("k", MaxMetadataKeyLength+1): "value"}))
+	assert.Error(t, ValidateMetadata(map[string]string{"key": strings.Repeat("v", MaxMetadataValueLength+1)}))
+}
+
+func TestAnalysisResultLoadTimeRoundTrip(t *testing.T) {
+	result := &AnalysisResult{
+		Title:    "Test Page",
+		LoadTime: 1500 * time.Millisecond,
+	}
+
+	// the cache stores results with encoding/json, the same encoding the API uses
+	encoded, err := json.Marshal(result)
+	assert.NoError(t, err)
+	assert.Contains(t, string(encoded), `"load_time":1500000000`)
+
+	var decoded AnalysisResult
+	assert.NoError(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, result.LoadTime, decoded.LoadTime)
+}
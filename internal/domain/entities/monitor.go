@@ -0,0 +1,55 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MonitoredURL is a URL re-analyzed every Interval. The first analysis is
+// due as soon as the monitor is created.
+type MonitoredURL struct {
+	ID             uuid.UUID     `json:"id" db:"id"`
+	URL            string        `json:"url" db:"url"`
+	UserID         string        `json:"user_id,omitempty" db:"user_id"`
+	Interval       time.Duration `json:"interval" db:"interval_seconds"`
+	NextRunAt      time.Time     `json:"next_run_at" db:"next_run_at"`
+	LastRunAt      *time.Time    `json:"last_run_at,omitempty" db:"last_run_at"`
+	LastAnalysisID *uuid.UUID    `json:"last_analysis_id,omitempty" db:"last_analysis_id"`
+	CreatedAt      time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+func NewMonitoredURL(url, userID string, interval time.Duration) *MonitoredURL {
+	now := time.Now()
+	return &MonitoredURL{
+		ID:        uuid.New(),
+		URL:       url,
+		UserID:    userID,
+		Interval:  interval,
+		NextRunAt: now,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// IsDue reports whether the monitor should be analyzed at now.
+func (m *MonitoredURL) IsDue(now time.Time) bool {
+	return !m.NextRunAt.After(now)
+}
+
+// RecordRun notes that analysisID was started for the monitor at runAt and
+// schedules the next run one interval later.
+func (m *MonitoredURL) RecordRun(analysisID uuid.UUID, runAt time.Time) {
+	m.LastAnalysisID = &analysisID
+	m.LastRunAt = &runAt
+	m.NextRunAt = runAt.Add(m.Interval)
+	m.UpdatedAt = time.Now()
+}
+
+// Postpone schedules the next run one interval after now without recording
+// an analysis, for runs that could not be started at all.
+func (m *MonitoredURL) Postpone(now time.Time) {
+	m.NextRunAt = now.Add(m.Interval)
+	m.UpdatedAt = time.Now()
+}
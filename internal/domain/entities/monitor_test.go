@@ -0,0 +1,42 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMonitoredURLIsDueImmediately(t *testing.T) {
+	monitor := NewMonitoredURL("https://example.com", "user1", 24*time.Hour)
+
+	assert.NotEqual(t, uuid.Nil, monitor.ID)
+	assert.True(t, monitor.IsDue(time.Now()))
+	assert.Nil(t, monitor.LastRunAt)
+	assert.Nil(t, monitor.LastAnalysisID)
+}
+
+func TestMonitoredURLRecordRun(t *testing.T) {
+	monitor := NewMonitoredURL("https://example.com", "user1", time.Hour)
+	runAt := time.Now()
+	analysisID := uuid.New()
+
+	monitor.RecordRun(analysisID, runAt)
+
+	assert.Equal(t, analysisID, *monitor.LastAnalysisID)
+	assert.Equal(t, runAt, *monitor.LastRunAt)
+	assert.Equal(t, runAt.Add(time.Hour), monitor.NextRunAt)
+	assert.False(t, monitor.IsDue(runAt.Add(59*time.Minute)))
+	assert.True(t, monitor.IsDue(runAt.Add(time.Hour)))
+}
+
+func TestMonitoredURLPostpone(t *testing.T) {
+	monitor := NewMonitoredURL("https://example.com", "user1", time.Hour)
+	now := time.Now()
+
+	monitor.Postpone(now)
+
+	assert.Equal(t, now.Add(time.Hour), monitor.NextRunAt)
+	assert.Nil(t, monitor.LastAnalysisID)
+}
@@ -0,0 +1,22 @@
+package entities
+
+import "time"
+
+// AnalysisStats summarizes the stored analyses for dashboards. Load time,
+// HTML versions and broken-link hosts only consider completed analyses.
+// AverageLoadTime is encoded as integer nanoseconds, like LoadTime.
+type AnalysisStats struct {
+	Total           int                    `json:"total"`
+	ByStatus        map[AnalysisStatus]int `json:"by_status"`
+	AverageLoadTime time.Duration          `json:"average_load_time"`
+	HTMLVersions    []StatCount            `json:"html_versions"`
+	BrokenLinkHosts []StatCount            `json:"broken_link_hosts"`
+	GeneratedAt     time.Time              `json:"generated_at"`
+}
+
+// StatCount is one value and how many analyses have it, listed most
+// common first.
+type StatCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
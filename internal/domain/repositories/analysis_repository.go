@@ -2,17 +2,70 @@ package repositories
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 	"webpage-analyzer/internal/domain/entities"
 
 	"github.com/google/uuid"
 )
 
+// ErrVersionConflict is returned by Update when the stored analysis no longer
+// has the version the caller read, i.e. someone else updated it first.
+// Callers should re-read the analysis and decide whether to retry.
+var ErrVersionConflict = errors.New("analysis was modified concurrently")
+
+// ErrDatabaseBusy is returned when a write could not get a database
+// connection in time because too many writes are in flight. It is transient:
+// callers may retry after a short backoff.
+var ErrDatabaseBusy = errors.New("database is busy")
+
 type AnalysisRepository interface {
 	Create(ctx context.Context, analysis *entities.Analysis) error
 	GetByID(ctx context.Context, id uuid.UUID) (*entities.Analysis, error)
+	// GetByIDs fetches several analyses in one round-trip. Results follow the
+	// order of ids; IDs with no matching analysis are omitted.
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*entities.Analysis, error)
 	GetByURL(ctx context.Context, url string) (*entities.Analysis, error)
 	Update(ctx context.Context, analysis *entities.Analysis) error
 	List(ctx context.Context, filters AnalysisFilters) ([]*entities.Analysis, error)
+	// DeleteOlderThan removes analyses created more than age ago and returns
+	// the number of rows deleted.
+	DeleteOlderThan(ctx context.Context, age time.Duration) (int64, error)
+	// DeleteOldestForUser removes userID's analyses in a terminal status
+	// beyond the keep most recent ones and returns the number of rows
+	// deleted. Analyses still pending or running are kept.
+	DeleteOldestForUser(ctx context.Context, userID string, keep int) (int64, error)
+	// Stats aggregates all stored analyses, listing at most top HTML
+	// versions and broken-link hosts.
+	Stats(ctx context.Context, top int) (*entities.AnalysisStats, error)
+}
+
+// ErrSourceNotFound is returned when no captured source exists for an
+// analysis, either because capture was off or the source expired.
+var ErrSourceNotFound = errors.New("analysis source not found")
+
+// SourceRepository stores the raw HTML captured for analyses.
+type SourceRepository interface {
+	SaveSource(ctx context.Context, source *entities.AnalysisSource) error
+	GetSource(ctx context.Context, analysisID uuid.UUID) (*entities.AnalysisSource, error)
+}
+
+// ErrMonitorNotFound is returned when no monitor has the requested ID.
+var ErrMonitorNotFound = errors.New("monitor not found")
+
+// MonitorRepository stores URLs that are re-analyzed on a schedule.
+type MonitorRepository interface {
+	Create(ctx context.Context, monitor *entities.MonitoredURL) error
+	GetByID(ctx context.Context, id uuid.UUID) (*entities.MonitoredURL, error)
+	// ClaimDue atomically moves up to limit monitors whose next run is at
+	// or before now to run next at until, and returns them. Concurrent
+	// schedulers never claim the same monitor; one that is never updated
+	// again falls due at until.
+	ClaimDue(ctx context.Context, now, until time.Time, limit int) ([]*entities.MonitoredURL, error)
+	Update(ctx context.Context, monitor *entities.MonitoredURL) error
 }
 
 type CacheRepository interface {
@@ -20,6 +73,8 @@ type CacheRepository interface {
 	Get(ctx context.Context, key string, dest interface{}) error
 	Delete(ctx context.Context, key string) error
 	Exists(ctx context.Context, key string) (bool, error)
+	// Ping reports whether the cache backend is reachable.
+	Ping(ctx context.Context) error
 }
 
 type AnalysisFilters struct {
@@ -30,4 +85,57 @@ type AnalysisFilters struct {
 	Offset    int
 	SortBy    string
 	SortOrder string
+	Metadata  map[string]string
+	// Cursor switches List to keyset pagination ordered by created_at, id
+	// descending; Offset, SortBy and SortOrder are ignored when it is set.
+	Cursor *ListCursor
+}
+
+// ListCursor marks the last row of a page in cursor-based listing.
+type ListCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+func NewListCursor(analysis *entities.Analysis) *ListCursor {
+	return &ListCursor{CreatedAt: analysis.CreatedAt, ID: analysis.ID}
+}
+
+// IsStart reports whether the cursor requests the first page.
+func (c *ListCursor) IsStart() bool {
+	return c.CreatedAt.IsZero() && c.ID == uuid.Nil
+}
+
+// Encode returns the opaque string form handed to clients.
+func (c *ListCursor) Encode() string {
+	raw := fmt.Sprintf("%s|%s", c.CreatedAt.UTC().Format(time.RFC3339Nano), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeListCursor(encoded string) (*ListCursor, error) {
+	if encoded == "" {
+		return &ListCursor{}, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor encoding")
+	}
+
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid cursor format")
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor timestamp")
+	}
+
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor id")
+	}
+
+	return &ListCursor{CreatedAt: createdAt, ID: id}, nil
 }
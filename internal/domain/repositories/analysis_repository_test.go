@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+	"webpage-analyzer/internal/domain/entities"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListCursorRoundTrip(t *testing.T) {
+	analysis := entities.NewAnalysis("https://example.com", "user1", "corr1")
+	analysis.CreatedAt = time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.UTC)
+
+	cursor := NewListCursor(analysis)
+	decoded, err := DecodeListCursor(cursor.Encode())
+
+	assert.NoError(t, err)
+	assert.True(t, analysis.CreatedAt.Equal(decoded.CreatedAt))
+	assert.Equal(t, analysis.ID, decoded.ID)
+	assert.False(t, decoded.IsStart())
+}
+
+func TestDecodeListCursorEmpty(t *testing.T) {
+	cursor, err := DecodeListCursor("")
+
+	assert.NoError(t, err)
+	assert.True(t, cursor.IsStart())
+}
+
+func TestDecodeListCursorInvalid(t *testing.T) {
+	_, err := DecodeListCursor("not base64!")
+	assert.Error(t, err)
+
+	_, err = DecodeListCursor("bm8tc2VwYXJhdG9y")
+	assert.Error(t, err)
+}
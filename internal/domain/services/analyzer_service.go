@@ -2,6 +2,10 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -10,10 +14,15 @@ import (
 	"net/url"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
+	"unicode/utf8"
 	"webpage-analyzer/internal/domain/entities"
+	"webpage-analyzer/pkg/tracing"
 
 	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/net/publicsuffix"
 )
 
 type AnalyzerService interface {
@@ -34,6 +43,71 @@ type AnalyzerConfig struct {
 	MaxConcurrentLinkChecks int
 	MaxHTMLDepth            int
 	MaxURLLength            int
+	AllowedSchemes          []string
+	MaxFetchRetries         int
+	FetchRetryBackoff       time.Duration
+	// TreatSubdomainsAsInternal compares registrable domains (eTLD+1)
+	// instead of exact hosts when classifying links.
+	TreatSubdomainsAsInternal bool
+	// MaxHTMLNodes bounds the number of tokens a document may contain
+	// before it is rejected, independent of nesting depth.
+	MaxHTMLNodes int
+	// MaxTitleLength caps the title and meta description, in characters.
+	MaxTitleLength int
+	// MaxHeadings caps how many headings are counted and outlined per page.
+	MaxHeadings int
+	// LinkCheckSkipHosts lists hosts whose links are classified but never
+	// fetched; a host matches itself and all of its subdomains.
+	LinkCheckSkipHosts []string
+	// LinkCheckMaxRedirects caps how many redirect hops a link check
+	// follows before judging the status; a link still redirecting after
+	// that is reported as not checked. 0 follows redirects like a default
+	// http.Client and judges the final status.
+	LinkCheckMaxRedirects int
+	// AllowDataURLs lets AnalyzeURL parse data:text/html URLs directly,
+	// without a network call. Meant for testing the parser.
+	AllowDataURLs bool
+	// SourceCaptureMaxBytes attaches up to this many bytes of the raw page
+	// to each result for debugging; 0 disables capture.
+	SourceCaptureMaxBytes int
+	// ForceHTTP1 disables HTTP/2 for link checks; page fetches follow the
+	// client passed to NewAnalyzerService.
+	ForceHTTP1 bool
+	// DNSServer resolves link-check hostnames through the DNS server at this
+	// host:port instead of the system resolver; DNSTimeout > 0 bounds each
+	// lookup. Like ForceHTTP1, page fetches follow the client passed to
+	// NewAnalyzerService.
+	DNSServer  string
+	DNSTimeout time.Duration
+	// MinTLSVersion is the oldest TLS version, "1.2" or "1.3", link checks
+	// negotiate; empty means DefaultMinTLSVersion. Page fetches follow the
+	// client passed to NewAnalyzerService, which should be built from the
+	// same config.
+	MinTLSVersion string
+	// AcceptLanguage is sent with the page fetch so localized sites serve
+	// the wanted locale; empty leaves the header unset.
+	AcceptLanguage string
+	// LinkCheckAcceptLanguage also sends AcceptLanguage on link checks.
+	LinkCheckAcceptLanguage bool
+	// LinkCheckBudget caps the wall-clock time spent checking one page's
+	// links; links reached after it elapses are reported as not checked.
+	// 0 disables the budget.
+	LinkCheckBudget time.Duration
+	// AdaptiveLinkChecks sizes each page's link-check concurrency by its
+	// link count, from MinConcurrentLinkChecks for a page with no links up
+	// to MaxConcurrentLinkChecks for one with MaxLinksToCheck or more.
+	// MaxConcurrentLinkChecks still caps checks across all pages.
+	AdaptiveLinkChecks      bool
+	MinConcurrentLinkChecks int
+	// CaptureResponseHeaders lists response headers recorded in the result
+	// metadata when the page sends them; absent headers are left out.
+	CaptureResponseHeaders []string
+	// LinkCheckCache, when set, shares link checks across analyses and
+	// replicas; the parser's in-memory cache still applies.
+	LinkCheckCache LinkCheckCache
+	// AllowedDomains restricts analysis to these hosts and their subdomains;
+	// empty allows any host.
+	AllowedDomains []string
 }
 
 type HTTPClient interface {
@@ -42,7 +116,8 @@ type HTTPClient interface {
 }
 
 type httpClientWrapper struct {
-	client *http.Client
+	client         *http.Client
+	acceptLanguage string
 }
 
 func (w *httpClientWrapper) Get(url string) (*http.Response, error) {
@@ -54,6 +129,11 @@ func (w *httpClientWrapper) GetWithContext(ctx context.Context, url string) (*ht
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	setAcceptLanguage(req, w.acceptLanguage)
+	setConditionalHeaders(ctx, req)
+	if traceparent, ok := tracing.FromContext(ctx); ok {
+		req.Header.Set(tracing.Header, traceparent.Child().String())
+	}
 
 	resp, err := w.client.Do(req)
 	if err != nil {
@@ -67,6 +147,160 @@ func NewHTTPClient(client *http.Client) HTTPClient {
 	return &httpClientWrapper{client: client}
 }
 
+// withAcceptLanguage returns a copy of client that sends the given
+// Accept-Language header. Clients not built by NewHTTPClient are returned
+// unchanged since their requests cannot be amended.
+func withAcceptLanguage(client HTTPClient, acceptLanguage string) HTTPClient {
+	wrapper, ok := client.(*httpClientWrapper)
+	if !ok || acceptLanguage == "" {
+		return client
+	}
+	return &httpClientWrapper{client: wrapper.client, acceptLanguage: acceptLanguage}
+}
+
+// maxPageRedirects matches the http.Client default, which a custom
+// CheckRedirect replaces.
+const maxPageRedirects = 10
+
+// withAllowedDomains returns a copy of client that refuses to follow
+// redirects to hosts outside domains. Clients not built by NewHTTPClient
+// are returned unchanged; AnalyzeURL still checks where they ended up.
+func withAllowedDomains(client HTTPClient, domains []string) HTTPClient {
+	wrapper, ok := client.(*httpClientWrapper)
+	if !ok || len(domains) == 0 {
+		return client
+	}
+
+	restricted := *wrapper.client
+	next := restricted.CheckRedirect
+	restricted.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !matchesHost(req.URL.Hostname(), domains) {
+			return withKind(ErrDomainNotAllowed, fmt.Errorf("redirect to host %s is not in the allowed domains", req.URL.Hostname()))
+		}
+		if next != nil {
+			return next(req, via)
+		}
+		if len(via) >= maxPageRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxPageRedirects)
+		}
+		return nil
+	}
+	return &httpClientWrapper{client: &restricted, acceptLanguage: wrapper.acceptLanguage}
+}
+
+func setAcceptLanguage(req *http.Request, acceptLanguage string) {
+	if acceptLanguage != "" {
+		req.Header.Set("Accept-Language", acceptLanguage)
+	}
+}
+
+// TransportConfig selects how a shared transport connects. Transports are
+// built once per distinct config and shared by every client built from it.
+type TransportConfig struct {
+	ForceHTTP1    bool
+	DNSServer     string
+	DNSTimeout    time.Duration
+	MinTLSVersion string
+}
+
+// Transport returns the transport settings link checks use.
+func (c *AnalyzerConfig) Transport() TransportConfig {
+	return TransportConfig{
+		ForceHTTP1:    c.ForceHTTP1,
+		DNSServer:     c.DNSServer,
+		DNSTimeout:    c.DNSTimeout,
+		MinTLSVersion: c.MinTLSVersion,
+	}
+}
+
+var (
+	sharedTransportsMu sync.Mutex
+	sharedTransports   = make(map[TransportConfig]*http.Transport)
+)
+
+// SharedTransport returns the process-wide tuned transport for config, so
+// page fetches and link checks share one keep-alive connection pool.
+func SharedTransport(config TransportConfig) *http.Transport {
+	config.MinTLSVersion = minTLSVersionName(config.MinTLSVersion)
+
+	sharedTransportsMu.Lock()
+	defer sharedTransportsMu.Unlock()
+
+	transport, ok := sharedTransports[config]
+	if !ok {
+		transport = newTransport(config)
+		sharedTransports[config] = transport
+	}
+	return transport
+}
+
+func newTransport(config TransportConfig) *http.Transport {
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         baseDialer.DialContext,
+		TLSClientConfig:     newTLSClientConfig(config.MinTLSVersion),
+		MaxIdleConns:        DefaultMaxIdleConns,
+		MaxIdleConnsPerHost: DefaultMaxIdleConnsPerHost,
+		IdleConnTimeout:     DefaultIdleConnTimeout,
+		ForceAttemptHTTP2:   !config.ForceHTTP1,
+	}
+	if resolver := newHostResolver(config.DNSServer, config.DNSTimeout); resolver != nil {
+		transport.DialContext = resolver.dialContext
+	}
+	if config.ForceHTTP1 {
+		// a non-nil empty map stops ALPN from ever offering h2
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return transport
+}
+
+// NewSharedHTTPClient builds an *http.Client on top of the shared transport
+// for config. Clients are cheap; only the transports hold pooled
+// connections. timeout 0 leaves requests bounded by their context alone.
+func NewSharedHTTPClient(timeout time.Duration, config TransportConfig) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: SharedTransport(config),
+	}
+}
+
+// fetchContext bounds the page fetch. The caller's deadline governs when it
+// has one, so callers can allow more than DefaultRequestTimeout; without one
+// DefaultRequestTimeout applies.
+func fetchContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, DefaultRequestTimeout)
+}
+
+// ValidateAllowedSchemes reports an error for any scheme the analyzer cannot
+// fetch. AllowedSchemes can only narrow SupportedSchemes: a URL that passes
+// validation must also be fetchable. Call it at startup, since
+// NewAnalyzerService drops such schemes rather than failing.
+func ValidateAllowedSchemes(schemes []string) error {
+	for _, scheme := range schemes {
+		if normalized := strings.ToLower(strings.TrimSpace(scheme)); !contains(SupportedSchemes, normalized) {
+			return fmt.Errorf("unsupported scheme %q (supported: %v)", scheme, SupportedSchemes)
+		}
+	}
+	return nil
+}
+
+// fetchableSchemes returns the schemes in schemes that the analyzer can
+// fetch, lower-cased and in order. It never adds schemes, so a list with
+// none left allows nothing.
+func fetchableSchemes(schemes []string) []string {
+	fetchable := make([]string, 0, len(schemes))
+	for _, scheme := range schemes {
+		scheme = strings.ToLower(strings.TrimSpace(scheme))
+		if contains(SupportedSchemes, scheme) && !contains(fetchable, scheme) {
+			fetchable = append(fetchable, scheme)
+		}
+	}
+	return fetchable
+}
+
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
 		if s == item {
@@ -76,36 +310,113 @@ func contains(slice []string, item string) bool {
 	return false
 }
 
+// HTMLParser extracts page data. Parse only classifies links; CheckLink
+// does the network check for one of them, so callers decide how many run
+// at once.
 type HTMLParser interface {
 	Parse(html, baseURL string) (*ParsedHTML, error)
+	CheckLink(ctx context.Context, href, baseURL string) LinkCheck
 	SetLinkCheckTimeout(timeout time.Duration)
+	SetAllowedSchemes(schemes []string)
+	SetTreatSubdomainsAsInternal(enabled bool)
+	SetMaxNodes(maxNodes int)
+	SetMaxTitleLength(maxLength int)
+	SetMaxHeadings(maxHeadings int)
+	SetLinkCheckSkipHosts(hosts []string)
+	SetLinkCheckMaxRedirects(maxRedirects int)
+	SetTransport(config TransportConfig)
+	SetLinkCheckAcceptLanguage(acceptLanguage string)
+	SetLinkCheckCache(cache LinkCheckCache)
+}
+
+// NodeLimitExceededError is returned when a document has more nodes than the
+// configured limit allows.
+type NodeLimitExceededError struct {
+	Limit int
+}
+
+func (e *NodeLimitExceededError) Error() string {
+	return fmt.Sprintf("HTML document exceeds maximum of %d nodes", e.Limit)
 }
 
 type ParsedHTML struct {
-	HTMLVersion   string         `json:"html_version"`
-	Title         string         `json:"title"`
-	Headings      map[string]int `json:"headings"`
-	Links         []Link         `json:"links"`
-	HasLoginForm  bool           `json:"has_login_form"`
-	ContentLength int64          `json:"content_length"`
+	HTMLVersion             string                 `json:"html_version"`
+	Title                   string                 `json:"title"`
+	TitleTruncated          bool                   `json:"title_truncated"`
+	Description             string                 `json:"description"`
+	DescriptionTruncated    bool                   `json:"description_truncated"`
+	Headings                map[string]int         `json:"headings"`
+	HeadingOutline          []entities.HeadingNode `json:"heading_outline"`
+	HeadingsTruncated       bool                   `json:"headings_truncated"`
+	LowConfidence           bool                   `json:"low_confidence"`
+	StructuredData          []string               `json:"structured_data"`
+	InvalidJSONLDBlocks     int                    `json:"invalid_json_ld_blocks"`
+	Links                   []Link                 `json:"links"`
+	HasLoginForm            bool                   `json:"has_login_form"`
+	Forms                   []entities.FormInfo    `json:"forms"`
+	PasswordAutocomplete    []string               `json:"password_autocomplete"`
+	DeprecatedElements      []string               `json:"deprecated_elements"`
+	RenderBlockingResources int                    `json:"render_blocking_resources"`
+	Trackers                []string               `json:"trackers"`
+	Languages               []string               `json:"languages"`
+	ContentLength           int64                  `json:"content_length"`
 }
 
 type Link struct {
 	URL          string `json:"url"`
 	IsInternal   bool   `json:"is_internal"`
 	IsAccessible bool   `json:"is_accessible"`
+	Reason       string `json:"reason,omitempty"`
+	Skipped      bool   `json:"skipped,omitempty"`
+	NotChecked   bool   `json:"not_checked,omitempty"`
+	StatusCode   int    `json:"status_code,omitempty"`
 }
 
 func NewAnalyzerService(httpClient HTTPClient, parser HTMLParser, config *AnalyzerConfig) AnalyzerService {
 	if config.MaxConcurrentLinkChecks <= 0 {
 		config.MaxConcurrentLinkChecks = 10
 	}
+	if config.MinConcurrentLinkChecks <= 0 {
+		config.MinConcurrentLinkChecks = DefaultMinConcurrentChecks
+	}
+	if config.MinConcurrentLinkChecks > config.MaxConcurrentLinkChecks {
+		config.MinConcurrentLinkChecks = config.MaxConcurrentLinkChecks
+	}
+	if len(config.AllowedSchemes) == 0 {
+		config.AllowedSchemes = SupportedSchemes
+	} else {
+		config.AllowedSchemes = fetchableSchemes(config.AllowedSchemes)
+	}
+	if config.MaxFetchRetries < 0 {
+		config.MaxFetchRetries = 0
+	}
+	if config.FetchRetryBackoff <= 0 {
+		config.FetchRetryBackoff = DefaultFetchRetryBackoff
+	}
+	if config.LinkCheckTimeout <= 0 {
+		config.LinkCheckTimeout = DefaultLinkCheckTimeout
+	}
+	config.AllowedDomains = normalizeHosts(config.AllowedDomains)
 
-	// Configure the parser with the timeout
+	// Configure the parser with the timeout and schemes
 	parser.SetLinkCheckTimeout(config.LinkCheckTimeout)
+	parser.SetAllowedSchemes(config.AllowedSchemes)
+	parser.SetTreatSubdomainsAsInternal(config.TreatSubdomainsAsInternal)
+	parser.SetMaxNodes(config.MaxHTMLNodes)
+	parser.SetMaxTitleLength(config.MaxTitleLength)
+	parser.SetMaxHeadings(config.MaxHeadings)
+	parser.SetLinkCheckSkipHosts(config.LinkCheckSkipHosts)
+	parser.SetLinkCheckMaxRedirects(config.LinkCheckMaxRedirects)
+	parser.SetTransport(config.Transport())
+	if config.LinkCheckCache != nil {
+		parser.SetLinkCheckCache(config.LinkCheckCache)
+	}
+	if config.LinkCheckAcceptLanguage {
+		parser.SetLinkCheckAcceptLanguage(config.AcceptLanguage)
+	}
 
 	return &analyzerService{
-		httpClient: httpClient,
+		httpClient: withAllowedDomains(withAcceptLanguage(httpClient, config.AcceptLanguage), config.AllowedDomains),
 		parser:     parser,
 		semaphore:  make(chan struct{}, config.MaxConcurrentLinkChecks),
 		config:     config,
@@ -113,32 +424,51 @@ func NewAnalyzerService(httpClient HTTPClient, parser HTMLParser, config *Analyz
 }
 
 func (s *analyzerService) ValidateURL(targetURL string) error {
+	return withKind(ErrInvalidURL, s.validateURL(targetURL))
+}
+
+func (s *analyzerService) validateURL(targetURL string) error {
 	if targetURL == "" {
-		return fmt.Errorf("URL cannot be empty")
+		return invalidURL(ValidationReasonEmpty, fmt.Errorf("URL cannot be empty"))
+	}
+
+	if isDataURL(targetURL) {
+		if !s.config.AllowDataURLs {
+			return invalidURL(ValidationReasonBadScheme, fmt.Errorf("data URLs are not enabled"))
+		}
+		if _, _, err := decodeDataURL(targetURL); err != nil {
+			return invalidURL(ValidationReasonParseError, err)
+		}
+		return nil
 	}
 
 	if len(targetURL) > s.config.MaxURLLength {
-		return fmt.Errorf("URL too long (max %d characters)", s.config.MaxURLLength)
+		return invalidURL(ValidationReasonTooLong, fmt.Errorf("URL too long (max %d characters)", s.config.MaxURLLength))
 	}
 
 	u, err := url.Parse(targetURL)
 	if err != nil {
-		return fmt.Errorf("invalid URL format: %w", err)
+		return invalidURL(ValidationReasonParseError, fmt.Errorf("invalid URL format: %w", err))
 	}
 
 	if u.Scheme == "" || u.Host == "" {
-		return fmt.Errorf("URL must include scheme and host")
+		return invalidURL(ValidationReasonNoHost, fmt.Errorf("URL must include scheme and host"))
 	}
 
-	if !contains(SupportedSchemes, u.Scheme) {
-		return fmt.Errorf("only %v schemes are supported", SupportedSchemes)
+	if !contains(s.config.AllowedSchemes, u.Scheme) {
+		return invalidURL(ValidationReasonBadScheme, fmt.Errorf("only %v schemes are supported", s.config.AllowedSchemes))
 	}
 
 	// allow localhost for testing
 	_ = u.Hostname()
 
 	if strings.Contains(u.Hostname(), "..") {
-		return fmt.Errorf("invalid hostname format")
+		return invalidURL(ValidationReasonNoHost, fmt.Errorf("invalid hostname format"))
+	}
+
+	if len(s.config.AllowedDomains) > 0 && !matchesHost(u.Hostname(), s.config.AllowedDomains) {
+		return invalidURL(ValidationReasonNotAllowed,
+			withKind(ErrDomainNotAllowed, fmt.Errorf("host %s is not in the allowed domains", u.Hostname())))
 	}
 
 	return nil
@@ -149,8 +479,11 @@ func (s *analyzerService) createDetailedError(err error, targetURL string) error
 		return nil
 	}
 
+	// error messages end up in logs and API responses; never echo credentials
+	targetURL = withoutUserinfo(targetURL)
+
 	if strings.Contains(err.Error(), "context deadline exceeded") {
-		return fmt.Errorf("context deadline exceeded")
+		return withKind(ErrTargetTimeout, fmt.Errorf("context deadline exceeded"))
 	}
 
 	if strings.Contains(err.Error(), "context canceled") {
@@ -160,42 +493,54 @@ func (s *analyzerService) createDetailedError(err error, targetURL string) error
 	switch e := err.(type) {
 	case *url.Error:
 		if e.Timeout() {
-			return fmt.Errorf("connection timeout exceeded")
+			return withKind(ErrTargetTimeout, fmt.Errorf("connection timeout exceeded"))
 		}
 		if netErr, ok := e.Err.(net.Error); ok {
 			if netErr.Timeout() {
-				return fmt.Errorf("connection timeout exceeded while accessing %s", targetURL)
+				return withKind(ErrTargetTimeout, fmt.Errorf("connection timeout exceeded while accessing %s", targetURL))
 			}
 		}
 		return s.classifyNetworkError(e.Error(), targetURL)
 
 	case net.Error:
 		if e.Timeout() {
-			return fmt.Errorf("connection timeout exceeded")
+			return withKind(ErrTargetTimeout, fmt.Errorf("connection timeout exceeded"))
 		}
-		return fmt.Errorf("network error: %v", e)
+		return withKind(ErrTargetUnreachable, fmt.Errorf("network error: %v", e))
 
 	default:
 		return s.classifyNetworkError(err.Error(), targetURL)
 	}
 }
 
+// withoutUserinfo drops any user:pass@ part from targetURL.
+func withoutUserinfo(targetURL string) string {
+	u, err := url.Parse(targetURL)
+	if err != nil || u.User == nil {
+		return targetURL
+	}
+	u.User = nil
+	return u.String()
+}
+
 func (s *analyzerService) classifyNetworkError(errorMsg, targetURL string) error {
 	errorMsg = strings.ToLower(errorMsg)
 
 	switch {
 	case strings.Contains(errorMsg, "no such host") || strings.Contains(errorMsg, "name resolution"):
-		return fmt.Errorf("domain not found: %s", targetURL)
+		return withKind(ErrTargetUnreachable, fmt.Errorf("domain not found: %s", targetURL))
 	case strings.Contains(errorMsg, "connection refused"):
-		return fmt.Errorf("connection refused by server: %s", targetURL)
+		return withKind(ErrTargetUnreachable, fmt.Errorf("connection refused by server: %s", targetURL))
 	case strings.Contains(errorMsg, "network is unreachable"):
-		return fmt.Errorf("network is unreachable: %s", targetURL)
+		return withKind(ErrTargetUnreachable, fmt.Errorf("network is unreachable: %s", targetURL))
 	case strings.Contains(errorMsg, "timeout"):
-		return fmt.Errorf("connection timeout exceeded while accessing %s", targetURL)
+		return withKind(ErrTargetTimeout, fmt.Errorf("connection timeout exceeded while accessing %s", targetURL))
+	case strings.Contains(errorMsg, "protocol version"):
+		return withKind(ErrTargetUnreachable, fmt.Errorf("%s does not support TLS %s or newer", targetURL, minTLSVersionName(s.config.MinTLSVersion)))
 	case strings.Contains(errorMsg, "tls") || strings.Contains(errorMsg, "certificate"):
-		return fmt.Errorf("SSL/TLS error while accessing %s", targetURL)
+		return withKind(ErrTargetUnreachable, fmt.Errorf("SSL/TLS error while accessing %s", targetURL))
 	default:
-		return fmt.Errorf("network error while accessing %s: %s", targetURL, errorMsg)
+		return withKind(ErrTargetUnreachable, fmt.Errorf("network error while accessing %s: %s", targetURL, errorMsg))
 	}
 }
 
@@ -206,12 +551,23 @@ func (s *analyzerService) AnalyzeURL(ctx context.Context, targetURL string) (*en
 		return nil, fmt.Errorf("URL validation failed: %w", err)
 	}
 
-	// change timeout here if needed
-	requestCtx, cancel := context.WithTimeout(ctx, DefaultRequestTimeout)
+	if isDataURL(targetURL) {
+		content, contentType, err := decodeDataURL(targetURL)
+		if err != nil {
+			return nil, withKind(ErrInvalidURL, err)
+		}
+		return s.analyzeContent(ctx, content, contentType, targetURL, http.StatusOK, startTime, time.Since(startTime))
+	}
+
+	requestCtx, cancel := fetchContext(ctx)
 	defer cancel()
 
-	resp, err := s.httpClient.GetWithContext(requestCtx, targetURL)
+	resp, err := s.fetchWithRetry(requestCtx, targetURL)
 	if err != nil {
+		var urlErr *url.Error
+		if errors.Is(err, ErrDomainNotAllowed) && errors.As(err, &urlErr) {
+			return nil, urlErr.Err
+		}
 		return nil, s.createDetailedError(err, targetURL)
 	}
 	defer func() {
@@ -221,12 +577,27 @@ func (s *analyzerService) AnalyzeURL(ctx context.Context, targetURL string) (*en
 		}
 	}()
 
+	if len(s.config.AllowedDomains) > 0 && resp.Request != nil && !matchesHost(resp.Request.URL.Hostname(), s.config.AllowedDomains) {
+		return nil, withKind(ErrDomainNotAllowed, fmt.Errorf("redirect to host %s is not in the allowed domains", resp.Request.URL.Hostname()))
+	}
+
+	if resp.StatusCode == http.StatusNotModified {
+		loadTime := time.Since(startTime)
+		return &entities.AnalysisResult{
+			StatusCode: resp.StatusCode,
+			LoadTime:   loadTime,
+			FetchTime:  loadTime,
+		}, ErrNotModified
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		errorMsg := s.getHTTPStatusMessage(resp.StatusCode)
+		loadTime := time.Since(startTime)
 		return &entities.AnalysisResult{
 			StatusCode: resp.StatusCode,
-			LoadTime:   time.Since(startTime),
-		}, fmt.Errorf("HTTP %d: %s", resp.StatusCode, errorMsg)
+			LoadTime:   loadTime,
+			FetchTime:  loadTime,
+		}, &TargetStatusError{StatusCode: resp.StatusCode, Message: errorMsg}
 	}
 
 	content, err := io.ReadAll(io.LimitReader(resp.Body, MaxContentSize))
@@ -234,23 +605,255 @@ func (s *analyzerService) AnalyzeURL(ctx context.Context, targetURL string) (*en
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	parsed, err := s.parser.Parse(string(content), targetURL)
+	result, err := s.analyzeContent(ctx, content, resp.Header.Get("Content-Type"), targetURL, resp.StatusCode, startTime, time.Since(startTime))
+	if err != nil {
+		return nil, err
+	}
+	s.captureResponseHeaders(result, resp.Header)
+	result.SecurityHeaders = gradeSecurityHeaders(resp.Header)
+	result.ETag = resp.Header.Get(HeaderETag)
+	result.LastModified = resp.Header.Get(HeaderLastModified)
+	return result, nil
+}
+
+// captureResponseHeaders copies the configured response headers into the
+// result, joining repeated values and capping each at the metadata value
+// limit.
+func (s *analyzerService) captureResponseHeaders(result *entities.AnalysisResult, header http.Header) {
+	for _, name := range s.config.CaptureResponseHeaders {
+		values := header.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+		value, _ := truncateText(strings.Join(values, ", "), entities.MaxMetadataValueLength)
+		if result.ResponseHeaders == nil {
+			result.ResponseHeaders = make(map[string]string)
+		}
+		result.ResponseHeaders[strings.ToLower(name)] = value
+	}
+}
+
+// analyzeContent parses a fetched or decoded page body and builds the result.
+// fetchTime is how long getting content took, out of the time since startTime.
+func (s *analyzerService) analyzeContent(ctx context.Context, content []byte, contentType, targetURL string, statusCode int, startTime time.Time, fetchTime time.Duration) (*entities.AnalysisResult, error) {
+	contentHash := hashContent(content)
+
+	// a successful but empty page is a thin page, not a failure
+	if len(strings.TrimSpace(string(content))) == 0 {
+		return s.withSource(&entities.AnalysisResult{
+			Headings: make(map[string]int),
+			Links: entities.LinkAnalysis{
+				BrokenLinks:   make([]string, 0),
+				ExternalHosts: make([]string, 0),
+			},
+			LoadTime:      time.Since(startTime),
+			FetchTime:     fetchTime,
+			ContentLength: int64(len(content)),
+			ContentHash:   contentHash,
+			StatusCode:    statusCode,
+			Note:          NoteEmptyBody,
+			Warnings:      qualityWarnings("", nil),
+		}, content), nil
+	}
+
+	parseStart := time.Now()
+	parsed, err := s.parser.Parse(string(decodeToUTF8(content, contentType)), targetURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse HTML: %w", err)
 	}
+	parseTime := time.Since(parseStart)
+
+	linkCheckStart := time.Now()
+	linkAnalysis := s.analyzeLinkAccessibility(ctx, parsed.Links, targetURL)
+	linkCheckTime := time.Since(linkCheckStart)
+
+	warnings := append(qualityWarnings(parsed.Title, parsed.Headings), securityWarnings(parsed.PasswordAutocomplete)...)
+	if parsed.HeadingsTruncated {
+		warnings = append(warnings, WarningHeadingsTruncated)
+	}
+	if parsed.LowConfidence {
+		warnings = append(warnings, WarningNotHTML)
+	}
+	if parsed.InvalidJSONLDBlocks > 0 {
+		warnings = append(warnings, WarningInvalidStructuredData)
+	}
+
+	return s.withSource(&entities.AnalysisResult{
+		HTMLVersion:             parsed.HTMLVersion,
+		Title:                   parsed.Title,
+		TitleTruncated:          parsed.TitleTruncated,
+		Description:             parsed.Description,
+		DescriptionTruncated:    parsed.DescriptionTruncated,
+		Headings:                parsed.Headings,
+		HeadingOutline:          parsed.HeadingOutline,
+		Links:                   linkAnalysis,
+		HasLoginForm:            parsed.HasLoginForm,
+		Forms:                   parsed.Forms,
+		DeprecatedElements:      parsed.DeprecatedElements,
+		RenderBlockingResources: parsed.RenderBlockingResources,
+		Trackers:                parsed.Trackers,
+		DetectedLanguages:       parsed.Languages,
+		LowConfidence:           parsed.LowConfidence,
+		StructuredData:          parsed.StructuredData,
+		Warnings:                warnings,
+		LoadTime:                time.Since(startTime),
+		FetchTime:               fetchTime,
+		ParseTime:               parseTime,
+		LinkCheckTime:           linkCheckTime,
+		ContentLength:           parsed.ContentLength,
+		ContentHash:             contentHash,
+		StatusCode:              statusCode,
+	}, content), nil
+}
 
-	linkAnalysis := s.analyzeLinkAccessibility(ctx, parsed.Links)
+// withSource attaches the raw content to result, capped at
+// SourceCaptureMaxBytes, when source capture is enabled.
+func (s *analyzerService) withSource(result *entities.AnalysisResult, content []byte) *entities.AnalysisResult {
+	maxBytes := s.config.SourceCaptureMaxBytes
+	if maxBytes <= 0 {
+		return result
+	}
 
-	return &entities.AnalysisResult{
-		HTMLVersion:   parsed.HTMLVersion,
-		Title:         parsed.Title,
-		Headings:      parsed.Headings,
-		Links:         linkAnalysis,
-		HasLoginForm:  parsed.HasLoginForm,
-		LoadTime:      time.Since(startTime),
-		ContentLength: parsed.ContentLength,
-		StatusCode:    resp.StatusCode,
-	}, nil
+	if len(content) > maxBytes {
+		content = content[:maxBytes]
+		result.SourceTruncated = true
+	}
+	result.Source = append([]byte(nil), content...)
+	return result
+}
+
+// qualityWarnings flags basic SEO issues: missing title and anything other
+// than exactly one h1.
+func qualityWarnings(title string, headings map[string]int) []string {
+	warnings := make([]string, 0)
+
+	switch h1 := headings[HTMLElementH1]; {
+	case h1 > 1:
+		warnings = append(warnings, WarningMultipleH1)
+	case h1 == 0:
+		warnings = append(warnings, WarningNoH1)
+	}
+
+	if title == "" {
+		warnings = append(warnings, WarningNoTitle)
+	}
+
+	return warnings
+}
+
+// securityWarnings flags password fields that browsers may autofill, given
+// the effective autocomplete of each; only "off" or "new-password" opts a
+// password field out.
+func securityWarnings(passwordAutocomplete []string) []string {
+	for _, autocomplete := range passwordAutocomplete {
+		switch autocomplete {
+		case AutocompleteOff, AutocompleteNewPassword:
+		default:
+			return []string{WarningPasswordAutocomplete}
+		}
+	}
+	return nil
+}
+
+// hashContent returns the hex-encoded SHA-256 of the raw response body so
+// clients can tell whether a page changed between analyses.
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// decodeToUTF8 transcodes content to UTF-8 using the charset from the
+// Content-Type header, a BOM or a <meta> declaration. Content without a
+// declared charset is assumed to already be UTF-8.
+func decodeToUTF8(content []byte, contentType string) []byte {
+	enc, name, certain := charset.DetermineEncoding(content, contentType)
+	if !certain && !declaresMetaCharset(content) {
+		return content
+	}
+	if name == "utf-8" {
+		return content
+	}
+
+	decoded, err := enc.NewDecoder().Bytes(content)
+	if err != nil {
+		return content
+	}
+	return decoded
+}
+
+// declaresMetaCharset reports whether the start of the document carries a
+// <meta charset> or <meta http-equiv="Content-Type"> declaration.
+func declaresMetaCharset(content []byte) bool {
+	if len(content) > charsetPrescanSize {
+		content = content[:charsetPrescanSize]
+	}
+
+	tokenizer := html.NewTokenizer(strings.NewReader(string(content)))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return false
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			if token.Data != HTMLElementMeta {
+				continue
+			}
+			for _, attr := range token.Attr {
+				if attr.Key == "charset" ||
+					(attr.Key == "http-equiv" && strings.EqualFold(attr.Val, "content-type")) {
+					return true
+				}
+			}
+		}
+	}
+}
+
+// fetchWithRetry retries clearly transient failures (connection resets,
+// temporary DNS errors, 502/503/504) with exponential backoff. 4xx responses
+// and unknown hosts are returned immediately.
+func (s *analyzerService) fetchWithRetry(ctx context.Context, targetURL string) (*http.Response, error) {
+	backoff := s.config.FetchRetryBackoff
+
+	for attempt := 0; ; attempt++ {
+		resp, err := s.httpClient.GetWithContext(ctx, targetURL)
+		if attempt >= s.config.MaxFetchRetries || !isTransientFetchFailure(resp, err) {
+			return resp, err
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+func isTransientFetchFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) {
+			return !dnsErr.IsNotFound && (dnsErr.IsTemporary || dnsErr.IsTimeout)
+		}
+
+		if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return true
+		}
+
+		return strings.Contains(strings.ToLower(err.Error()), "connection reset")
+	}
+
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
 }
 
 func (s *analyzerService) getHTTPStatusMessage(statusCode int) string {
@@ -272,82 +875,301 @@ func (s *analyzerService) getHTTPStatusMessage(statusCode int) string {
 	}
 }
 
-func (s *analyzerService) analyzeLinkAccessibility(ctx context.Context, links []Link) entities.LinkAnalysis {
+// linkCheckConcurrency is how many of a page's links may be checked at once.
+// Without AdaptiveLinkChecks it is always MaxConcurrentLinkChecks; with it,
+// it grows linearly with the link count between the configured bounds.
+func (s *analyzerService) linkCheckConcurrency(links int) int {
+	maxChecks := s.config.MaxConcurrentLinkChecks
+	fullScale := s.config.MaxLinksToCheck
+	if !s.config.AdaptiveLinkChecks || fullScale <= 0 || links >= fullScale {
+		return maxChecks
+	}
+
+	minChecks := s.config.MinConcurrentLinkChecks
+	return minChecks + (maxChecks-minChecks)*links/fullScale
+}
+
+func (s *analyzerService) analyzeLinkAccessibility(ctx context.Context, links []Link, baseURL string) entities.LinkAnalysis {
 	analysis := entities.LinkAnalysis{
 		BrokenLinks:   make([]string, 0),
 		ExternalHosts: make([]string, 0),
 	}
 
 	hostMap := make(map[string]bool)
-	var mu sync.Mutex
 
+	analysis.LinksFound = len(links)
 	if len(links) > s.config.MaxLinksToCheck {
 		links = links[:s.config.MaxLinksToCheck]
 	}
 
-	var wg sync.WaitGroup
-	for _, link := range links {
-		wg.Add(1)
-		go func(l Link) {
-			defer wg.Done()
+	checked := s.checkLinks(ctx, links, baseURL)
+	for i, l := range links {
+		// links still queued when ctx ended are left out
+		if !checked[i] {
+			continue
+		}
 
-			select {
-			case s.semaphore <- struct{}{}:
-				defer func() { <-s.semaphore }()
-			case <-ctx.Done():
-				return
+		if l.IsInternal {
+			analysis.Internal++
+		} else {
+			analysis.External++
+			if linkU, err := url.Parse(l.URL); err == nil && !hostMap[linkU.Host] {
+				analysis.ExternalHosts = append(analysis.ExternalHosts, linkU.Host)
+				hostMap[linkU.Host] = true
 			}
+		}
 
-			mu.Lock()
-			if l.IsInternal {
-				analysis.Internal++
-			} else {
-				analysis.External++
-				if linkU, err := url.Parse(l.URL); err == nil && !hostMap[linkU.Host] {
-					analysis.ExternalHosts = append(analysis.ExternalHosts, linkU.Host)
-					hostMap[linkU.Host] = true
+		if l.StatusCode != 0 {
+			if analysis.StatusCodes == nil {
+				analysis.StatusCodes = make(map[string]int)
+			}
+			analysis.StatusCodes[l.URL] = l.StatusCode
+		}
+
+		if l.Skipped {
+			analysis.Skipped++
+			analysis.SkippedLinks = append(analysis.SkippedLinks, l.URL)
+		} else if l.NotChecked {
+			analysis.NotCheckedLinks = append(analysis.NotCheckedLinks, l.URL)
+		} else {
+			analysis.LinksChecked++
+		}
+
+		if !l.Skipped && !l.NotChecked && !l.IsAccessible {
+			analysis.Inaccessible++
+			analysis.BrokenLinks = append(analysis.BrokenLinks, l.URL)
+			if l.Reason != "" {
+				if analysis.BrokenLinkReasons == nil {
+					analysis.BrokenLinkReasons = make(map[string]string)
 				}
+				analysis.BrokenLinkReasons[l.URL] = l.Reason
 			}
+		}
+	}
+
+	return analysis
+}
 
-			if !l.IsAccessible {
-				analysis.Inaccessible++
-				analysis.BrokenLinks = append(analysis.BrokenLinks, l.URL)
+// checkLinks fills in the accessibility of links on a pool of
+// linkCheckConcurrency workers. Each check also holds a slot of the
+// semaphore shared by all pages, so the per-page pool never exceeds the
+// global limit. The returned slice marks which links were judged.
+func (s *analyzerService) checkLinks(ctx context.Context, links []Link, baseURL string) []bool {
+	checked := make([]bool, len(links))
+	if len(links) == 0 {
+		return checked
+	}
+
+	ctx = withSharedCacheScope(ctx)
+	started := time.Now()
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(s.linkCheckConcurrency(len(links)), len(links)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				checked[i] = s.checkLink(ctx, &links[i], baseURL, started)
 			}
-			mu.Unlock()
-		}(link)
+		}()
 	}
 
-	done := make(chan struct{})
-	go func() {
-		wg.Wait()
-		close(done)
-	}()
+enqueue:
+	for i := range links {
+		select {
+		case queue <- i:
+		case <-ctx.Done():
+			break enqueue
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	return checked
+}
+
+// checkLink judges one link in place and reports whether it was judged.
+// Links reached after LinkCheckBudget, measured from started, are reported
+// as not checked.
+func (s *analyzerService) checkLink(ctx context.Context, link *Link, baseURL string, started time.Time) bool {
+	if link.Skipped || link.Reason != "" {
+		return true
+	}
+	if s.config.LinkCheckBudget > 0 && time.Since(started) >= s.config.LinkCheckBudget {
+		link.IsAccessible = true
+		link.NotChecked = true
+		return true
+	}
 
 	select {
-	case <-done:
+	case s.semaphore <- struct{}{}:
+		defer func() { <-s.semaphore }()
 	case <-ctx.Done():
+		return false
 	}
 
-	return analysis
+	check := s.parser.CheckLink(ctx, link.URL, baseURL)
+	link.IsAccessible, link.StatusCode, link.NotChecked = check.Accessible, check.StatusCode, check.NotChecked
+	return ctx.Err() == nil
 }
 
 type htmlParser struct {
-	httpClient       HTTPClient
-	urlCache         map[string]bool
-	mu               sync.RWMutex
-	linkCheckTimeout time.Duration
+	httpClient           HTTPClient
+	urlCache             map[string]LinkCheck
+	sharedCache          LinkCheckCache
+	mu                   sync.RWMutex
+	linkCheckTimeout     time.Duration
+	allowedSchemes       []string
+	subdomainsAsInternal bool
+	maxNodes             int
+	maxTitleLength       int
+	maxHeadings          int
+	skipHosts            []string
+	maxLinkRedirects     int
+	transport            TransportConfig
+	acceptLanguage       string
 }
 
 func NewHTMLParser(httpClient HTTPClient) HTMLParser {
+	return newHTMLParser(httpClient)
+}
+
+// NewHTMLParserOfKind returns the parser named by kind: HTMLParserTree (or
+// "") for the DOM-based parser, HTMLParserStreaming for the tokenizer one.
+func NewHTMLParserOfKind(kind string, httpClient HTTPClient) (HTMLParser, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "", HTMLParserTree:
+		return NewHTMLParser(httpClient), nil
+	case HTMLParserStreaming:
+		return NewStreamingHTMLParser(httpClient), nil
+	default:
+		return nil, fmt.Errorf("unknown HTML parser %q", kind)
+	}
+}
+
+func newHTMLParser(httpClient HTTPClient) *htmlParser {
 	return &htmlParser{
 		httpClient:       httpClient,
-		urlCache:         make(map[string]bool),
+		urlCache:         make(map[string]LinkCheck),
 		linkCheckTimeout: DefaultLinkCheckTimeout,
+		allowedSchemes:   SupportedSchemes,
+		maxNodes:         DefaultMaxHTMLNodes,
+		maxTitleLength:   DefaultMaxTitleLength,
+		maxHeadings:      DefaultMaxHeadings,
 	}
 }
 
 func (p *htmlParser) SetLinkCheckTimeout(timeout time.Duration) {
-	p.linkCheckTimeout = timeout
+	if timeout > 0 {
+		p.linkCheckTimeout = timeout
+	}
+}
+
+func (p *htmlParser) SetAllowedSchemes(schemes []string) {
+	p.allowedSchemes = schemes
+}
+
+func (p *htmlParser) SetTreatSubdomainsAsInternal(enabled bool) {
+	p.subdomainsAsInternal = enabled
+}
+
+func (p *htmlParser) SetMaxNodes(maxNodes int) {
+	if maxNodes > 0 {
+		p.maxNodes = maxNodes
+	}
+}
+
+func (p *htmlParser) SetMaxTitleLength(maxLength int) {
+	if maxLength > 0 {
+		p.maxTitleLength = maxLength
+	}
+}
+
+func (p *htmlParser) SetMaxHeadings(maxHeadings int) {
+	if maxHeadings > 0 {
+		p.maxHeadings = maxHeadings
+	}
+}
+
+func (p *htmlParser) SetLinkCheckSkipHosts(hosts []string) {
+	p.skipHosts = normalizeHosts(hosts)
+}
+
+// normalizeHosts lower-cases hosts and drops surrounding dots and blanks
+// so they can be compared with matchesHost.
+func normalizeHosts(hosts []string) []string {
+	normalized := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		if host = strings.Trim(strings.ToLower(strings.TrimSpace(host)), "."); host != "" {
+			normalized = append(normalized, host)
+		}
+	}
+	return normalized
+}
+
+// matchesHost reports whether host is one of the normalized hosts or a
+// subdomain of one; matching is on label boundaries, so "notexample.com"
+// does not match "example.com".
+func matchesHost(host string, hosts []string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" {
+		return false
+	}
+	for _, candidate := range hosts {
+		if host == candidate || strings.HasSuffix(host, "."+candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *htmlParser) SetTransport(config TransportConfig) {
+	p.transport = config
+}
+
+func (p *htmlParser) SetLinkCheckAcceptLanguage(acceptLanguage string) {
+	p.acceptLanguage = strings.TrimSpace(acceptLanguage)
+}
+
+func (p *htmlParser) SetLinkCheckMaxRedirects(maxRedirects int) {
+	if maxRedirects < 0 {
+		maxRedirects = 0
+	}
+	p.maxLinkRedirects = maxRedirects
+}
+
+// checkNodeCount streams through the document with a tokenizer and aborts as
+// soon as the node limit is exceeded, before html.Parse builds the full tree.
+// It returns how many tags and doctypes the source actually contains, since
+// html.Parse synthesizes html, head and body even for plain text.
+func (p *htmlParser) checkNodeCount(content string) (int, error) {
+	tokenizer := html.NewTokenizer(strings.NewReader(content))
+	nodes := 0
+	markup := 0
+
+	for {
+		switch token := tokenizer.Next(); token {
+		case html.ErrorToken:
+			return markup, nil
+		case html.StartTagToken, html.SelfClosingTagToken, html.TextToken, html.CommentToken, html.DoctypeToken:
+			nodes++
+			if nodes > p.maxNodes {
+				return markup, &NodeLimitExceededError{Limit: p.maxNodes}
+			}
+			if token != html.TextToken && token != html.CommentToken {
+				markup++
+			}
+		}
+	}
+}
+
+// isLowConfidence reports whether parsing found nothing recognizable in
+// content that either has no markup or looks binary.
+func isLowConfidence(content string, markup int, parsed *ParsedHTML) bool {
+	if parsed.Title != "" || len(parsed.HeadingOutline) > 0 || len(parsed.Links) > 0 {
+		return false
+	}
+	return markup == 0 || strings.ContainsRune(content, 0) || !utf8.ValidString(content)
 }
 
 func (p *htmlParser) Parse(content string, baseURL string) (*ParsedHTML, error) {
@@ -359,6 +1181,11 @@ func (p *htmlParser) Parse(content string, baseURL string) (*ParsedHTML, error)
 		return nil, fmt.Errorf("HTML content too large (max %d bytes)", MaxContentSize)
 	}
 
+	markup, err := p.checkNodeCount(content)
+	if err != nil {
+		return nil, err
+	}
+
 	doc, err := html.Parse(strings.NewReader(content))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse HTML: %w", err)
@@ -371,46 +1198,63 @@ func (p *htmlParser) Parse(content string, baseURL string) (*ParsedHTML, error)
 	}
 
 	parsed.HTMLVersion = p.extractHTMLVersion(doc)
-	parsed.Title = p.extractTitle(doc)
-	parsed.Headings = p.extractHeadings(doc)
-	parsed.Links = p.extractLinks(doc, baseURL)
+	parsed.Title, parsed.TitleTruncated = truncateText(p.extractTitle(doc), p.maxTitleLength)
+	parsed.Description, parsed.DescriptionTruncated = truncateText(p.extractDescription(doc), p.maxTitleLength)
+	parsed.Headings, parsed.HeadingOutline, parsed.HeadingsTruncated = p.extractHeadings(doc)
+	parsed.Links = p.extractLinks(doc, baseURL, p.collectAnchorTargets(doc))
 	parsed.HasLoginForm = p.hasLoginForm(doc)
+	parsed.Forms = p.extractForms(doc)
+	parsed.PasswordAutocomplete = p.extractPasswordAutocomplete(doc)
+	parsed.DeprecatedElements = p.extractDeprecatedElements(doc)
+	parsed.StructuredData, parsed.InvalidJSONLDBlocks = p.extractStructuredData(doc)
+	parsed.RenderBlockingResources = p.countRenderBlockingResources(doc)
+	parsed.Trackers = p.extractTrackers(doc)
+	parsed.Languages = p.extractLanguages(doc)
+	parsed.LowConfidence = isLowConfidence(content, markup, parsed)
 
 	return parsed, nil
 }
 
-func (p *htmlParser) extractHTMLVersion(doc *html.Node) string {
-	doctypeMap := map[string]string{
-		"html 4.01 strict":        "HTML 4.01 Strict",
-		"html 4.01//strict":       "HTML 4.01 Strict",
-		"html 4.01 transitional":  "HTML 4.01 Transitional",
-		"html 4.01//transitional": "HTML 4.01 Transitional",
-		"html 4.01 frameset":      "HTML 4.01 Frameset",
-		"html 4.01//frameset":     "HTML 4.01 Frameset",
-		"html 4.0":                "HTML 4.0",
-		"html 3.2":                "HTML 3.2",
-		"html 2.0":                "HTML 2.0",
-		"xhtml 1.1":               "XHTML 1.1",
-		"xhtml 1.0 strict":        "XHTML 1.0 Strict",
-		"xhtml 1.0//strict":       "XHTML 1.0 Strict",
-		"xhtml 1.0 transitional":  "XHTML 1.0 Transitional",
-		"xhtml 1.0//transitional": "XHTML 1.0 Transitional",
-		"xhtml 1.0 frameset":      "XHTML 1.0 Frameset",
-		"xhtml 1.0//frameset":     "XHTML 1.0 Frameset",
-		"xhtml basic":             "XHTML Basic",
-		"xhtml mobile":            "XHTML Mobile Profile",
-		"xhtml":                   "XHTML",
-		"html":                    "HTML",
+var doctypeVersions = map[string]string{
+	"html 4.01 strict":        "HTML 4.01 Strict",
+	"html 4.01//strict":       "HTML 4.01 Strict",
+	"html 4.01 transitional":  "HTML 4.01 Transitional",
+	"html 4.01//transitional": "HTML 4.01 Transitional",
+	"html 4.01 frameset":      "HTML 4.01 Frameset",
+	"html 4.01//frameset":     "HTML 4.01 Frameset",
+	"html 4.0":                "HTML 4.0",
+	"html 3.2":                "HTML 3.2",
+	"html 2.0":                "HTML 2.0",
+	"xhtml 1.1":               "XHTML 1.1",
+	"xhtml 1.0 strict":        "XHTML 1.0 Strict",
+	"xhtml 1.0//strict":       "XHTML 1.0 Strict",
+	"xhtml 1.0 transitional":  "XHTML 1.0 Transitional",
+	"xhtml 1.0//transitional": "XHTML 1.0 Transitional",
+	"xhtml 1.0 frameset":      "XHTML 1.0 Frameset",
+	"xhtml 1.0//frameset":     "XHTML 1.0 Frameset",
+	"xhtml basic":             "XHTML Basic",
+	"xhtml mobile":            "XHTML Mobile Profile",
+	"xhtml":                   "XHTML",
+	"html":                    "HTML",
+}
+
+// doctypeVersion maps a doctype name to an HTML version, or "" if unknown.
+func doctypeVersion(name string) string {
+	doctype := strings.ToLower(name)
+	for pattern, version := range doctypeVersions {
+		if strings.Contains(doctype, pattern) {
+			return version
+		}
 	}
+	return ""
+}
 
+func (p *htmlParser) extractHTMLVersion(doc *html.Node) string {
 	var findDoctype func(*html.Node) string
 	findDoctype = func(n *html.Node) string {
 		if n.Type == html.DoctypeNode {
-			doctype := strings.ToLower(n.Data)
-			for pattern, version := range doctypeMap {
-				if strings.Contains(doctype, pattern) {
-					return version
-				}
+			if version := doctypeVersion(n.Data); version != "" {
+				return version
 			}
 		}
 		for c := n.FirstChild; c != nil; c = c.NextSibling {
@@ -449,17 +1293,73 @@ func (p *htmlParser) extractTitle(doc *html.Node) string {
 	return findTitle(doc)
 }
 
-func (p *htmlParser) extractHeadings(doc *html.Node) map[string]int {
+func (p *htmlParser) extractDescription(doc *html.Node) string {
+	var findDescription func(*html.Node) string
+	findDescription = func(n *html.Node) string {
+		if n.Type == html.ElementNode && n.Data == HTMLElementMeta {
+			if description := metaDescription(n.Attr); description != "" {
+				return description
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if description := findDescription(c); description != "" {
+				return description
+			}
+		}
+		return ""
+	}
+	return findDescription(doc)
+}
+
+// metaDescription returns the content of a <meta name="description">, or ""
+// for any other meta element.
+func metaDescription(attrs []html.Attribute) string {
+	var name, content string
+	for _, attr := range attrs {
+		switch attr.Key {
+		case HTMLAttrName:
+			name = attr.Val
+		case HTMLAttrContent:
+			content = attr.Val
+		}
+	}
+	if strings.EqualFold(name, MetaNameDescription) {
+		return strings.TrimSpace(content)
+	}
+	return ""
+}
+
+// truncateText cuts text to maxLength characters, reporting whether it did.
+func truncateText(text string, maxLength int) (string, bool) {
+	if maxLength <= 0 || utf8.RuneCountInString(text) <= maxLength {
+		return text, false
+	}
+	return string([]rune(text)[:maxLength]), true
+}
+
+// extractHeadings counts headings per level and collects their text in
+// document order, stopping once maxHeadings have been seen.
+func (p *htmlParser) extractHeadings(doc *html.Node) (map[string]int, []entities.HeadingNode, bool) {
 	headings := make(map[string]int)
+	outline := make([]entities.HeadingNode, 0)
+	truncated := false
 	var traverse func(*html.Node, int)
 	traverse = func(n *html.Node, depth int) {
-		if depth > MaxHTMLDepth {
+		if depth > MaxHTMLDepth || truncated {
 			return
 		}
 		if n.Type == html.ElementNode {
 			switch n.Data {
 			case HTMLElementH1, HTMLElementH2, HTMLElementH3, HTMLElementH4, HTMLElementH5, HTMLElementH6:
+				if len(outline) >= p.maxHeadings {
+					truncated = true
+					return
+				}
 				headings[n.Data]++
+				outline = append(outline, entities.HeadingNode{
+					Level: int(n.Data[1] - '0'),
+					Text:  nodeText(n),
+				})
 			}
 		}
 		for c := n.FirstChild; c != nil; c = c.NextSibling {
@@ -467,10 +1367,71 @@ func (p *htmlParser) extractHeadings(doc *html.Node) map[string]int {
 		}
 	}
 	traverse(doc, 0)
-	return headings
+	return headings, outline, truncated
 }
 
-func (p *htmlParser) extractLinks(doc *html.Node, baseURL string) []Link {
+// nodeText concatenates all text beneath n, including nested inline
+// elements, with whitespace collapsed.
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
+		}
+	}
+	collect(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// collectAnchorTargets gathers every element id and <a name> on the page so
+// same-page #fragment links can be checked against them.
+func (p *htmlParser) collectAnchorTargets(doc *html.Node) map[string]bool {
+	targets := make(map[string]bool)
+	var traverse func(*html.Node, int)
+	traverse = func(n *html.Node, depth int) {
+		if depth > MaxHTMLDepth {
+			return
+		}
+		if n.Type == html.ElementNode {
+			addAnchorTargets(targets, n.Data, n.Attr)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c, depth+1)
+		}
+	}
+	traverse(doc, 0)
+	return targets
+}
+
+func addAnchorTargets(targets map[string]bool, tag string, attrs []html.Attribute) {
+	for _, attr := range attrs {
+		if attr.Key == HTMLAttrID || (tag == HTMLElementA && attr.Key == HTMLAttrName) {
+			targets[attr.Val] = true
+		}
+	}
+}
+
+// hasAnchorTarget reports whether a same-page #fragment link resolves. An
+// empty fragment and #top always scroll to the top of the document.
+func hasAnchorTarget(href string, targets map[string]bool) bool {
+	fragment := strings.TrimPrefix(href, "#")
+	if fragment == "" || strings.EqualFold(fragment, "top") {
+		return true
+	}
+	if targets[fragment] {
+		return true
+	}
+	if decoded, err := url.PathUnescape(fragment); err == nil && targets[decoded] {
+		return true
+	}
+	return false
+}
+
+func (p *htmlParser) extractLinks(doc *html.Node, baseURL string, anchorTargets map[string]bool) []Link {
 	links := make([]Link, 0, 100)
 	var traverse func(*html.Node, int)
 
@@ -479,16 +1440,8 @@ func (p *htmlParser) extractLinks(doc *html.Node, baseURL string) []Link {
 			return
 		}
 		if n.Type == html.ElementNode && n.Data == HTMLElementA {
-			for _, attr := range n.Attr {
-				if attr.Key == HTMLAttrHref && attr.Val != "" {
-					link := Link{
-						URL:          attr.Val,
-						IsInternal:   p.isInternalLink(attr.Val, baseURL),
-						IsAccessible: p.checkLinkAccessibility(attr.Val, baseURL),
-					}
-					links = append(links, link)
-					break
-				}
+			if href := linkHref(n.Attr); href != "" {
+				links = append(links, p.buildLink(href, baseURL, anchorTargets))
 			}
 		}
 		for c := n.FirstChild; c != nil; c = c.NextSibling {
@@ -499,6 +1452,54 @@ func (p *htmlParser) extractLinks(doc *html.Node, baseURL string) []Link {
 	return links
 }
 
+// linkHref returns the first non-empty href of an anchor.
+func linkHref(attrs []html.Attribute) string {
+	for _, attr := range attrs {
+		if attr.Key == HTMLAttrHref && attr.Val != "" {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// buildLink classifies one href found on the page. Skipped links and
+// fragments without a target are settled here; the rest are left for
+// CheckLink.
+func (p *htmlParser) buildLink(href, baseURL string, anchorTargets map[string]bool) Link {
+	link := Link{
+		URL:        href,
+		IsInternal: p.isInternalLink(href, baseURL),
+	}
+	if p.isSkippedHost(href, baseURL) {
+		link.IsAccessible = true
+		link.Skipped = true
+	}
+	if strings.HasPrefix(href, "#") && !hasAnchorTarget(href, anchorTargets) {
+		link.IsAccessible = false
+		link.Reason = LinkReasonMissingAnchor
+	}
+	return link
+}
+
+// isSkippedHost reports whether href resolves to a host on the skip list,
+// matching by host suffix on label boundaries.
+func (p *htmlParser) isSkippedHost(href, baseURL string) bool {
+	if len(p.skipHosts) == 0 {
+		return false
+	}
+
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return false
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return false
+	}
+
+	return matchesHost(base.ResolveReference(ref).Hostname(), p.skipHosts)
+}
+
 func (p *htmlParser) isInternalLink(href string, baseURL string) bool {
 
 	if strings.HasPrefix(href, "#") || strings.HasPrefix(href, "?") {
@@ -526,18 +1527,43 @@ func (p *htmlParser) isInternalLink(href string, baseURL string) bool {
 	}
 
 	// Compare hosts - if they match, it's internal
-	return hrefURL.Host == baseURLParsed.Host
+	if hrefURL.Host == baseURLParsed.Host {
+		return true
+	}
+
+	if p.subdomainsAsInternal {
+		return sameRegistrableDomain(hrefURL.Hostname(), baseURLParsed.Hostname())
+	}
+
+	return false
 }
 
-func (p *htmlParser) checkLinkAccessibility(href string, baseURL string) bool {
+// sameRegistrableDomain reports whether both hosts share an eTLD+1, so
+// blog.example.com and www.example.com match but example.co.uk and
+// other.co.uk do not.
+func sameRegistrableDomain(hostA, hostB string) bool {
+	domainA, err := publicsuffix.EffectiveTLDPlusOne(strings.ToLower(hostA))
+	if err != nil {
+		return false
+	}
+	domainB, err := publicsuffix.EffectiveTLDPlusOne(strings.ToLower(hostB))
+	if err != nil {
+		return false
+	}
+	return domainA == domainB
+}
+
+// CheckLink reports whether href is reachable and, for links that were
+// fetched, the final HTTP status code.
+func (p *htmlParser) CheckLink(ctx context.Context, href string, baseURL string) LinkCheck {
 	if strings.HasPrefix(href, "#") || strings.HasPrefix(href, "?") ||
 		strings.HasPrefix(href, "mailto:") || strings.HasPrefix(href, "tel:") {
-		return true
+		return LinkCheck{Accessible: true}
 	}
 
 	hrefURL, err := url.Parse(href)
 	if err != nil {
-		return false
+		return LinkCheck{}
 	}
 
 	var fullURL string
@@ -545,14 +1571,14 @@ func (p *htmlParser) checkLinkAccessibility(href string, baseURL string) bool {
 	if hrefURL.Scheme == "" && hrefURL.Host == "" {
 		baseURLParsed, err := url.Parse(baseURL)
 		if err != nil {
-			return false
+			return LinkCheck{}
 		}
 		resolvedURL := baseURLParsed.ResolveReference(hrefURL)
 		fullURL = resolvedURL.String()
 	} else if strings.HasPrefix(href, "/") {
 		baseURLParsed, err := url.Parse(baseURL)
 		if err != nil {
-			return false
+			return LinkCheck{}
 		}
 		resolvedURL := &url.URL{
 			Scheme: baseURLParsed.Scheme,
@@ -561,58 +1587,62 @@ func (p *htmlParser) checkLinkAccessibility(href string, baseURL string) bool {
 		}
 		fullURL = resolvedURL.String()
 	} else {
-		if !contains(SupportedSchemes, hrefURL.Scheme) {
-			return true
+		// only allowed schemes the HTTP client can actually reach are checked
+		if !contains(p.allowedSchemes, hrefURL.Scheme) || !contains(SupportedSchemes, hrefURL.Scheme) {
+			return LinkCheck{Accessible: true}
 		}
 		fullURL = href
 	}
 
-	// check cache first
-	p.mu.RLock()
-	if accessible, exists := p.urlCache[fullURL]; exists {
-		p.mu.RUnlock()
-		return accessible
+	if cached, exists := p.cachedLinkCheck(ctx, fullURL); exists {
+		return cached
 	}
-	p.mu.RUnlock()
-
-	accessible := p.checkHTTPLink(fullURL, p.linkCheckTimeout)
 
-	// cache result
-	p.mu.Lock()
-	p.urlCache[fullURL] = accessible
-	p.mu.Unlock()
+	check := p.checkHTTPLink(ctx, fullURL, p.linkCheckTimeout)
+	// a check cut short by the caller says nothing about the link
+	if ctx.Err() == nil {
+		p.storeLinkCheck(ctx, fullURL, check)
+	}
 
-	return accessible
+	return check
 }
 
-func (p *htmlParser) checkHTTPLink(url string, timeout time.Duration) bool {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+// checkHTTPLink issues a HEAD request and treats the final 2xx or 3xx status
+// as accessible. Redirects are followed like a default http.Client unless
+// maxLinkRedirects caps them; a redirect beyond that cap leaves the target
+// unverified, so the link is reported as not checked.
+func (p *htmlParser) checkHTTPLink(ctx context.Context, url string, timeout time.Duration) LinkCheck {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, HTTPMethodHEAD, url, nil)
 	if err != nil {
-		return false
+		return LinkCheck{}
 	}
 
 	req.Header.Set("User-Agent", UserAgent)
 	req.Header.Set("Accept", "*/*")
-
-	client := &http.Client{
-		Timeout: timeout,
-		Transport: &http.Transport{
-			MaxIdleConns:        10,
-			MaxIdleConnsPerHost: 2,
-			IdleConnTimeout:     30 * time.Second,
-		},
+	setAcceptLanguage(req, p.acceptLanguage)
+
+	client := NewSharedHTTPClient(timeout, p.transport)
+	redirectCapped := false
+	if p.maxLinkRedirects > 0 {
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if len(via) > p.maxLinkRedirects {
+				redirectCapped = true
+				return http.ErrUseLastResponse
+			}
+			return nil
+		}
 	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return false
+		return LinkCheck{}
 	}
 
 	if resp == nil {
-		return false
+		return LinkCheck{}
 	}
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil {
@@ -622,30 +1652,105 @@ func (p *htmlParser) checkHTTPLink(url string, timeout time.Duration) bool {
 		}
 	}()
 
-	return resp.StatusCode >= 200 && resp.StatusCode < 400
+	if redirectCapped {
+		return LinkCheck{Accessible: true, StatusCode: resp.StatusCode, NotChecked: true}
+	}
+	return LinkCheck{Accessible: resp.StatusCode >= 200 && resp.StatusCode < 400, StatusCode: resp.StatusCode}
 }
 
-func (p *htmlParser) hasLoginForm(doc *html.Node) bool {
-	loginKeywords := map[string]bool{
-		"login": true, "signin": true, "sign-in": true, "sign_in": true, "log-in": true, "log_in": true,
-		"sign in": true, "log in": true, "logon": true, "log on": true,
-		"password": true, "passwd": true, "pwd": true, "pass": true,
-		"username": true, "userid": true, "user_id": true,
-		"authenticate": true, "authentication": true,
-		"credentials": true, "credential": true,
-		"forgot password": true, "reset password": true, "password reset": true,
-		"remember me": true, "stay logged in": true,
+// loginKeywords mark form, button and link attributes or element text as
+// part of a sign-in flow.
+var loginKeywords = map[string]bool{
+	"login": true, "signin": true, "sign-in": true, "sign_in": true, "log-in": true, "log_in": true,
+	"sign in": true, "log in": true, "logon": true, "log on": true,
+	"password": true, "passwd": true, "pwd": true, "pass": true,
+	"username": true, "userid": true, "user_id": true,
+	"authenticate": true, "authentication": true,
+	"credentials": true, "credential": true,
+	"forgot password": true, "reset password": true, "password reset": true,
+	"remember me": true, "stay logged in": true,
+}
+
+// loginInputNames mark an input's name, id or class as a login field.
+var loginInputNames = map[string]bool{
+	"username": true, "userid": true, "user_id": true,
+	"password": true, "passwd": true, "pwd": true, "pass": true, "passphrase": true,
+	"login": true, "signin": true, "authenticate": true,
+	"remember": true, "remember_me": true, "stay_logged_in": true,
+}
+
+// loginSignals accumulates evidence of a login form while a document is
+// walked; a page has one when it has a password field and login context.
+type loginSignals struct {
+	passwordField bool
+	loginContext  bool
+}
+
+func (s *loginSignals) found() bool {
+	return s.passwordField && s.loginContext
+}
+
+// observeElement inspects an element's tag and attributes.
+func (s *loginSignals) observeElement(tag string, attrs []html.Attribute) {
+	if tag == "input" {
+		var inputType, inputName, inputId, inputClass string
+		for _, attr := range attrs {
+			switch attr.Key {
+			case "type":
+				inputType = strings.ToLower(attr.Val)
+			case "name":
+				inputName = strings.ToLower(attr.Val)
+			case "id":
+				inputId = strings.ToLower(attr.Val)
+			case "class":
+				inputClass = strings.ToLower(attr.Val)
+			}
+		}
+
+		if inputType == "password" {
+			s.passwordField = true
+		}
+		for loginName := range loginInputNames {
+			if strings.Contains(inputName, loginName) || strings.Contains(inputId, loginName) || strings.Contains(inputClass, loginName) {
+				s.loginContext = true
+			}
+		}
 	}
 
-	loginInputNames := map[string]bool{
-		"username": true, "userid": true, "user_id": true,
-		"password": true, "passwd": true, "pwd": true, "pass": true, "passphrase": true,
-		"login": true, "signin": true, "authenticate": true,
-		"remember": true, "remember_me": true, "stay_logged_in": true,
+	if tag == "form" {
+		for _, attr := range attrs {
+			if attr.Key == "action" || attr.Key == "id" || attr.Key == "class" || attr.Key == "name" {
+				s.observeKeywords(attr.Val)
+			}
+		}
+	}
+
+	if tag == HTMLElementButton || tag == HTMLElementA {
+		for _, attr := range attrs {
+			if attr.Key == "id" || attr.Key == "class" || attr.Key == "name" || attr.Key == "value" {
+				s.observeKeywords(attr.Val)
+			}
+		}
+	}
+}
+
+// observeText inspects the leading text of an element listed in
+// AccessibilityElements.
+func (s *loginSignals) observeText(text string) {
+	s.observeKeywords(strings.TrimSpace(text))
+}
+
+func (s *loginSignals) observeKeywords(value string) {
+	value = strings.ToLower(value)
+	for keyword := range loginKeywords {
+		if strings.Contains(value, keyword) {
+			s.loginContext = true
+		}
 	}
+}
 
-	var hasPasswordField bool
-	var hasLoginContext bool
+func (p *htmlParser) hasLoginForm(doc *html.Node) bool {
+	var signals loginSignals
 
 	var traverse func(*html.Node, int)
 	traverse = func(n *html.Node, depth int) {
@@ -653,125 +1758,290 @@ func (p *htmlParser) hasLoginForm(doc *html.Node) bool {
 			return
 		}
 		if n.Type == html.ElementNode {
-			if n.Data == "input" {
-				var inputType, inputName, inputId, inputClass string
-				for _, attr := range n.Attr {
-					switch attr.Key {
-					case "type":
-						inputType = strings.ToLower(attr.Val)
-					case "name":
-						inputName = strings.ToLower(attr.Val)
-					case "id":
-						inputId = strings.ToLower(attr.Val)
-					case "class":
-						inputClass = strings.ToLower(attr.Val)
-					}
-				}
+			signals.observeElement(n.Data, n.Attr)
 
-				if inputType == "password" {
-					hasPasswordField = true
-				}
-				for loginName := range loginInputNames {
-					if strings.Contains(inputName, loginName) || strings.Contains(inputId, loginName) || strings.Contains(inputClass, loginName) {
-						hasLoginContext = true
-					}
+			if contains(AccessibilityElements, n.Data) {
+				if n.FirstChild != nil && n.FirstChild.Type == html.TextNode {
+					signals.observeText(n.FirstChild.Data)
 				}
 			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c, depth+1)
+		}
+	}
 
-			if n.Data == "form" {
-				for _, attr := range n.Attr {
-					if attr.Key == "action" || attr.Key == "id" || attr.Key == "class" || attr.Key == "name" {
-						value := strings.ToLower(attr.Val)
-						for keyword := range loginKeywords {
-							if strings.Contains(value, keyword) {
-								hasLoginContext = true
-							}
-						}
-					}
-				}
-			}
+	traverse(doc, 0)
+
+	return signals.found()
+}
 
-			if n.Data == HTMLElementButton || n.Data == HTMLElementA {
-				for _, attr := range n.Attr {
-					if attr.Key == "id" || attr.Key == "class" || attr.Key == "name" || attr.Key == "value" {
-						value := strings.ToLower(attr.Val)
-						for keyword := range loginKeywords {
-							if strings.Contains(value, keyword) {
-								hasLoginContext = true
-							}
-						}
-					}
+// extractForms lists every form in document order with its method, action
+// and the name and type of each field it contains.
+func (p *htmlParser) extractForms(doc *html.Node) []entities.FormInfo {
+	forms := make([]entities.FormInfo, 0)
+	var traverse func(*html.Node, int, *entities.FormInfo)
+	traverse = func(n *html.Node, depth int, form *entities.FormInfo) {
+		if depth > MaxHTMLDepth {
+			return
+		}
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case HTMLElementForm:
+				info, formAutocomplete := newFormInfo(n.Attr)
+				for c := n.FirstChild; c != nil; c = c.NextSibling {
+					traverse(c, depth+1, &info)
+				}
+				inheritAutocomplete(&info, formAutocomplete)
+				forms = append(forms, info)
+				return
+			case HTMLElementInput, HTMLElementSelect, HTMLElementTextarea:
+				if form != nil {
+					form.Inputs = append(form.Inputs, formInput(n))
 				}
 			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c, depth+1, form)
+		}
+	}
+	traverse(doc, 0, nil)
+	return forms
+}
 
-			if contains(AccessibilityElements, n.Data) {
-				if n.FirstChild != nil && n.FirstChild.Type == html.TextNode {
-					text := strings.ToLower(strings.TrimSpace(n.FirstChild.Data))
-					for keyword := range loginKeywords {
-						if strings.Contains(text, keyword) {
-							hasLoginContext = true
-						}
-					}
-				}
+// extractPasswordAutocomplete returns the effective autocomplete of every
+// password field, including fields outside any form.
+func (p *htmlParser) extractPasswordAutocomplete(doc *html.Node) []string {
+	var fields passwordFields
+	var traverse func(*html.Node, int, string)
+	traverse = func(n *html.Node, depth int, formAutocomplete string) {
+		if depth > MaxHTMLDepth {
+			return
+		}
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case HTMLElementForm:
+				formAutocomplete = fields.addForm(n.Attr)
+			case HTMLElementInput:
+				fields.addInput(n.Attr, formAutocomplete)
 			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c, depth+1, formAutocomplete)
+		}
+	}
+	traverse(doc, 0, "")
+	return fields.autocompletes()
+}
 
+// extractDeprecatedElements lists each obsolete element used in the document
+// once, in order of first appearance.
+func (p *htmlParser) extractDeprecatedElements(doc *html.Node) []string {
+	found := make([]string, 0)
+	seen := make(map[string]bool)
+	var traverse func(*html.Node, int)
+	traverse = func(n *html.Node, depth int) {
+		if depth > MaxHTMLDepth {
+			return
+		}
+		if n.Type == html.ElementNode && DeprecatedElements[n.Data] && !seen[n.Data] {
+			seen[n.Data] = true
+			found = append(found, n.Data)
 		}
 		for c := n.FirstChild; c != nil; c = c.NextSibling {
 			traverse(c, depth+1)
 		}
 	}
-
 	traverse(doc, 0)
+	return found
+}
 
-	return hasPasswordField && hasLoginContext
+// newFormInfo reads a form's method and action, and returns its
+// autocomplete setting for the fields to inherit.
+func newFormInfo(attrs []html.Attribute) (entities.FormInfo, string) {
+	info := entities.FormInfo{
+		Method: FormMethodGET,
+		Inputs: make([]entities.FormInput, 0),
+	}
+	formAutocomplete := ""
+	for _, attr := range attrs {
+		switch attr.Key {
+		case HTMLAttrMethod:
+			if method := strings.ToLower(strings.TrimSpace(attr.Val)); method != "" {
+				info.Method = method
+			}
+		case HTMLAttrAction:
+			info.Action = strings.TrimSpace(attr.Val)
+		case HTMLAttrAutocomplete:
+			formAutocomplete = strings.ToLower(strings.TrimSpace(attr.Val))
+		}
+	}
+	return info, formAutocomplete
 }
 
-func (p *htmlParser) hasHTML5Features(doc *html.Node) bool {
-	html5Elements := map[string]bool{
-		"article": true, "aside": true, "audio": true, "canvas": true,
-		"datalist": true, "details": true, "embed": true, "figcaption": true,
-		"figure": true, "footer": true, "header": true, "hgroup": true,
-		"keygen": true, "mark": true, "meter": true, "nav": true,
-		"output": true, "progress": true, "rp": true, "rt": true,
-		"ruby": true, "section": true, "source": true, "summary": true,
-		"time": true, "track": true, "video": true, "wbr": true,
+// inheritAutocomplete applies the form's autocomplete to fields that do not
+// set their own.
+func inheritAutocomplete(info *entities.FormInfo, formAutocomplete string) {
+	for i := range info.Inputs {
+		if info.Inputs[i].Autocomplete == "" {
+			info.Inputs[i].Autocomplete = formAutocomplete
+		}
 	}
+}
 
-	html5InputTypes := map[string]bool{
-		LinkTypeEmail: true, LinkTypeURL: true, LinkTypeTel: true, LinkTypeSearch: true,
-		"number": true, "range": true, "date": true, "time": true,
-		"datetime": true, "datetime-local": true, "month": true,
-		"week": true, "color": true,
+func formInput(n *html.Node) entities.FormInput {
+	return formInputFromAttrs(n.Data, n.Attr)
+}
+
+func formInputFromAttrs(tag string, attrs []html.Attribute) entities.FormInput {
+	input := entities.FormInput{Type: tag}
+	if tag == HTMLElementInput {
+		input.Type = InputTypeText
+	}
+	for _, attr := range attrs {
+		switch attr.Key {
+		case HTMLAttrName:
+			input.Name = attr.Val
+		case HTMLAttrType:
+			if tag == HTMLElementInput && attr.Val != "" {
+				input.Type = strings.ToLower(attr.Val)
+			}
+		case HTMLAttrAutocomplete:
+			input.Autocomplete = strings.ToLower(strings.TrimSpace(attr.Val))
+		}
 	}
+	return input
+}
+
+// passwordFields collects password inputs anywhere in a document. A field's
+// autocomplete falls back to that of its form owner: the form named by its
+// form attribute if it has one, else its enclosing form. Forms may come
+// after the fields that reference them, so owners are resolved at the end.
+type passwordFields struct {
+	formAutocomplete map[string]string
+	fields           []passwordField
+}
 
-	html5Attributes := map[string]bool{
-		"contenteditable": true, "draggable": true, "hidden": true, "spellcheck": true,
+type passwordField struct {
+	autocomplete string
+	// formID is the form attribute, set when hasFormID is true.
+	formID    string
+	hasFormID bool
+	// enclosing is the autocomplete of the form the field sits in.
+	enclosing string
+}
+
+// addForm records a form so fields can reference it by id, and returns its
+// autocomplete for the fields it encloses.
+func (p *passwordFields) addForm(attrs []html.Attribute) string {
+	id, autocomplete := "", ""
+	for _, attr := range attrs {
+		switch attr.Key {
+		case HTMLAttrID:
+			id = attr.Val
+		case HTMLAttrAutocomplete:
+			autocomplete = strings.ToLower(strings.TrimSpace(attr.Val))
+		}
 	}
+	if id != "" {
+		if p.formAutocomplete == nil {
+			p.formAutocomplete = make(map[string]string)
+		}
+		// the first element with an id wins, as for getElementById
+		if _, ok := p.formAutocomplete[id]; !ok {
+			p.formAutocomplete[id] = autocomplete
+		}
+	}
+	return autocomplete
+}
 
-	var traverse func(*html.Node, int) bool
-	traverse = func(n *html.Node, depth int) bool {
-		if depth > MaxHTMLDepth {
-			return false
+// addInput records an input if it is a password field; enclosing is the
+// autocomplete of the form it sits in, if any.
+func (p *passwordFields) addInput(attrs []html.Attribute, enclosing string) {
+	input := formInputFromAttrs(HTMLElementInput, attrs)
+	if input.Type != InputTypePassword {
+		return
+	}
+	field := passwordField{autocomplete: input.Autocomplete, enclosing: enclosing}
+	for _, attr := range attrs {
+		if attr.Key == HTMLAttrForm {
+			field.formID, field.hasFormID = attr.Val, true
 		}
-		if n.Type == html.ElementNode {
-			if html5Elements[n.Data] {
-				return true
-			}
+	}
+	p.fields = append(p.fields, field)
+}
 
-			if n.Data == "input" {
-				for _, attr := range n.Attr {
-					if attr.Key == "type" && html5InputTypes[attr.Val] {
-						return true
-					}
-				}
+// autocompletes returns each field's effective autocomplete in document
+// order. A form attribute naming no form leaves the field without an owner.
+func (p *passwordFields) autocompletes() []string {
+	values := make([]string, 0, len(p.fields))
+	for _, field := range p.fields {
+		value := field.autocomplete
+		if value == "" {
+			if field.hasFormID {
+				value = p.formAutocomplete[field.formID]
+			} else {
+				value = field.enclosing
 			}
+		}
+		values = append(values, value)
+	}
+	return values
+}
 
-			for _, attr := range n.Attr {
-				if html5Attributes[attr.Key] {
-					return true
-				}
+var html5Elements = map[string]bool{
+	"article": true, "aside": true, "audio": true, "canvas": true,
+	"datalist": true, "details": true, "embed": true, "figcaption": true,
+	"figure": true, "footer": true, "header": true, "hgroup": true,
+	"keygen": true, "mark": true, "meter": true, "nav": true,
+	"output": true, "progress": true, "rp": true, "rt": true,
+	"ruby": true, "section": true, "source": true, "summary": true,
+	"time": true, "track": true, "video": true, "wbr": true,
+}
+
+var html5InputTypes = map[string]bool{
+	LinkTypeEmail: true, LinkTypeURL: true, LinkTypeTel: true, LinkTypeSearch: true,
+	"number": true, "range": true, "date": true, "time": true,
+	"datetime": true, "datetime-local": true, "month": true,
+	"week": true, "color": true,
+}
+
+var html5Attributes = map[string]bool{
+	"contenteditable": true, "draggable": true, "hidden": true, "spellcheck": true,
+}
+
+// isHTML5Element reports whether an element only exists in, or uses an
+// attribute introduced by, HTML5.
+func isHTML5Element(tag string, attrs []html.Attribute) bool {
+	if html5Elements[tag] {
+		return true
+	}
+
+	if tag == "input" {
+		for _, attr := range attrs {
+			if attr.Key == "type" && html5InputTypes[attr.Val] {
+				return true
 			}
 		}
+	}
+
+	for _, attr := range attrs {
+		if html5Attributes[attr.Key] {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *htmlParser) hasHTML5Features(doc *html.Node) bool {
+	var traverse func(*html.Node, int) bool
+	traverse = func(n *html.Node, depth int) bool {
+		if depth > MaxHTMLDepth {
+			return false
+		}
+		if n.Type == html.ElementNode && isHTML5Element(n.Data, n.Attr) {
+			return true
+		}
 
 		for c := n.FirstChild; c != nil; c = c.NextSibling {
 			if traverse(c, depth+1) {
@@ -2,13 +2,21 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
+	"webpage-analyzer/internal/domain/entities"
+	"webpage-analyzer/pkg/tracing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func getTestConfig() *AnalyzerConfig {
@@ -31,7 +39,7 @@ func TestLinkAccessibility(t *testing.T) {
 				<html>
 				<head><title>Test Page</title></head>
 				<body>
-					<h1>Test Page</h1>
+					<h1 id="fragment">Test Page</h1>
 					<a href="/internal-page">Internal Link</a>
 					<a href="relative-page">Relative Link</a>
 					<a href="#fragment">Fragment Link</a>
@@ -52,7 +60,7 @@ func TestLinkAccessibility(t *testing.T) {
 	}))
 	defer server.Close()
 
-	wrappedClient := NewHTTPClient(http.DefaultClient)
+	wrappedClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
 	parser := NewHTMLParser(wrappedClient)
 	service := NewAnalyzerService(wrappedClient, parser, getTestConfig())
 
@@ -129,7 +137,7 @@ func TestCheckLinkAccessibility(t *testing.T) {
 	defer server.Close()
 
 	// create parser with proper HTTP client
-	wrappedClient := NewHTTPClient(http.DefaultClient)
+	wrappedClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
 	_ = NewHTMLParser(wrappedClient) // parser not used in this test
 
 	tests := []struct {
@@ -147,31 +155,19 @@ func TestCheckLinkAccessibility(t *testing.T) {
 		{"notfound", server.URL, false},
 	}
 
-	testClient := NewHTTPClient(http.DefaultClient)
+	testClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
 	testParser := NewHTMLParser(testClient)
 
 	for _, test := range tests {
-		// create a simple HTML page with the test link
-		htmlContent := fmt.Sprintf(`<html><body><a href="%s">Test Link</a></body></html>`, test.href)
-
-		parsed, err := testParser.Parse(htmlContent, test.baseURL)
-		if err != nil {
-			t.Errorf("Parse failed for %q: %v", test.href, err)
-			continue
-		}
-
-		// find the link in the parsed results
-		if len(parsed.Links) > 0 {
-			link := parsed.Links[0]
-			if link.IsAccessible != test.expected {
-				t.Errorf("Link accessibility for %q = %v, expected %v", test.href, link.IsAccessible, test.expected)
-			}
+		accessible := testParser.CheckLink(context.Background(), test.href, test.baseURL).Accessible
+		if accessible != test.expected {
+			t.Errorf("Link accessibility for %q = %v, expected %v", test.href, accessible, test.expected)
 		}
 	}
 }
 
 func TestNewAnalyzerService(t *testing.T) {
-	httpClient := NewHTTPClient(&http.Client{})
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
 	parser := NewHTMLParser(httpClient)
 	service := NewAnalyzerService(httpClient, parser, getTestConfig())
 
@@ -179,7 +175,7 @@ func TestNewAnalyzerService(t *testing.T) {
 }
 
 func TestValidateURL(t *testing.T) {
-	httpClient := NewHTTPClient(&http.Client{})
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
 	parser := NewHTMLParser(httpClient)
 	service := NewAnalyzerService(httpClient, parser, getTestConfig())
 
@@ -200,21 +196,21 @@ func TestNewHTMLParser(t *testing.T) {
 }
 
 func TestAnalyzerServiceConstructor(t *testing.T) {
-	httpClient := NewHTTPClient(&http.Client{})
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
 	parser := NewHTMLParser(httpClient)
 	service := NewAnalyzerService(httpClient, parser, getTestConfig())
 	assert.NotNil(t, service)
 }
 
 func TestAnalyzerServiceWithDifferentMaxDepth(t *testing.T) {
-	httpClient := NewHTTPClient(&http.Client{})
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
 	parser := NewHTMLParser(httpClient)
 	service := NewAnalyzerService(httpClient, parser, getTestConfig())
 	assert.NotNil(t, service)
 }
 
 func TestAnalyzerServiceWithZeroMaxDepth(t *testing.T) {
-	httpClient := NewHTTPClient(&http.Client{})
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
 	parser := NewHTMLParser(httpClient)
 	service := NewAnalyzerService(httpClient, parser, getTestConfig())
 	assert.NotNil(t, service)
@@ -246,7 +242,7 @@ func TestAnalyzeWebPage(t *testing.T) {
 	}))
 	defer server.Close()
 
-	httpClient := NewHTTPClient(&http.Client{})
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
 	parser := NewHTMLParser(httpClient)
 	service := NewAnalyzerService(httpClient, parser, getTestConfig())
 	result, err := service.AnalyzeURL(context.Background(), server.URL)
@@ -262,7 +258,7 @@ func TestAnalyzeWebPage(t *testing.T) {
 }
 
 func TestAnalyzeWebPageWithInvalidURL(t *testing.T) {
-	httpClient := NewHTTPClient(&http.Client{})
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
 	parser := NewHTMLParser(httpClient)
 	service := NewAnalyzerService(httpClient, parser, getTestConfig())
 	_, err := service.AnalyzeURL(context.Background(), "not-a-valid-url")
@@ -276,7 +272,7 @@ func TestAnalyzeWebPageWith404(t *testing.T) {
 	}))
 	defer server.Close()
 
-	httpClient := NewHTTPClient(&http.Client{})
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
 	parser := NewHTMLParser(httpClient)
 	service := NewAnalyzerService(httpClient, parser, getTestConfig())
 	_, err := service.AnalyzeURL(context.Background(), server.URL)
@@ -348,7 +344,7 @@ func TestHTMLParserDetectLoginForm(t *testing.T) {
 }
 
 func TestValidateURLComprehensive(t *testing.T) {
-	httpClient := NewHTTPClient(&http.Client{})
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
 	parser := NewHTMLParser(httpClient)
 	service := NewAnalyzerService(httpClient, parser, getTestConfig())
 
@@ -375,3 +371,1232 @@ func TestValidateURLComprehensive(t *testing.T) {
 		assert.Error(t, err, "URL should be invalid: %s", url)
 	}
 }
+
+func TestValidateURLWithHTTPSOnlySchemes(t *testing.T) {
+	config := getTestConfig()
+	config.AllowedSchemes = []string{"https"}
+
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	parser := NewHTMLParser(httpClient)
+	service := NewAnalyzerService(httpClient, parser, config)
+
+	assert.NoError(t, service.ValidateURL("https://example.com"))
+
+	err := service.ValidateURL("http://example.com")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "[https]")
+}
+
+func TestValidateURLWithDefaultSchemes(t *testing.T) {
+	config := getTestConfig()
+	config.AllowedSchemes = nil
+
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	parser := NewHTMLParser(httpClient)
+	service := NewAnalyzerService(httpClient, parser, config)
+
+	assert.NoError(t, service.ValidateURL("http://example.com"))
+	assert.NoError(t, service.ValidateURL("https://example.com"))
+	assert.Error(t, service.ValidateURL("ftp://example.com"))
+}
+
+func TestValidateURLWithCustomSchemes(t *testing.T) {
+	config := getTestConfig()
+	config.AllowedSchemes = []string{"HTTPS", "ftp"}
+
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	parser := NewHTMLParser(httpClient)
+	service := NewAnalyzerService(httpClient, parser, config)
+
+	assert.NoError(t, service.ValidateURL("https://example.com"))
+	assert.Error(t, service.ValidateURL("http://example.com"))
+	err := service.ValidateURL("ftp://example.com/pub")
+	assert.ErrorIs(t, err, ErrInvalidURL, "schemes the analyzer cannot fetch are rejected up front")
+	assert.EqualError(t, err, "only [https] schemes are supported")
+}
+
+func TestValidateAllowedSchemes(t *testing.T) {
+	assert.NoError(t, ValidateAllowedSchemes(nil))
+	assert.NoError(t, ValidateAllowedSchemes([]string{"HTTPS", " http "}))
+	assert.EqualError(t, ValidateAllowedSchemes([]string{"ftp"}), `unsupported scheme "ftp" (supported: [http https])`)
+	assert.Error(t, ValidateAllowedSchemes([]string{"https", "ftp"}))
+}
+
+func TestValidateURLWithOnlyUnfetchableSchemes(t *testing.T) {
+	config := getTestConfig()
+	config.AllowedSchemes = []string{"ftp"}
+
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	parser := NewHTMLParser(httpClient)
+	service := NewAnalyzerService(httpClient, parser, config)
+
+	// the config never widens to schemes the operator did not allow
+	assert.Error(t, service.ValidateURL("https://example.com"))
+	assert.Error(t, service.ValidateURL("http://example.com"))
+	assert.Error(t, service.ValidateURL("ftp://example.com/pub"))
+}
+
+func TestAnalyzeWebPageWithEmptyBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	parser := NewHTMLParser(httpClient)
+	service := NewAnalyzerService(httpClient, parser, getTestConfig())
+	result, err := service.AnalyzeURL(context.Background(), server.URL)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.Equal(t, int64(0), result.ContentLength)
+	assert.Empty(t, result.Title)
+	assert.Empty(t, result.Headings)
+	assert.Equal(t, 0, result.Links.Internal+result.Links.External)
+	assert.Equal(t, NoteEmptyBody, result.Note)
+}
+
+func getRetryTestConfig(maxRetries int) *AnalyzerConfig {
+	config := getTestConfig()
+	config.MaxFetchRetries = maxRetries
+	config.FetchRetryBackoff = time.Millisecond
+	return config
+}
+
+func TestAnalyzeURLRetriesTransientStatus(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`<html><head><title>Recovered</title></head><body></body></html>`))
+	}))
+	defer server.Close()
+
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	parser := NewHTMLParser(httpClient)
+	service := NewAnalyzerService(httpClient, parser, getRetryTestConfig(2))
+	result, err := service.AnalyzeURL(context.Background(), server.URL)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Recovered", result.Title)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+}
+
+func TestAnalyzeURLRetriesConnectionReset(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				_ = conn.Close()
+			}
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`<html><head><title>Recovered</title></head><body></body></html>`))
+	}))
+	defer server.Close()
+
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	parser := NewHTMLParser(httpClient)
+	service := NewAnalyzerService(httpClient, parser, getRetryTestConfig(2))
+	result, err := service.AnalyzeURL(context.Background(), server.URL)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Recovered", result.Title)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+}
+
+func TestAnalyzeURLDoesNotRetryClientErrors(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	parser := NewHTMLParser(httpClient)
+	service := NewAnalyzerService(httpClient, parser, getRetryTestConfig(3))
+	_, err := service.AnalyzeURL(context.Background(), server.URL)
+
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+}
+
+func TestAnalyzeURLGivesUpAfterMaxRetries(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	parser := NewHTMLParser(httpClient)
+	service := NewAnalyzerService(httpClient, parser, getRetryTestConfig(2))
+	_, err := service.AnalyzeURL(context.Background(), server.URL)
+
+	assert.Error(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&hits))
+}
+
+func TestIsTransientFetchFailure(t *testing.T) {
+	assert.True(t, isTransientFetchFailure(nil, &net.DNSError{Err: "server misbehaving", IsTemporary: true}))
+	assert.False(t, isTransientFetchFailure(nil, &net.DNSError{Err: "no such host", IsNotFound: true}))
+	assert.True(t, isTransientFetchFailure(nil, fmt.Errorf("read: connection reset by peer")))
+	assert.False(t, isTransientFetchFailure(&http.Response{StatusCode: http.StatusTooManyRequests}, nil))
+	assert.True(t, isTransientFetchFailure(&http.Response{StatusCode: http.StatusGatewayTimeout}, nil))
+}
+
+func TestAnalyzeURLContentHash(t *testing.T) {
+	var body atomic.Value
+	body.Store(`<html><head><title>Version 1</title></head><body></body></html>`)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(body.Load().(string)))
+	}))
+	defer server.Close()
+
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	parser := NewHTMLParser(httpClient)
+	service := NewAnalyzerService(httpClient, parser, getTestConfig())
+
+	first, err := service.AnalyzeURL(context.Background(), server.URL)
+	assert.NoError(t, err)
+	assert.Len(t, first.ContentHash, 64)
+
+	second, err := service.AnalyzeURL(context.Background(), server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, first.ContentHash, second.ContentHash)
+
+	body.Store(`<html><head><title>Version 2</title></head><body></body></html>`)
+	third, err := service.AnalyzeURL(context.Background(), server.URL)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.ContentHash, third.ContentHash)
+}
+
+func TestConfiguredLinkCheckTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`<html><body><a href="/slow">Slow</a><a href="/fast">Fast</a></body></html>`))
+		case "/slow":
+			time.Sleep(500 * time.Millisecond)
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	config := getTestConfig()
+	config.LinkCheckTimeout = 100 * time.Millisecond
+
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	parser := NewHTMLParser(httpClient)
+	service := NewAnalyzerService(httpClient, parser, config)
+
+	result, err := service.AnalyzeURL(context.Background(), server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Links.Inaccessible)
+	assert.Equal(t, []string{"/slow"}, result.Links.BrokenLinks)
+}
+
+func TestZeroLinkCheckTimeoutFallsBackToDefault(t *testing.T) {
+	parser := NewHTMLParser(nil).(*htmlParser)
+	parser.SetLinkCheckTimeout(0)
+
+	assert.Equal(t, DefaultLinkCheckTimeout, parser.linkCheckTimeout)
+}
+
+func TestIsInternalLinkSubdomains(t *testing.T) {
+	baseURL := "https://www.example.com"
+
+	tests := []struct {
+		href             string
+		exactHost        bool
+		subdomainsAsSite bool
+	}{
+		{"https://www.example.com/page", true, true},
+		{"https://blog.example.com/post", false, true},
+		{"https://example.com/", false, true},
+		{"https://example.org/", false, false},
+		{"https://www.example.com.evil.com/", false, false},
+		{"https://other.co.uk/", false, false},
+	}
+
+	exact := &htmlParser{}
+	subdomains := &htmlParser{subdomainsAsInternal: true}
+
+	for _, test := range tests {
+		assert.Equal(t, test.exactHost, exact.isInternalLink(test.href, baseURL), "exact host: %s", test.href)
+		assert.Equal(t, test.subdomainsAsSite, subdomains.isInternalLink(test.href, baseURL), "subdomains: %s", test.href)
+	}
+}
+
+func TestSameRegistrableDomain(t *testing.T) {
+	assert.True(t, sameRegistrableDomain("shop.example.co.uk", "www.example.co.uk"))
+	assert.False(t, sameRegistrableDomain("example.co.uk", "other.co.uk"))
+	assert.False(t, sameRegistrableDomain("localhost", "localhost.example.com"))
+}
+
+func TestFragmentLinkTargets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`
+			<html>
+			<body>
+				<h2 id="intro">Intro</h2>
+				<a name="legacy"></a>
+				<a href="#intro">Intro</a>
+				<a href="#legacy">Legacy</a>
+				<a href="#">Top</a>
+				<a href="#top">Top</a>
+				<a href="#missing">Missing</a>
+			</body>
+			</html>
+		`))
+	}))
+	defer server.Close()
+
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	parser := NewHTMLParser(httpClient)
+	service := NewAnalyzerService(httpClient, parser, getTestConfig())
+
+	result, err := service.AnalyzeURL(context.Background(), server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, result.Links.Internal)
+	assert.Equal(t, 1, result.Links.Inaccessible)
+	assert.Equal(t, []string{"#missing"}, result.Links.BrokenLinks)
+	assert.Equal(t, LinkReasonMissingAnchor, result.Links.BrokenLinkReasons["#missing"])
+}
+
+func TestParseRejectsTooManyNodes(t *testing.T) {
+	parser := NewHTMLParser(nil)
+	parser.SetMaxNodes(1000)
+
+	wide := "<html><body>" + strings.Repeat("<div></div>", 5000) + "</body></html>"
+	deep := "<html><body>" + strings.Repeat("<div>", 5000) + strings.Repeat("</div>", 5000) + "</body></html>"
+
+	for name, content := range map[string]string{"wide": wide, "deep": deep} {
+		_, err := parser.Parse(content, "https://example.com")
+
+		var limitErr *NodeLimitExceededError
+		assert.True(t, errors.As(err, &limitErr), name)
+		assert.Equal(t, 1000, limitErr.Limit, name)
+	}
+}
+
+func TestParseAllowsDocumentsWithinNodeLimit(t *testing.T) {
+	parser := NewHTMLParser(nil)
+	parser.SetMaxNodes(1000)
+
+	parsed, err := parser.Parse("<html><head><title>Small</title></head><body>"+strings.Repeat("<p>x</p>", 100)+"</body></html>", "https://example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "Small", parsed.Title)
+}
+
+func TestAnalyzeURLDecodesDeclaredCharset(t *testing.T) {
+	// "Café Crème" encoded as ISO-8859-1
+	latin1Title := "Caf\xe9 Cr\xe8me"
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{"latin-1 header", "text/html; charset=ISO-8859-1", "<html><head><title>" + latin1Title + "</title></head></html>"},
+		{"latin-1 meta", "text/html", `<html><head><meta charset="iso-8859-1"><title>` + latin1Title + "</title></head></html>"},
+		{"utf-8 header", "text/html; charset=utf-8", "<html><head><title>Café Crème</title></head></html>"},
+		{"utf-8 undeclared", "text/html", "<html><head><title>Café Crème</title></head></html>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+			service := NewAnalyzerService(client, NewHTMLParser(client), getTestConfig())
+
+			result, err := service.AnalyzeURL(context.Background(), server.URL)
+			assert.NoError(t, err)
+			assert.Equal(t, "Café Crème", result.Title)
+		})
+	}
+}
+
+func TestParseTruncatesLongTitleAndDescription(t *testing.T) {
+	parser := NewHTMLParser(nil)
+	parser.SetMaxTitleLength(10)
+
+	longTitle := strings.Repeat("é", 5000)
+	content := `<html><head><title>` + longTitle + `</title>` +
+		`<meta name="description" content="` + strings.Repeat("d", 50) + `"></head><body></body></html>`
+
+	parsed, err := parser.Parse(content, "https://example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("é", 10), parsed.Title)
+	assert.True(t, parsed.TitleTruncated)
+	assert.Equal(t, strings.Repeat("d", 10), parsed.Description)
+	assert.True(t, parsed.DescriptionTruncated)
+
+	parsed, err = parser.Parse(`<html><head><title>Short</title><meta name="description" content="Brief"></head></html>`, "https://example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "Short", parsed.Title)
+	assert.False(t, parsed.TitleTruncated)
+	assert.Equal(t, "Brief", parsed.Description)
+	assert.False(t, parsed.DescriptionTruncated)
+}
+
+func TestQualityWarnings(t *testing.T) {
+	tests := []struct {
+		name     string
+		title    string
+		headings map[string]int
+		expected []string
+	}{
+		{"clean page", "Home", map[string]int{"h1": 1, "h2": 3}, []string{}},
+		{"multiple h1", "Home", map[string]int{"h1": 2}, []string{WarningMultipleH1}},
+		{"no h1", "Home", map[string]int{"h2": 1}, []string{WarningNoH1}},
+		{"no title", "", map[string]int{"h1": 1}, []string{WarningNoTitle}},
+		{"no title and no h1", "", nil, []string{WarningNoH1, WarningNoTitle}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, qualityWarnings(tt.title, tt.headings))
+		})
+	}
+}
+
+func TestAnalyzeURLReportsWarnings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><head></head><body><h1>One</h1><h1>Two</h1></body></html>`))
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	service := NewAnalyzerService(client, NewHTMLParser(client), getTestConfig())
+
+	result, err := service.AnalyzeURL(context.Background(), server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{WarningMultipleH1, WarningNoTitle}, result.Warnings)
+}
+
+func TestAnalyzeURLTypedErrors(t *testing.T) {
+	notFound := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer notFound.Close()
+
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closedURL := closed.URL
+	closed.Close()
+
+	client := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	service := NewAnalyzerService(client, NewHTMLParser(client), getTestConfig())
+
+	_, err := service.AnalyzeURL(context.Background(), notFound.URL)
+	var statusErr *TargetStatusError
+	assert.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+	assert.Equal(t, "HTTP 404: page not found", err.Error())
+
+	_, err = service.AnalyzeURL(context.Background(), closedURL)
+	assert.ErrorIs(t, err, ErrTargetUnreachable)
+
+	_, err = service.AnalyzeURL(context.Background(), "ftp://example.com")
+	assert.ErrorIs(t, err, ErrInvalidURL)
+	assert.Equal(t, "only [http https] schemes are supported", service.ValidateURL("ftp://example.com").Error())
+}
+
+func TestParseHeadingOutline(t *testing.T) {
+	parser := NewHTMLParser(nil)
+
+	parsed, err := parser.Parse(`<html><body>
+		<h1>Main <em>title</em></h1>
+		<h2><a href="/intro"><span>Intro</span>duction</a></h2>
+		<h3>
+			Details
+		</h3>
+		<h2>Summary</h2>
+	</body></html>`, "https://example.com")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []entities.HeadingNode{
+		{Level: 1, Text: "Main title"},
+		{Level: 2, Text: "Introduction"},
+		{Level: 3, Text: "Details"},
+		{Level: 2, Text: "Summary"},
+	}, parsed.HeadingOutline)
+	assert.Equal(t, 2, parsed.Headings["h2"])
+}
+
+func TestParseExtractsForms(t *testing.T) {
+	parser := NewHTMLParser(nil)
+
+	parsed, err := parser.Parse(`<html><body>
+		<form action="/search">
+			<input name="q">
+			<select name="category"><option>All</option></select>
+		</form>
+		<div>
+			<form method="POST" action="/login">
+				<input type="hidden" name="csrf_token" value="abc">
+				<input type="EMAIL" name="email">
+				<input type="password" name="password">
+				<textarea name="note"></textarea>
+				<button type="submit">Log in</button>
+			</form>
+		</div>
+		<input name="outside">
+	</body></html>`, "https://example.com")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []entities.FormInfo{
+		{
+			Method: "get",
+			Action: "/search",
+			Inputs: []entities.FormInput{
+				{Name: "q", Type: "text"},
+				{Name: "category", Type: "select"},
+			},
+		},
+		{
+			Method: "post",
+			Action: "/login",
+			Inputs: []entities.FormInput{
+				{Name: "csrf_token", Type: "hidden"},
+				{Name: "email", Type: "email"},
+				{Name: "password", Type: "password"},
+				{Name: "note", Type: "textarea"},
+			},
+		},
+	}, parsed.Forms)
+}
+
+func TestSecurityWarningsPasswordAutocomplete(t *testing.T) {
+	tests := []struct {
+		name     string
+		form     string
+		expected []string
+	}{
+		{"no attribute", `<form><input type="password" name="pw"></form>`, []string{WarningPasswordAutocomplete}},
+		{"autocomplete on", `<form><input type="password" autocomplete="on"></form>`, []string{WarningPasswordAutocomplete}},
+		{"current-password", `<form><input type="password" autocomplete="current-password"></form>`, []string{WarningPasswordAutocomplete}},
+		{"autocomplete off", `<form><input type="password" autocomplete="off"></form>`, nil},
+		{"new-password", `<form><input type="password" autocomplete="New-Password"></form>`, nil},
+		{"inherited from form", `<form autocomplete="off"><input type="password"></form>`, nil},
+		{"input overrides form", `<form autocomplete="off"><input type="password" autocomplete="on"></form>`, []string{WarningPasswordAutocomplete}},
+		{"no password field", `<form><input type="text" name="q"></form>`, nil},
+		{"outside any form", `<div><input type="password"></div>`, []string{WarningPasswordAutocomplete}},
+		{"outside any form with autocomplete off", `<div><input type="password" autocomplete="off"></div>`, nil},
+		{"form attribute", `<input type="password" form="login"><form id="login" autocomplete="off"></form>`, nil},
+		{"form attribute overrides enclosing form", `<form id="login" autocomplete="on"></form><form autocomplete="off"><input type="password" form="login"></form>`, []string{WarningPasswordAutocomplete}},
+		{"form attribute naming no form", `<form autocomplete="off"><input type="password" form="missing"></form>`, []string{WarningPasswordAutocomplete}},
+	}
+
+	parser := NewHTMLParser(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := parser.Parse("<html><body>"+tt.form+"</body></html>", "https://example.com")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, securityWarnings(parsed.PasswordAutocomplete))
+		})
+	}
+}
+
+func TestAnalyzeURLReportsPasswordAutocompleteWarning(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><head><title>Login</title></head><body><h1>Login</h1>
+			<form method="post"><input name="user"><input type="password" name="pass"></form></body></html>`))
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	service := NewAnalyzerService(client, NewHTMLParser(client), getTestConfig())
+
+	result, err := service.AnalyzeURL(context.Background(), server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{WarningPasswordAutocomplete}, result.Warnings)
+}
+
+func TestIsSkippedHost(t *testing.T) {
+	parser := NewHTMLParser(nil).(*htmlParser)
+	parser.SetLinkCheckSkipHosts([]string{"LinkedIn.com", " .twitter.com "})
+
+	tests := []struct {
+		href     string
+		expected bool
+	}{
+		{"https://linkedin.com/in/someone", true},
+		{"https://www.linkedin.com/company/acme", true},
+		{"https://notlinkedin.com/", false},
+		{"https://twitter.com/acme", true},
+		{"https://example.com/about", false},
+		{"/about", false},
+		{"mailto:hello@linkedin.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.href, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parser.isSkippedHost(tt.href, "https://example.com"))
+		})
+	}
+}
+
+func TestAnalyzeURLSkipsLinkChecksForSkipListedHosts(t *testing.T) {
+	var linkHits int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&linkHits, 1)
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer target.Close()
+
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><head><title>Home</title></head><body><h1>Home</h1>
+			<a href="` + target.URL + `/profile">Profile</a></body></html>`))
+	}))
+	defer page.Close()
+
+	targetURL, err := url.Parse(target.URL)
+	assert.NoError(t, err)
+
+	config := getTestConfig()
+	config.LinkCheckSkipHosts = []string{targetURL.Hostname()}
+	client := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	service := NewAnalyzerService(client, NewHTMLParser(client), config)
+
+	result, err := service.AnalyzeURL(context.Background(), page.URL)
+	assert.NoError(t, err)
+
+	assert.Equal(t, int32(0), atomic.LoadInt32(&linkHits))
+	assert.Equal(t, 1, result.Links.Skipped)
+	assert.Equal(t, []string{target.URL + "/profile"}, result.Links.SkippedLinks)
+	assert.Equal(t, 0, result.Links.Inaccessible)
+	assert.Empty(t, result.Links.BrokenLinks)
+}
+
+func TestSharedHTTPClientReusesTransport(t *testing.T) {
+	first := NewSharedHTTPClient(time.Second, TransportConfig{})
+	second := NewSharedHTTPClient(5*time.Second, TransportConfig{})
+
+	assert.Same(t, SharedTransport(TransportConfig{}), first.Transport)
+	assert.Same(t, first.Transport, second.Transport)
+	assert.Equal(t, 5*time.Second, second.Timeout)
+	assert.Equal(t, DefaultMaxIdleConnsPerHost, SharedTransport(TransportConfig{}).MaxIdleConnsPerHost)
+}
+
+func TestForceHTTP1DisablesHTTP2(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	tlsConfig := server.Client().Transport.(*http.Transport).TLSClientConfig
+
+	tests := []struct {
+		name       string
+		forceHTTP1 bool
+		wantProto  string
+	}{
+		{"http2 negotiated by default", false, "HTTP/2.0"},
+		{"http1 forced", true, "HTTP/1.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := newTransport(TransportConfig{ForceHTTP1: tt.forceHTTP1})
+			transport.TLSClientConfig = tlsConfig.Clone()
+			defer transport.CloseIdleConnections()
+
+			resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.wantProto, resp.Proto)
+		})
+	}
+
+	assert.NotSame(t, SharedTransport(TransportConfig{}), NewSharedHTTPClient(time.Second, TransportConfig{ForceHTTP1: true}).Transport)
+}
+
+func TestCheckHTTPLinkFollowsRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, "/gone", http.StatusMovedPermanently)
+		case "/twice":
+			http.Redirect(w, r, "/moved", http.StatusMovedPermanently)
+		case "/thrice":
+			http.Redirect(w, r, "/twice", http.StatusMovedPermanently)
+		case "/to-ok":
+			http.Redirect(w, r, "/ok", http.StatusFound)
+		case "/ok-twice":
+			http.Redirect(w, r, "/to-ok", http.StatusMovedPermanently)
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		maxRedirects int
+		path         string
+		want         LinkCheck
+	}{
+		{"unset follows to a 404", 0, "/thrice", LinkCheck{StatusCode: http.StatusNotFound}},
+		{"unset follows two hops to a 200", 0, "/ok-twice", LinkCheck{Accessible: true, StatusCode: http.StatusOK}},
+		{"one hop to a 404", 1, "/moved", LinkCheck{StatusCode: http.StatusNotFound}},
+		{"second hop not followed", 1, "/twice", LinkCheck{Accessible: true, StatusCode: http.StatusMovedPermanently, NotChecked: true}},
+		{"two hops to a 404", 2, "/twice", LinkCheck{StatusCode: http.StatusNotFound}},
+		{"chain past the cap", 2, "/thrice", LinkCheck{Accessible: true, StatusCode: http.StatusMovedPermanently, NotChecked: true}},
+		{"chain within the cap ending in a 404", 3, "/thrice", LinkCheck{StatusCode: http.StatusNotFound}},
+		{"one hop to a 200", 1, "/to-ok", LinkCheck{Accessible: true, StatusCode: http.StatusOK}},
+		{"two hops to a 200", 2, "/ok-twice", LinkCheck{Accessible: true, StatusCode: http.StatusOK}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := NewHTMLParser(nil).(*htmlParser)
+			parser.SetLinkCheckMaxRedirects(tt.maxRedirects)
+
+			assert.Equal(t, tt.want, parser.checkHTTPLink(context.Background(), server.URL+tt.path, time.Second))
+		})
+	}
+}
+
+func TestAnalyzeURLReportsLinkStatusCodes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			_, _ = w.Write([]byte(`<html><head><title>Home</title></head><body><h1>Home</h1>
+				<a href="/moved">Moved</a><a href="/twice">Twice</a><a href="/ok">OK</a></body></html>`))
+		case "/moved":
+			http.Redirect(w, r, "/gone", http.StatusMovedPermanently)
+		case "/twice":
+			http.Redirect(w, r, "/moved", http.StatusMovedPermanently)
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	config := getTestConfig()
+	config.LinkCheckMaxRedirects = 1
+	client := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	service := NewAnalyzerService(client, NewHTMLParser(client), config)
+
+	result, err := service.AnalyzeURL(context.Background(), server.URL)
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]int{
+		"/moved": http.StatusNotFound,
+		"/twice": http.StatusMovedPermanently,
+		"/ok":    http.StatusOK,
+	}, result.Links.StatusCodes)
+	assert.Equal(t, []string{"/moved"}, result.Links.BrokenLinks)
+	assert.Equal(t, []string{"/twice"}, result.Links.NotCheckedLinks)
+}
+
+func TestAnalyzeURLErrorsOmitCredentials(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := listener.Addr().String()
+	assert.NoError(t, listener.Close())
+
+	client := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	service := NewAnalyzerService(client, NewHTMLParser(client), getTestConfig())
+
+	_, err = service.AnalyzeURL(context.Background(), "http://user:secret@"+addr+"/")
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret")
+	assert.Contains(t, err.Error(), addr)
+}
+
+func TestAnalyzeURLCapturesSource(t *testing.T) {
+	page := `<html><head><title>Source</title></head><body><h1>Raw</h1></body></html>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+
+	result, err := NewAnalyzerService(client, NewHTMLParser(client), getTestConfig()).AnalyzeURL(context.Background(), server.URL)
+	assert.NoError(t, err)
+	assert.Nil(t, result.Source)
+
+	config := getTestConfig()
+	config.SourceCaptureMaxBytes = 1024
+	result, err = NewAnalyzerService(client, NewHTMLParser(client), config).AnalyzeURL(context.Background(), server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, page, string(result.Source))
+	assert.False(t, result.SourceTruncated)
+
+	config = getTestConfig()
+	config.SourceCaptureMaxBytes = 10
+	result, err = NewAnalyzerService(client, NewHTMLParser(client), config).AnalyzeURL(context.Background(), server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, page[:10], string(result.Source))
+	assert.True(t, result.SourceTruncated)
+}
+
+func TestAnalyzeURLSendsAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		name             string
+		linkChecks       bool
+		wantLinkLanguage string
+	}{
+		{"page fetch only", false, ""},
+		{"page fetch and link checks", true, "de-DE,de;q=0.9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var linkLanguage atomic.Value
+			linkLanguage.Store("unset")
+			target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				linkLanguage.Store(r.Header.Get("Accept-Language"))
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer target.Close()
+
+			var pageLanguage string
+			page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				pageLanguage = r.Header.Get("Accept-Language")
+				_, _ = w.Write([]byte(`<html><head><title>Start</title></head><body>
+					<a href="` + target.URL + `/impressum">Impressum</a></body></html>`))
+			}))
+			defer page.Close()
+
+			config := getTestConfig()
+			config.AcceptLanguage = "de-DE,de;q=0.9"
+			config.LinkCheckAcceptLanguage = tt.linkChecks
+			client := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+			service := NewAnalyzerService(client, NewHTMLParser(client), config)
+
+			_, err := service.AnalyzeURL(context.Background(), page.URL)
+			assert.NoError(t, err)
+
+			assert.Equal(t, "de-DE,de;q=0.9", pageLanguage)
+			assert.Equal(t, tt.wantLinkLanguage, linkLanguage.Load())
+		})
+	}
+}
+
+func TestParseDetectsDeprecatedElements(t *testing.T) {
+	parser := NewHTMLParser(nil)
+
+	parsed, err := parser.Parse(`<html><body>
+		<center><h1>Welcome</h1></center>
+		<p><font color="red">Sale</font> and <font size="2">more</font></p>
+		<center>Footer</center>
+	</body></html>`, "https://example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"center", "font"}, parsed.DeprecatedElements)
+
+	parsed, err = parser.Parse(`<html><body><h1>Modern</h1></body></html>`, "https://example.com")
+	assert.NoError(t, err)
+	assert.Empty(t, parsed.DeprecatedElements)
+}
+
+func TestAnalyzeURLStopsLinkChecksWhenBudgetElapses(t *testing.T) {
+	var linkHits int32
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&linkHits, 1)
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer slow.Close()
+
+	var body strings.Builder
+	body.WriteString(`<html><head><title>Links</title></head><body><h1>Links</h1>`)
+	for i := 0; i < 6; i++ {
+		fmt.Fprintf(&body, `<a href="%s/page-%d">Page %d</a>`, slow.URL, i, i)
+	}
+	body.WriteString(`</body></html>`)
+
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body.String()))
+	}))
+	defer page.Close()
+
+	config := getTestConfig()
+	config.LinkCheckBudget = 150 * time.Millisecond
+	// two at a time, the third round of checks starts after the budget
+	config.MaxConcurrentLinkChecks = 2
+	client := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	service := NewAnalyzerService(client, NewHTMLParser(client), config)
+
+	result, err := service.AnalyzeURL(context.Background(), page.URL)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 6, result.Links.LinksFound)
+	assert.Equal(t, int(atomic.LoadInt32(&linkHits)), result.Links.LinksChecked)
+	assert.Less(t, result.Links.LinksChecked, 6)
+	assert.Len(t, result.Links.NotCheckedLinks, 6-result.Links.LinksChecked)
+	assert.Empty(t, result.Links.BrokenLinks)
+}
+
+func TestAnalyzeURLCapsHeadingCount(t *testing.T) {
+	var body strings.Builder
+	body.WriteString(`<html><head><title>Headings</title></head><body><h1>Top</h1>`)
+	for i := 0; i < 500; i++ {
+		fmt.Fprintf(&body, `<h2>Section %d</h2>`, i)
+	}
+	body.WriteString(`</body></html>`)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body.String()))
+	}))
+	defer server.Close()
+
+	config := getTestConfig()
+	config.MaxHeadings = 100
+	client := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	service := NewAnalyzerService(client, NewHTMLParser(client), config)
+
+	result, err := service.AnalyzeURL(context.Background(), server.URL)
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]int{"h1": 1, "h2": 99}, result.Headings)
+	assert.Len(t, result.HeadingOutline, 100)
+	assert.Contains(t, result.Warnings, WarningHeadingsTruncated)
+	assert.NotContains(t, result.Warnings, WarningNoH1)
+}
+
+func TestParseFlagsContentThatIsNotHTML(t *testing.T) {
+	parser := NewHTMLParser(nil)
+
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"binary", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\xff\xfe", true},
+		{"plain text", "just some text << >> without any markup", true},
+		{"truncated markup", "<", true},
+		{"tag soup with a heading", "<h1>Broken<p><b>unclosed", false},
+		{"markup without content", "<html><body><div></div></body></html>", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := parser.Parse(tt.content, "https://example.com")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, parsed.LowConfidence)
+		})
+	}
+}
+
+func TestAnalyzeURLWarnsOnBinaryContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte{0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0xcb, 0x48})
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	service := NewAnalyzerService(client, NewHTMLParser(client), getTestConfig())
+
+	result, err := service.AnalyzeURL(context.Background(), server.URL)
+	assert.NoError(t, err)
+	assert.True(t, result.LowConfidence)
+	assert.Contains(t, result.Warnings, WarningNotHTML)
+}
+
+func TestAnalyzeURLPropagatesTraceparent(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("traceparent")
+		_, _ = w.Write([]byte(`<html><head><title>Traced</title></head><body><h1>Traced</h1></body></html>`))
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	service := NewAnalyzerService(client, NewHTMLParser(client), getTestConfig())
+
+	incoming, _ := tracing.Parse("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	_, err := service.AnalyzeURL(tracing.WithTraceparent(context.Background(), incoming), server.URL)
+	assert.NoError(t, err)
+
+	outbound, ok := tracing.Parse(received)
+	assert.True(t, ok)
+	assert.Equal(t, incoming.TraceID, outbound.TraceID)
+	assert.NotEqual(t, incoming.ParentID, outbound.ParentID)
+
+	received = ""
+	_, err = service.AnalyzeURL(context.Background(), server.URL)
+	assert.NoError(t, err)
+	assert.Empty(t, received)
+}
+
+func TestLinkCheckConcurrencyBounds(t *testing.T) {
+	adaptive := getTestConfig()
+	adaptive.AdaptiveLinkChecks = true
+	adaptive.MinConcurrentLinkChecks = 2
+	service := NewAnalyzerService(nil, NewHTMLParser(nil), adaptive).(*analyzerService)
+
+	tests := []struct {
+		links    int
+		expected int
+	}{
+		{0, 2},
+		{1, 2},
+		{5, 2},
+		{25, 6},
+		{49, 9},
+		{50, 10},
+		{5000, 10},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, service.linkCheckConcurrency(tt.links), "links=%d", tt.links)
+	}
+
+	fixed := NewAnalyzerService(nil, NewHTMLParser(nil), getTestConfig()).(*analyzerService)
+	assert.Equal(t, 10, fixed.linkCheckConcurrency(1))
+	assert.Equal(t, 10, fixed.linkCheckConcurrency(5000))
+}
+
+func TestLinkCheckConcurrencyClampsMinimumToMaximum(t *testing.T) {
+	config := getTestConfig()
+	config.AdaptiveLinkChecks = true
+	config.MinConcurrentLinkChecks = 50
+	service := NewAnalyzerService(nil, NewHTMLParser(nil), config).(*analyzerService)
+
+	assert.Equal(t, 10, service.linkCheckConcurrency(0))
+	assert.Equal(t, 10, service.linkCheckConcurrency(50))
+}
+
+func TestAnalyzeURLBoundsConcurrentLinkChecks(t *testing.T) {
+	var active, peak atomic.Int32
+	var body strings.Builder
+	body.WriteString(`<html><head><title>Links</title></head><body><h1>Links</h1>`)
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(&body, `<a href="/link-%d">Link %d</a>`, i, i)
+	}
+	body.WriteString(`</body></html>`)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/link-") {
+			_, _ = w.Write([]byte(body.String()))
+			return
+		}
+		current := active.Add(1)
+		defer active.Add(-1)
+		for {
+			seen := peak.Load()
+			if current <= seen || peak.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+		time.Sleep(30 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		adaptive bool
+		expected int32
+	}{
+		// 20 of 50 links scale 2..10 concurrent checks down to 5
+		{"adaptive", true, 5},
+		{"fixed", false, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			peak.Store(0)
+			config := getTestConfig()
+			config.AdaptiveLinkChecks = tt.adaptive
+			config.MinConcurrentLinkChecks = 2
+			client := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+			service := NewAnalyzerService(client, NewHTMLParser(client), config)
+
+			result, err := service.AnalyzeURL(context.Background(), server.URL)
+			require.NoError(t, err)
+
+			assert.Equal(t, 20, result.Links.LinksChecked)
+			assert.Empty(t, result.Links.BrokenLinks)
+			assert.LessOrEqual(t, peak.Load(), tt.expected)
+			assert.Greater(t, peak.Load(), int32(1))
+		})
+	}
+}
+
+func TestValidateURLReasons(t *testing.T) {
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	config := getTestConfig()
+	config.MaxURLLength = 40
+	service := NewAnalyzerService(httpClient, NewHTMLParser(httpClient), config)
+
+	tests := []struct {
+		url  string
+		want ValidationReason
+	}{
+		{"", ValidationReasonEmpty},
+		{"https://example.com/" + strings.Repeat("a", 40), ValidationReasonTooLong},
+		{"ftp://example.com", ValidationReasonBadScheme},
+		{"data:text/html,hi", ValidationReasonBadScheme},
+		{"invalid-url", ValidationReasonNoHost},
+		{"https://example..com", ValidationReasonNoHost},
+		{"https://exa mple.com", ValidationReasonParseError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			err := service.ValidateURL(tt.url)
+			require.Error(t, err)
+			assert.ErrorIs(t, err, ErrInvalidURL)
+
+			reason, ok := ValidationFailureReason(err)
+			assert.True(t, ok)
+			assert.Equal(t, tt.want, reason)
+		})
+	}
+
+	_, ok := ValidationFailureReason(service.ValidateURL("https://example.com"))
+	assert.False(t, ok)
+}
+
+func TestValidateURLAllowedDomains(t *testing.T) {
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	config := getTestConfig()
+	config.AllowedDomains = []string{" Example.com ", ".example.org"}
+	service := NewAnalyzerService(httpClient, NewHTMLParser(httpClient), config)
+
+	for _, allowed := range []string{
+		"https://example.com",
+		"https://EXAMPLE.com/path",
+		"https://www.example.com",
+		"https://a.b.example.org:8443/",
+	} {
+		assert.NoError(t, service.ValidateURL(allowed), allowed)
+	}
+
+	for _, disallowed := range []string{
+		"https://example.net",
+		"https://notexample.com",
+		"https://example.com.evil.net",
+	} {
+		err := service.ValidateURL(disallowed)
+		require.Error(t, err, disallowed)
+		assert.ErrorIs(t, err, ErrDomainNotAllowed)
+		assert.ErrorIs(t, err, ErrInvalidURL)
+
+		reason, ok := ValidationFailureReason(err)
+		assert.True(t, ok)
+		assert.Equal(t, ValidationReasonNotAllowed, reason)
+	}
+}
+
+func TestAnalyzeURLRejectsRedirectOutsideAllowedDomains(t *testing.T) {
+	var outsideHits atomic.Int32
+	outside := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		outsideHits.Add(1)
+		_, _ = w.Write([]byte(`<html><head><title>Elsewhere</title></head></html>`))
+	}))
+	defer outside.Close()
+
+	allowed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, outside.URL, http.StatusFound)
+	}))
+	defer allowed.Close()
+
+	// both servers listen on 127.0.0.1; only "localhost" is allowed
+	allowedURL := strings.Replace(allowed.URL, "127.0.0.1", "localhost", 1)
+	config := getTestConfig()
+	config.AllowedDomains = []string{"localhost"}
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	service := NewAnalyzerService(httpClient, NewHTMLParser(httpClient), config)
+
+	_, err := service.AnalyzeURL(context.Background(), allowedURL)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrDomainNotAllowed)
+	assert.Zero(t, outsideHits.Load())
+}
+
+func TestValidateURLEmptyAllowedDomainsAllowsAll(t *testing.T) {
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	service := NewAnalyzerService(httpClient, NewHTMLParser(httpClient), getTestConfig())
+
+	assert.NoError(t, service.ValidateURL("https://example.net"))
+}
+
+func TestAnalyzeURLCapturesConfiguredResponseHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "nginx")
+		w.Header().Set("X-Powered-By", "PHP/8.2")
+		w.Header().Add("Cache-Control", "no-cache")
+		w.Header().Add("Cache-Control", "private")
+		w.Header().Set("X-Long", strings.Repeat("v", 300))
+		_, _ = w.Write([]byte("<html><head><title>Headers</title></head><body><h1>Hi</h1></body></html>"))
+	}))
+	defer server.Close()
+
+	config := getTestConfig()
+	config.CaptureResponseHeaders = []string{"server", "Cache-Control", "Strict-Transport-Security", "X-Long"}
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	service := NewAnalyzerService(httpClient, NewHTMLParser(httpClient), config)
+
+	result, err := service.AnalyzeURL(context.Background(), server.URL)
+	require.NoError(t, err)
+
+	assert.Equal(t, "nginx", result.ResponseHeaders["server"])
+	assert.Equal(t, "no-cache, private", result.ResponseHeaders["cache-control"])
+	assert.Len(t, result.ResponseHeaders["x-long"], entities.MaxMetadataValueLength)
+	assert.NotContains(t, result.ResponseHeaders, "strict-transport-security")
+	assert.NotContains(t, result.ResponseHeaders, "x-powered-by")
+}
+
+func TestAnalyzeURLCapturesNoHeadersByDefault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "nginx")
+		_, _ = w.Write([]byte("<html><head><title>Headers</title></head><body></body></html>"))
+	}))
+	defer server.Close()
+
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	service := NewAnalyzerService(httpClient, NewHTMLParser(httpClient), getTestConfig())
+
+	result, err := service.AnalyzeURL(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Empty(t, result.ResponseHeaders)
+}
+
+func TestFetchContextKeepsCallerDeadline(t *testing.T) {
+	parent, cancelParent := context.WithTimeout(context.Background(), 2*DefaultRequestTimeout)
+	defer cancelParent()
+
+	ctx, cancel := fetchContext(parent)
+	defer cancel()
+	want, _ := parent.Deadline()
+	got, ok := ctx.Deadline()
+	require.True(t, ok)
+	assert.Equal(t, want, got, "a caller's longer deadline is not cut to DefaultRequestTimeout")
+
+	ctx, cancel = fetchContext(context.Background())
+	defer cancel()
+	got, ok = ctx.Deadline()
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(DefaultRequestTimeout), got, time.Second)
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// ErrNotModified is returned by AnalyzeURL when the page answered a
+// conditional request with 304, so the previous analysis still applies. The
+// returned result carries only the status code and timings.
+var ErrNotModified = errors.New("page not modified since the previous analysis")
+
+// Validators identify the version of a page seen by an earlier analysis.
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+func (v Validators) empty() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
+type validatorsKey struct{}
+
+// WithValidators makes the page fetch for ctx a conditional request, so an
+// unchanged page answers 304 instead of being downloaded again.
+func WithValidators(ctx context.Context, validators Validators) context.Context {
+	if validators.empty() {
+		return ctx
+	}
+	return context.WithValue(ctx, validatorsKey{}, validators)
+}
+
+// setConditionalHeaders adds If-None-Match and If-Modified-Since from the
+// validators on ctx, if any.
+func setConditionalHeaders(ctx context.Context, req *http.Request) {
+	validators, ok := ctx.Value(validatorsKey{}).(Validators)
+	if !ok {
+		return
+	}
+	if validators.ETag != "" {
+		req.Header.Set(HeaderIfNoneMatch, validators.ETag)
+	}
+	if validators.LastModified != "" {
+		req.Header.Set(HeaderIfModifiedSince, validators.LastModified)
+	}
+}
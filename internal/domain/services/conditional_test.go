@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testETag         = `"v1"`
+	testLastModified = "Wed, 21 Oct 2026 07:28:00 GMT"
+)
+
+func newConditionalServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(HeaderIfNoneMatch) == testETag || r.Header.Get(HeaderIfModifiedSince) == testLastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set(HeaderETag, testETag)
+		w.Header().Set(HeaderLastModified, testLastModified)
+		_, _ = w.Write([]byte("<html><head><title>Cached</title></head><body><h1>Hi</h1></body></html>"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAnalyzeURLRecordsValidators(t *testing.T) {
+	server := newConditionalServer(t)
+
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	service := NewAnalyzerService(httpClient, NewHTMLParser(httpClient), getTestConfig())
+	result, err := service.AnalyzeURL(context.Background(), server.URL)
+	require.NoError(t, err)
+
+	assert.Equal(t, testETag, result.ETag)
+	assert.Equal(t, testLastModified, result.LastModified)
+	assert.False(t, result.NotModified)
+}
+
+func TestAnalyzeURLNotModified(t *testing.T) {
+	server := newConditionalServer(t)
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	service := NewAnalyzerService(httpClient, NewHTMLParser(httpClient), getTestConfig())
+
+	for name, validators := range map[string]Validators{
+		"etag":          {ETag: testETag},
+		"last modified": {LastModified: testLastModified},
+	} {
+		t.Run(name, func(t *testing.T) {
+			result, err := service.AnalyzeURL(WithValidators(context.Background(), validators), server.URL)
+
+			assert.ErrorIs(t, err, ErrNotModified)
+			require.NotNil(t, result)
+			assert.Equal(t, http.StatusNotModified, result.StatusCode)
+			assert.Equal(t, result.LoadTime, result.FetchTime)
+			assert.Empty(t, result.Title)
+		})
+	}
+}
+
+func TestAnalyzeURLStaleValidators(t *testing.T) {
+	server := newConditionalServer(t)
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	service := NewAnalyzerService(httpClient, NewHTMLParser(httpClient), getTestConfig())
+
+	ctx := WithValidators(context.Background(), Validators{ETag: `"v0"`})
+	result, err := service.AnalyzeURL(ctx, server.URL)
+
+	require.NoError(t, err)
+	assert.Equal(t, "Cached", result.Title)
+	assert.Equal(t, testETag, result.ETag)
+}
@@ -5,40 +5,139 @@ import "time"
 const (
 	MaxContentSize             = 10 * 1024 * 1024 // 10MB
 	MaxURLLength               = 2048
+	MaxDataURLLength           = MaxContentSize
 	MaxHTMLDepth               = 100
 	DefaultMaxConcurrentChecks = 10
+	DefaultMinConcurrentChecks = 2
 	DefaultRequestTimeout      = 60 * time.Second
 	DefaultLinkCheckTimeout    = 20 * time.Second
+	DefaultFetchRetryBackoff   = 200 * time.Millisecond
+	DefaultMaxHTMLNodes        = 200000
+	DefaultMaxTitleLength      = 512
+	DefaultMaxHeadings         = 10000
+	DefaultMaxIdleConns        = 100
+	DefaultMaxIdleConnsPerHost = 10
+	DefaultIdleConnTimeout     = 90 * time.Second
+	DefaultMinTLSVersion       = "1.2"
 	UserAgent                  = "WebPageAnalyzer/1.0"
 
+	// HTML parser implementations, selected by analysis.html_parser
+	HTMLParserTree      = "tree"
+	HTMLParserStreaming = "streaming"
+
 	// HTTP methods
 	HTTPMethodGET  = "GET"
 	HTTPMethodHEAD = "HEAD"
 
 	// HTML elements
-	HTMLElementTitle  = "title"
-	HTMLElementA      = "a"
-	HTMLElementH1     = "h1"
-	HTMLElementH2     = "h2"
-	HTMLElementH3     = "h3"
-	HTMLElementH4     = "h4"
-	HTMLElementH5     = "h5"
-	HTMLElementH6     = "h6"
-	HTMLElementButton = "button"
-	HTMLElementLabel  = "label"
-	HTMLElementSpan   = "span"
-	HTMLElementDiv    = "div"
-	HTMLElementP      = "p"
-	HTMLElementLegend = "legend"
+	HTMLElementTitle    = "title"
+	HTMLElementA        = "a"
+	HTMLElementH1       = "h1"
+	HTMLElementH2       = "h2"
+	HTMLElementH3       = "h3"
+	HTMLElementH4       = "h4"
+	HTMLElementH5       = "h5"
+	HTMLElementH6       = "h6"
+	HTMLElementButton   = "button"
+	HTMLElementLabel    = "label"
+	HTMLElementSpan     = "span"
+	HTMLElementDiv      = "div"
+	HTMLElementP        = "p"
+	HTMLElementLegend   = "legend"
+	HTMLElementMeta     = "meta"
+	HTMLElementForm     = "form"
+	HTMLElementInput    = "input"
+	HTMLElementSelect   = "select"
+	HTMLElementTextarea = "textarea"
+	HTMLElementScript   = "script"
+	HTMLElementLink     = "link"
+	HTMLElementNoscript = "noscript"
+	HTMLElementTemplate = "template"
+	HTMLElementImg      = "img"
+	HTMLElementIframe   = "iframe"
+
+	// ScriptTypeJSONLD marks a script holding JSON-LD structured data
+	ScriptTypeJSONLD = "application/ld+json"
 
 	// HTML attributes
-	HTMLAttrHref = "href"
+	HTMLAttrHref         = "href"
+	HTMLAttrID           = "id"
+	HTMLAttrName         = "name"
+	HTMLAttrContent      = "content"
+	HTMLAttrMethod       = "method"
+	HTMLAttrAction       = "action"
+	HTMLAttrType         = "type"
+	HTMLAttrAutocomplete = "autocomplete"
+	HTMLAttrForm         = "form"
+	HTMLAttrSrc          = "src"
+	HTMLAttrLang         = "lang"
+	HTMLAttrAsync        = "async"
+	HTMLAttrDefer        = "defer"
+	HTMLAttrRel          = "rel"
+	HTMLAttrMedia        = "media"
+	HTMLAttrDisabled     = "disabled"
+
+	// Link relations that affect render blocking
+	LinkRelStylesheet = "stylesheet"
+	LinkRelAlternate  = "alternate"
+
+	// Form defaults per the HTML spec
+	FormMethodGET     = "get"
+	InputTypeText     = "text"
+	InputTypePassword = "password"
+
+	AutocompleteOff         = "off"
+	AutocompleteNewPassword = "new-password"
+
+	MetaNameDescription = "description"
+
+	// charsetPrescanSize mirrors the 1024-byte window browsers scan for a
+	// <meta> charset declaration.
+	charsetPrescanSize = 1024
 
 	// Link types
 	LinkTypeEmail  = "email"
 	LinkTypeURL    = "url"
 	LinkTypeTel    = "tel"
 	LinkTypeSearch = "search"
+
+	// Broken link reasons
+	LinkReasonMissingAnchor = "missing anchor"
+
+	// Page quality warnings
+	WarningMultipleH1 = "multiple h1 tags"
+	WarningNoH1       = "no h1 tag"
+	WarningNoTitle    = "no title"
+
+	// WarningHeadingsTruncated means headings past the configured cap were
+	// not counted.
+	WarningHeadingsTruncated = "heading count truncated"
+	// WarningNotHTML marks content that parsed without any recognizable
+	// structure, such as binary data or plain text.
+	WarningNotHTML = "content may not be HTML"
+
+	// Security warnings
+	WarningPasswordAutocomplete = "password field allows autocomplete"
+
+	// WarningInvalidStructuredData means a JSON-LD block could not be parsed
+	// and its types are missing from StructuredData.
+	WarningInvalidStructuredData = "invalid JSON-LD structured data"
+
+	// Security response headers graded in AnalysisResult.SecurityHeaders
+	HeaderStrictTransportSecurity = "Strict-Transport-Security"
+	HeaderContentSecurityPolicy   = "Content-Security-Policy"
+	HeaderXFrameOptions           = "X-Frame-Options"
+	HeaderXContentTypeOptions     = "X-Content-Type-Options"
+	HeaderReferrerPolicy          = "Referrer-Policy"
+
+	// Conditional request headers
+	HeaderETag            = "ETag"
+	HeaderLastModified    = "Last-Modified"
+	HeaderIfNoneMatch     = "If-None-Match"
+	HeaderIfModifiedSince = "If-Modified-Since"
+
+	// Result notes
+	NoteEmptyBody = "empty response body"
 )
 
 var (
@@ -51,4 +150,30 @@ var (
 		HTMLElementH4, HTMLElementH5, HTMLElementH6, HTMLElementLabel, HTMLElementSpan,
 		HTMLElementDiv, HTMLElementP, HTMLElementLegend, HTMLElementTitle,
 	}
+
+	// Trackers maps analytics and tracking services to URL fragments that
+	// identify them. Fragments are matched case-insensitively against the
+	// src of scripts, images and iframes and against inline script and
+	// noscript text, which is where pixel fallbacks live.
+	Trackers = []Tracker{
+		{Name: "Google Analytics", Patterns: []string{"google-analytics.com/analytics.js", "google-analytics.com/ga.js", "googletagmanager.com/gtag/js"}},
+		{Name: "Google Tag Manager", Patterns: []string{"googletagmanager.com/gtm.js", "googletagmanager.com/ns.html"}},
+		{Name: "Facebook Pixel", Patterns: []string{"connect.facebook.net/", "facebook.com/tr?", "facebook.com/tr/"}},
+		{Name: "LinkedIn Insight Tag", Patterns: []string{"snap.licdn.com/li.lms-analytics", "px.ads.linkedin.com/collect"}},
+		{Name: "Twitter Pixel", Patterns: []string{"static.ads-twitter.com/uwt.js", "analytics.twitter.com/i/adsct"}},
+		{Name: "TikTok Pixel", Patterns: []string{"analytics.tiktok.com/i18n/pixel"}},
+		{Name: "Hotjar", Patterns: []string{"static.hotjar.com/"}},
+		{Name: "Microsoft Clarity", Patterns: []string{"clarity.ms/tag/"}},
+		{Name: "Segment", Patterns: []string{"cdn.segment.com/analytics.js"}},
+		{Name: "Mixpanel", Patterns: []string{"cdn.mxpnl.com/", "mixpanel.com/track"}},
+	}
+
+	// DeprecatedElements are obsolete in the HTML Living Standard and flag
+	// legacy markup.
+	DeprecatedElements = map[string]bool{
+		"acronym": true, "applet": true, "basefont": true, "big": true,
+		"blink": true, "center": true, "dir": true, "font": true,
+		"frame": true, "frameset": true, "isindex": true, "marquee": true,
+		"noframes": true, "strike": true, "tt": true,
+	}
 )
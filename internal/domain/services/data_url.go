@@ -0,0 +1,59 @@
+package services
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/url"
+	"strings"
+)
+
+const dataURLScheme = "data:"
+
+func isDataURL(targetURL string) bool {
+	return len(targetURL) >= len(dataURLScheme) && strings.EqualFold(targetURL[:len(dataURLScheme)], dataURLScheme)
+}
+
+// decodeDataURL decodes an RFC 2397 data URL carrying HTML and returns the
+// body with its media type, so the charset parameter can drive decoding
+// just like a Content-Type header would.
+func decodeDataURL(targetURL string) ([]byte, string, error) {
+	if len(targetURL) > MaxDataURLLength {
+		return nil, "", fmt.Errorf("data URL too long (max %d bytes)", MaxDataURLLength)
+	}
+
+	header, payload, found := strings.Cut(targetURL[len(dataURLScheme):], ",")
+	if !found {
+		return nil, "", fmt.Errorf("data URL is missing the ',' separator")
+	}
+
+	isBase64 := false
+	if strings.HasSuffix(strings.ToLower(header), ";base64") {
+		isBase64 = true
+		header = header[:len(header)-len(";base64")]
+	}
+
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err != nil || mediaType != "text/html" {
+		return nil, "", fmt.Errorf("only text/html data URLs are supported")
+	}
+
+	unescaped, err := url.PathUnescape(payload)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid data URL encoding: %w", err)
+	}
+
+	content := []byte(unescaped)
+	if isBase64 {
+		content, err = base64.StdEncoding.DecodeString(unescaped)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid base64 in data URL: %w", err)
+		}
+	}
+
+	if len(content) > MaxContentSize {
+		return nil, "", fmt.Errorf("data URL content too large (max %d bytes)", MaxContentSize)
+	}
+
+	return content, header, nil
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const dataURLPage = `<html><head><title>Data Page</title></head><body><h1>Hello</h1><h2>World</h2></body></html>`
+
+func newDataURLService(allow bool) AnalyzerService {
+	config := getTestConfig()
+	config.AllowDataURLs = allow
+	client := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	return NewAnalyzerService(client, NewHTMLParser(client), config)
+}
+
+func TestAnalyzeURLPlainDataURL(t *testing.T) {
+	service := newDataURLService(true)
+
+	result, err := service.AnalyzeURL(context.Background(), "data:text/html;charset=utf-8,"+strings.ReplaceAll(dataURLPage, " ", "%20"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Data Page", result.Title)
+	assert.Equal(t, 1, result.Headings["h1"])
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+}
+
+func TestAnalyzeURLBase64DataURL(t *testing.T) {
+	service := newDataURLService(true)
+
+	result, err := service.AnalyzeURL(context.Background(), "data:text/html;base64,"+base64.StdEncoding.EncodeToString([]byte(dataURLPage)))
+	assert.NoError(t, err)
+	assert.Equal(t, "Data Page", result.Title)
+	assert.Equal(t, 1, result.Headings["h2"])
+}
+
+func TestDataURLsDisabledByDefault(t *testing.T) {
+	service := newDataURLService(false)
+
+	err := service.ValidateURL("data:text/html,<h1>Hi</h1>")
+	assert.ErrorIs(t, err, ErrInvalidURL)
+	assert.Contains(t, err.Error(), "not enabled")
+}
+
+func TestDecodeDataURLRejectsInvalidInput(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"missing separator", "data:text/html"},
+		{"wrong media type", "data:text/plain,hello"},
+		{"bad base64", "data:text/html;base64,!!!"},
+		{"too long", "data:text/html," + strings.Repeat("a", MaxDataURLLength)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := decodeDataURL(tt.url)
+			assert.Error(t, err)
+		})
+	}
+}
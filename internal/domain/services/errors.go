@@ -0,0 +1,91 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error kinds let callers map analysis failures without parsing messages.
+// Use errors.Is to test for them; the original message is preserved.
+var (
+	ErrInvalidURL        = errors.New("invalid URL")
+	ErrTargetUnreachable = errors.New("target unreachable")
+	ErrTargetTimeout     = errors.New("target timed out")
+	// ErrDomainNotAllowed marks URLs whose host is outside
+	// AnalyzerConfig.AllowedDomains; it also matches ErrInvalidURL.
+	ErrDomainNotAllowed = errors.New("domain not allowed")
+)
+
+// TargetStatusError is returned when the analyzed page answers with a
+// non-200 status code.
+type TargetStatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *TargetStatusError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Message)
+}
+
+// ValidationReason classifies why a URL failed validation, for metrics and
+// callers that need more than the message.
+type ValidationReason string
+
+const (
+	ValidationReasonEmpty      ValidationReason = "empty"
+	ValidationReasonTooLong    ValidationReason = "too_long"
+	ValidationReasonBadScheme  ValidationReason = "bad_scheme"
+	ValidationReasonNoHost     ValidationReason = "no_host"
+	ValidationReasonParseError ValidationReason = "parse_error"
+	ValidationReasonNotAllowed ValidationReason = "domain_not_allowed"
+)
+
+// ValidationError is returned by ValidateURL; its message is the underlying
+// error's, so responses read the same as before reasons were attached.
+type ValidationError struct {
+	Reason ValidationReason
+	Err    error
+}
+
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+func invalidURL(reason ValidationReason, err error) error {
+	return &ValidationError{Reason: reason, Err: err}
+}
+
+// ValidationFailureReason returns the reason err failed URL validation, if
+// it is or wraps a ValidationError.
+func ValidationFailureReason(err error) (ValidationReason, bool) {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Reason, true
+	}
+	return "", false
+}
+
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+func (e *kindError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// withKind tags err with one of the Err* kinds while keeping its message.
+func withKind(kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &kindError{kind: kind, err: err}
+}
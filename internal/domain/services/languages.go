@@ -0,0 +1,55 @@
+package services
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// languageSet collects distinct lang attribute values in document order.
+// Values are lower-cased, since language tags are case-insensitive.
+type languageSet struct {
+	tags []string
+	seen map[string]bool
+}
+
+func newLanguageSet() *languageSet {
+	return &languageSet{tags: make([]string, 0), seen: make(map[string]bool)}
+}
+
+// observeElement records the element's lang attribute; an empty lang marks
+// the language as unknown and is skipped.
+func (s *languageSet) observeElement(attrs []html.Attribute) {
+	for _, attr := range attrs {
+		if attr.Key != HTMLAttrLang {
+			continue
+		}
+		tag := strings.ToLower(strings.TrimSpace(attr.Val))
+		if tag != "" && !s.seen[tag] {
+			s.seen[tag] = true
+			s.tags = append(s.tags, tag)
+		}
+		return
+	}
+}
+
+// extractLanguages lists the distinct lang values on the page, starting
+// with the root element's, so mixed-language content is visible.
+func (p *htmlParser) extractLanguages(doc *html.Node) []string {
+	languages := newLanguageSet()
+
+	var traverse func(*html.Node, int)
+	traverse = func(n *html.Node, depth int) {
+		if depth > MaxHTMLDepth {
+			return
+		}
+		if n.Type == html.ElementNode {
+			languages.observeElement(n.Attr)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c, depth+1)
+		}
+	}
+	traverse(doc, 0)
+	return languages.tags
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const multilingualPage = `<!DOCTYPE html>
+<html lang="en-US"><head><title>Welcome</title></head><body>
+	<h1>Welcome</h1>
+	<section lang="fr"><h2>Bienvenue</h2><p lang="FR">Bonjour</p></section>
+	<section lang="de-CH"><h2>Willkommen</h2></section>
+	<blockquote lang=""><p>Unknown language</p></blockquote>
+	<p lang="en-us">Back to English</p>
+</body></html>`
+
+func TestParseDetectsLanguages(t *testing.T) {
+	for _, parser := range []HTMLParser{NewHTMLParser(nil), NewStreamingHTMLParser(nil)} {
+		parsed, err := parser.Parse(multilingualPage, "https://example.com")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"en-us", "fr", "de-ch"}, parsed.Languages)
+	}
+}
+
+func TestParseWithoutLanguages(t *testing.T) {
+	page := `<html><body><h1>No lang</h1></body></html>`
+
+	for _, parser := range []HTMLParser{NewHTMLParser(nil), NewStreamingHTMLParser(nil)} {
+		parsed, err := parser.Parse(page, "https://example.com")
+		require.NoError(t, err)
+		assert.Empty(t, parsed.Languages)
+	}
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// LinkCheck is the shareable outcome of an HTTP link check; StatusCode is 0
+// when no response was received. NotChecked marks a link whose target was
+// never reached, such as one still redirecting past the configured cap.
+type LinkCheck struct {
+	Accessible bool `json:"accessible"`
+	StatusCode int  `json:"status_code"`
+	NotChecked bool `json:"not_checked,omitempty"`
+}
+
+// LinkCheckCache shares link checks beyond one parser, so a link checked by
+// one analysis or replica is reused by the others. Get reports false on a
+// miss. Errors are not fatal: the parser falls back to its in-memory cache
+// for the rest of that analysis.
+type LinkCheckCache interface {
+	Get(ctx context.Context, url string) (LinkCheck, bool, error)
+	Set(ctx context.Context, url string, check LinkCheck) error
+}
+
+// linkCheckCacheTimeout bounds each shared cache lookup or write so a slow
+// backend cannot stall link checking.
+const linkCheckCacheTimeout = 500 * time.Millisecond
+
+// sharedCacheScope tracks the shared cache for one analysis: after its
+// first error the rest of that analysis's link checks skip it.
+type sharedCacheScope struct {
+	failed atomic.Bool
+}
+
+type sharedCacheScopeKey struct{}
+
+// withSharedCacheScope starts a new scope for the link checks made with
+// ctx, so a shared cache error is remembered until the analysis ends.
+func withSharedCacheScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sharedCacheScopeKey{}, &sharedCacheScope{})
+}
+
+// sharedCacheFor returns the shared cache link checks made with ctx should
+// use, or nil when there is none or it already failed in this analysis.
+func (p *htmlParser) sharedCacheFor(ctx context.Context) LinkCheckCache {
+	if scope, ok := ctx.Value(sharedCacheScopeKey{}).(*sharedCacheScope); ok && scope.failed.Load() {
+		return nil
+	}
+	return p.sharedCache
+}
+
+// sharedCacheFailed marks the shared cache as failed for the analysis
+// ctx belongs to.
+func sharedCacheFailed(ctx context.Context) {
+	if scope, ok := ctx.Value(sharedCacheScopeKey{}).(*sharedCacheScope); ok {
+		scope.failed.Store(true)
+	}
+}
+
+func (p *htmlParser) SetLinkCheckCache(cache LinkCheckCache) {
+	p.sharedCache = cache
+}
+
+// cachedLinkCheck looks fullURL up in memory, then in the shared cache.
+func (p *htmlParser) cachedLinkCheck(ctx context.Context, fullURL string) (LinkCheck, bool) {
+	p.mu.RLock()
+	cached, exists := p.urlCache[fullURL]
+	p.mu.RUnlock()
+	shared := p.sharedCacheFor(ctx)
+	if exists || shared == nil {
+		return cached, exists
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, linkCheckCacheTimeout)
+	defer cancel()
+	check, found, err := shared.Get(lookupCtx, fullURL)
+	if err != nil {
+		sharedCacheFailed(ctx)
+		return LinkCheck{}, false
+	}
+	if !found {
+		return LinkCheck{}, false
+	}
+
+	p.mu.Lock()
+	p.urlCache[fullURL] = check
+	p.mu.Unlock()
+	return check, true
+}
+
+// storeLinkCheck records a check in memory and, best effort, in the shared
+// cache.
+func (p *htmlParser) storeLinkCheck(ctx context.Context, fullURL string, check LinkCheck) {
+	p.mu.Lock()
+	p.urlCache[fullURL] = check
+	p.mu.Unlock()
+
+	shared := p.sharedCacheFor(ctx)
+	if shared == nil {
+		return
+	}
+	storeCtx, cancel := context.WithTimeout(ctx, linkCheckCacheTimeout)
+	defer cancel()
+	if err := shared.Set(storeCtx, fullURL, check); err != nil {
+		sharedCacheFailed(ctx)
+	}
+}
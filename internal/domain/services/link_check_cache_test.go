@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type unavailableLinkCheckCache struct {
+	gets, sets atomic.Int32
+}
+
+func (c *unavailableLinkCheckCache) Get(ctx context.Context, url string) (LinkCheck, bool, error) {
+	c.gets.Add(1)
+	return LinkCheck{}, false, errors.New("connection refused")
+}
+
+func (c *unavailableLinkCheckCache) Set(ctx context.Context, url string, check LinkCheck) error {
+	c.sets.Add(1)
+	return errors.New("connection refused")
+}
+
+func TestLinkCheckCacheFallsBackToMemory(t *testing.T) {
+	var checks atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			checks.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	shared := &unavailableLinkCheckCache{}
+	parser := NewHTMLParser(NewHTTPClient(NewSharedHTTPClient(5*time.Second, TransportConfig{})))
+	parser.SetLinkCheckCache(shared)
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, LinkCheck{StatusCode: http.StatusNotFound}, parser.CheckLink(context.Background(), "/missing", server.URL))
+	}
+
+	assert.Equal(t, int32(1), checks.Load())
+	assert.Equal(t, int32(1), shared.gets.Load())
+	assert.Equal(t, int32(1), shared.sets.Load())
+}
+
+type ctxKey struct{}
+
+type recordingLinkCheckCache struct {
+	seen atomic.Value
+}
+
+func (c *recordingLinkCheckCache) Get(ctx context.Context, url string) (LinkCheck, bool, error) {
+	c.seen.Store(ctx.Value(ctxKey{}))
+	return LinkCheck{}, false, nil
+}
+
+func (c *recordingLinkCheckCache) Set(ctx context.Context, url string, check LinkCheck) error {
+	return nil
+}
+
+func TestLinkCheckCacheUsesLinkCheckContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	shared := &recordingLinkCheckCache{}
+	parser := NewHTMLParser(NewHTTPClient(NewSharedHTTPClient(5*time.Second, TransportConfig{})))
+	parser.SetLinkCheckCache(shared)
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "analysis")
+	parser.CheckLink(ctx, "/page", server.URL)
+
+	assert.Equal(t, "analysis", shared.seen.Load())
+}
+
+func TestLinkCheckCacheSkippedAfterFirstErrorInAnalysis(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/first", "/second":
+			_, _ = fmt.Fprintf(w, `<html><body>
+				<a href="%[1]s/one">one</a><a href="%[1]s/two">two</a><a href="%[1]s/three">three</a>
+			</body></html>`, r.URL.Path)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	shared := &unavailableLinkCheckCache{}
+	config := getTestConfig()
+	config.MaxConcurrentLinkChecks = 1
+	config.LinkCheckCache = shared
+	httpClient := NewHTTPClient(NewSharedHTTPClient(5*time.Second, TransportConfig{}))
+	service := NewAnalyzerService(httpClient, NewHTMLParser(httpClient), config)
+
+	for _, page := range []string{"/first", "/second"} {
+		result, err := service.AnalyzeURL(context.Background(), server.URL+page)
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.Links.Internal)
+	}
+
+	// one failed lookup per analysis; the rest of its links skip the cache
+	assert.Equal(t, int32(2), shared.gets.Load())
+	assert.Equal(t, int32(0), shared.sets.Load())
+}
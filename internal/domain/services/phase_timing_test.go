@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeURLPhaseTimes(t *testing.T) {
+	const fetchDelay, linkDelay = 40 * time.Millisecond, 60 * time.Millisecond
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow-link" {
+			time.Sleep(linkDelay)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		time.Sleep(fetchDelay)
+		_, _ = w.Write([]byte(`<html><head><title>Timed</title></head><body><h1>Hi</h1><a href="/slow-link">link</a></body></html>`))
+	}))
+	defer server.Close()
+
+	for _, kind := range []string{HTMLParserTree, HTMLParserStreaming} {
+		t.Run(kind, func(t *testing.T) {
+			httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+			parser, err := NewHTMLParserOfKind(kind, httpClient)
+			require.NoError(t, err)
+			service := NewAnalyzerService(httpClient, parser, getTestConfig())
+			result, err := service.AnalyzeURL(context.Background(), server.URL)
+			require.NoError(t, err)
+
+			assert.GreaterOrEqual(t, result.FetchTime, fetchDelay)
+			assert.GreaterOrEqual(t, result.LinkCheckTime, linkDelay)
+			assert.Positive(t, result.ParseTime)
+			assert.Less(t, result.ParseTime, linkDelay)
+
+			phases := result.FetchTime + result.ParseTime + result.LinkCheckTime
+			assert.LessOrEqual(t, phases, result.LoadTime)
+			assert.InDelta(t, float64(result.LoadTime), float64(phases), float64(10*time.Millisecond))
+		})
+	}
+}
+
+func TestAnalyzeURLPhaseTimesForErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	service := NewAnalyzerService(httpClient, NewHTMLParser(httpClient), getTestConfig())
+	result, err := service.AnalyzeURL(context.Background(), server.URL)
+	require.Error(t, err)
+
+	assert.Equal(t, result.LoadTime, result.FetchTime)
+	assert.Zero(t, result.ParseTime)
+	assert.Zero(t, result.LinkCheckTime)
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// classicScriptTypes are the script types a browser executes as a classic,
+// parser-blocking script. Modules are deferred by default, and data blocks
+// such as JSON-LD are never executed.
+var classicScriptTypes = map[string]bool{
+	"":                         true,
+	"text/javascript":          true,
+	"application/javascript":   true,
+	"application/x-javascript": true,
+	"text/ecmascript":          true,
+	"application/ecmascript":   true,
+}
+
+// headElements may appear in <head>; any other start tag implicitly ends it.
+var headElements = map[string]bool{
+	"base": true, HTMLElementLink: true, HTMLElementMeta: true, HTMLElementNoscript: true,
+	HTMLElementScript: true, "style": true, HTMLElementTemplate: true, HTMLElementTitle: true,
+}
+
+// countRenderBlockingResources counts the external scripts and stylesheets
+// in <head> that hold up first paint: classic scripts without async or
+// defer, and stylesheets that apply to screen media.
+func (p *htmlParser) countRenderBlockingResources(doc *html.Node) int {
+	head := findElement(doc, "head", 0)
+	if head == nil {
+		return 0
+	}
+
+	count := 0
+	for c := head.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && isRenderBlocking(c.Data, c.Attr) {
+			count++
+		}
+	}
+	return count
+}
+
+func findElement(n *html.Node, tag string, depth int) *html.Node {
+	if depth > MaxHTMLDepth {
+		return nil
+	}
+	if n.Type == html.ElementNode && n.Data == tag {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, tag, depth+1); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// isRenderBlocking reports whether a <head> element loads a resource the
+// browser must fetch before it can render the page.
+func isRenderBlocking(tag string, attrs []html.Attribute) bool {
+	switch tag {
+	case HTMLElementScript:
+		return isBlockingScript(attrs)
+	case HTMLElementLink:
+		return isBlockingStylesheet(attrs)
+	}
+	return false
+}
+
+func isBlockingScript(attrs []html.Attribute) bool {
+	hasSrc := false
+	for _, attr := range attrs {
+		switch attr.Key {
+		case HTMLAttrSrc:
+			hasSrc = strings.TrimSpace(attr.Val) != ""
+		case HTMLAttrAsync, HTMLAttrDefer:
+			return false
+		case HTMLAttrType:
+			mediaType, _, _ := strings.Cut(attr.Val, ";")
+			if !classicScriptTypes[strings.ToLower(strings.TrimSpace(mediaType))] {
+				return false
+			}
+		}
+	}
+	return hasSrc
+}
+
+func isBlockingStylesheet(attrs []html.Attribute) bool {
+	stylesheet, hasHref := false, false
+	for _, attr := range attrs {
+		switch attr.Key {
+		case HTMLAttrRel:
+			for _, rel := range strings.Fields(strings.ToLower(attr.Val)) {
+				switch rel {
+				case LinkRelStylesheet:
+					stylesheet = true
+				case LinkRelAlternate:
+					// alternate stylesheets are fetched without blocking
+					return false
+				}
+			}
+		case HTMLAttrHref:
+			hasHref = strings.TrimSpace(attr.Val) != ""
+		case HTMLAttrMedia:
+			// print and other non-matching media load in the background
+			switch strings.ToLower(strings.TrimSpace(attr.Val)) {
+			case "", "all", "screen":
+			default:
+				return false
+			}
+		case HTMLAttrDisabled:
+			return false
+		}
+	}
+	return stylesheet && hasHref
+}
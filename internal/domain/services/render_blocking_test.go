@@ -0,0 +1,55 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCountsRenderBlockingResources(t *testing.T) {
+	tests := []struct {
+		name     string
+		head     string
+		expected int
+	}{
+		{"sync script", `<script src="/app.js"></script>`, 1},
+		{"async script", `<script src="/app.js" async></script>`, 0},
+		{"defer script", `<script src="/app.js" defer></script>`, 0},
+		{"async and defer script", `<script src="/app.js" async defer></script>`, 0},
+		{"module script", `<script type="module" src="/app.js"></script>`, 0},
+		{"explicit javascript type", `<script type="text/javascript" src="/app.js"></script>`, 1},
+		{"inline script", `<script>var x = 1;</script>`, 0},
+		{"json-ld script", `<script type="application/ld+json">{}</script>`, 0},
+		{"mixed scripts", `<script src="/a.js"></script><script src="/b.js" async></script><script src="/c.js"></script><script src="/d.js" defer></script>`, 2},
+		{"stylesheet", `<link rel="stylesheet" href="/site.css">`, 1},
+		{"screen stylesheet", `<link rel="stylesheet" href="/site.css" media="screen">`, 1},
+		{"print stylesheet", `<link rel="stylesheet" href="/print.css" media="print">`, 0},
+		{"alternate stylesheet", `<link rel="alternate stylesheet" href="/dark.css">`, 0},
+		{"preload", `<link rel="preload" href="/site.css" as="style">`, 0},
+		{"scripts and stylesheets", `<link rel="stylesheet" href="/site.css"><script src="/app.js"></script><script src="/late.js" defer></script>`, 2},
+		{"noscript fallback", `<noscript><link rel="stylesheet" href="/noscript.css"></noscript>`, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := `<!DOCTYPE html><html><head><title>Page</title>` + tt.head +
+				`</head><body><h1>Page</h1><script src="/footer.js"></script></body></html>`
+
+			for _, parser := range []HTMLParser{NewHTMLParser(nil), NewStreamingHTMLParser(nil)} {
+				parsed, err := parser.Parse(page, "https://example.com")
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, parsed.RenderBlockingResources)
+			}
+		})
+	}
+}
+
+func TestParseCountsRenderBlockingResourcesInImpliedHead(t *testing.T) {
+	page := `<script src="/app.js"></script><p>Body starts</p><script src="/late.js"></script>`
+
+	for _, parser := range []HTMLParser{NewHTMLParser(nil), NewStreamingHTMLParser(nil)} {
+		parsed, err := parser.Parse(page, "https://example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, parsed.RenderBlockingResources)
+	}
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+// hostResolver is the DNS configuration a shared transport dials with.
+type hostResolver struct {
+	resolver *net.Resolver
+	timeout  time.Duration
+}
+
+// baseDialer matches the dialer http.Transport uses when none is set.
+var baseDialer net.Dialer
+
+// ValidateDNSServer reports an error unless address is empty or a host:port
+// pair. Call it at startup, since transports built with an invalid server
+// fail every lookup rather than falling back to the system resolver.
+func ValidateDNSServer(address string) error {
+	if address == "" {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return fmt.Errorf("invalid DNS server %q: %w", address, err)
+	}
+	return nil
+}
+
+// newHostResolver resolves hostnames through the DNS server at address
+// (host:port), or the system resolver when address is empty. timeout > 0
+// bounds each lookup. It returns nil when neither is set, leaving dials to
+// baseDialer.
+func newHostResolver(address string, timeout time.Duration) *hostResolver {
+	if address == "" && timeout <= 0 {
+		return nil
+	}
+
+	resolver := net.DefaultResolver
+	if address != "" {
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return baseDialer.DialContext(ctx, network, address)
+			},
+		}
+	}
+	return &hostResolver{resolver: resolver, timeout: timeout}
+}
+
+// dialContext resolves address with r, then dials the returned IPs in order
+// until one connects.
+func (r *hostResolver) dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || net.ParseIP(host) != nil {
+		return baseDialer.DialContext(ctx, network, address)
+	}
+
+	lookupCtx := ctx
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		lookupCtx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+	addrs, err := r.resolver.LookupIPAddr(lookupCtx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, addr := range addrs {
+		conn, err := baseDialer.DialContext(ctx, network, net.JoinHostPort(addr.IP.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return nil, lastErr
+}
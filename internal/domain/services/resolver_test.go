@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveNXDOMAIN answers every DNS query on a local UDP port with NXDOMAIN
+// and returns the server address.
+func serveNXDOMAIN(t *testing.T) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			// echo the header and question only: QR, RD, RA and rcode 3
+			end := 12
+			for end < n && buf[end] != 0 {
+				end += int(buf[end]) + 1
+			}
+			end += 5
+			if end > n {
+				continue
+			}
+			resp := append([]byte(nil), buf[:end]...)
+			resp[2], resp[3] = 0x81, 0x83
+			resp[6], resp[7], resp[8], resp[9], resp[10], resp[11] = 0, 0, 0, 0, 0, 0
+			_, _ = conn.WriteTo(resp, addr)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestValidateDNSServer(t *testing.T) {
+	assert.NoError(t, ValidateDNSServer(""))
+	assert.NoError(t, ValidateDNSServer("127.0.0.1:53"))
+	assert.Error(t, ValidateDNSServer("not-an-address"))
+}
+
+func TestCustomResolverNXDOMAIN(t *testing.T) {
+	config := getTestConfig()
+	config.DNSServer = serveNXDOMAIN(t)
+	config.DNSTimeout = time.Second
+
+	service := NewAnalyzerService(NewHTTPClient(NewSharedHTTPClient(5*time.Second, config.Transport())), NewHTMLParser(nil), config)
+	_, err := service.AnalyzeURL(context.Background(), "http://missing.example.test/")
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrTargetUnreachable)
+	assert.Contains(t, err.Error(), "domain not found")
+}
+
+func TestCustomResolverTimeout(t *testing.T) {
+	// a bound socket that never answers
+	silent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer silent.Close()
+	resolver := newHostResolver(silent.LocalAddr().String(), 50*time.Millisecond)
+
+	start := time.Now()
+	_, err = resolver.dialContext(context.Background(), "tcp", "slow.example.test:80")
+
+	require.Error(t, err)
+	var dnsErr *net.DNSError
+	assert.True(t, errors.As(err, &dnsErr))
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestCustomResolverLeavesIPAddressesAlone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewSharedHTTPClient(5*time.Second, TransportConfig{DNSServer: serveNXDOMAIN(t), DNSTimeout: time.Second})
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestTransportsFollowTheirOwnDNSConfig(t *testing.T) {
+	custom := TransportConfig{DNSServer: serveNXDOMAIN(t), DNSTimeout: time.Second}
+
+	assert.Same(t, SharedTransport(custom), SharedTransport(custom))
+	assert.NotSame(t, SharedTransport(TransportConfig{}), SharedTransport(custom))
+
+	_, err := SharedTransport(custom).DialContext(context.Background(), "tcp", "missing.example.test:80")
+	var dnsErr *net.DNSError
+	require.True(t, errors.As(err, &dnsErr))
+	assert.True(t, dnsErr.IsNotFound)
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"webpage-analyzer/internal/domain/entities"
+)
+
+// securityHeaderRules lists the graded headers in report order, each with
+// the rule a present value must satisfy to pass.
+var securityHeaderRules = []struct {
+	header string
+	passes func(value string) bool
+}{
+	{HeaderStrictTransportSecurity, hstsPasses},
+	{HeaderContentSecurityPolicy, func(value string) bool { return value != "" }},
+	{HeaderXFrameOptions, func(value string) bool {
+		return strings.EqualFold(value, "DENY") || strings.EqualFold(value, "SAMEORIGIN")
+	}},
+	{HeaderXContentTypeOptions, func(value string) bool { return strings.EqualFold(value, "nosniff") }},
+	{HeaderReferrerPolicy, referrerPolicyPasses},
+}
+
+// gradeSecurityHeaders checks the page response for each recommended
+// security header.
+func gradeSecurityHeaders(header http.Header) *entities.SecurityHeaders {
+	report := &entities.SecurityHeaders{Checks: make([]entities.SecurityHeaderCheck, 0, len(securityHeaderRules))}
+	for _, rule := range securityHeaderRules {
+		values := header.Values(rule.header)
+		check := entities.SecurityHeaderCheck{Header: rule.header, Present: len(values) > 0}
+		if check.Present {
+			check.Value, _ = truncateText(strings.TrimSpace(strings.Join(values, ", ")), entities.MaxMetadataValueLength)
+			check.Pass = rule.passes(check.Value)
+		}
+		if check.Pass {
+			report.Passed++
+		}
+		report.Checks = append(report.Checks, check)
+	}
+	return report
+}
+
+// hstsPasses requires a positive max-age; max-age=0 tells browsers to
+// forget the policy.
+func hstsPasses(value string) bool {
+	for _, directive := range strings.Split(value, ";") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		if !strings.EqualFold(name, "max-age") {
+			continue
+		}
+		maxAge, err := strconv.Atoi(strings.Trim(strings.TrimSpace(arg), `"`))
+		return err == nil && maxAge > 0
+	}
+	return false
+}
+
+// referrerPolicyPasses rejects unsafe-url, which leaks full URLs to every
+// origin. Browsers use the last policy they recognize.
+func referrerPolicyPasses(value string) bool {
+	policies := strings.Split(value, ",")
+	last := strings.ToLower(strings.TrimSpace(policies[len(policies)-1]))
+	return last != "" && last != "unsafe-url"
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"webpage-analyzer/internal/domain/entities"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func analyzeWithHeaders(t *testing.T, headers map[string]string) *entities.SecurityHeaders {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for name, value := range headers {
+			w.Header().Set(name, value)
+		}
+		_, _ = w.Write([]byte("<html><head><title>Secure</title></head><body><h1>Hi</h1></body></html>"))
+	}))
+	defer server.Close()
+
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	service := NewAnalyzerService(httpClient, NewHTMLParser(httpClient), getTestConfig())
+	result, err := service.AnalyzeURL(context.Background(), server.URL)
+	require.NoError(t, err)
+	require.NotNil(t, result.SecurityHeaders)
+	return result.SecurityHeaders
+}
+
+func TestSecurityHeadersAllPresent(t *testing.T) {
+	report := analyzeWithHeaders(t, map[string]string{
+		HeaderStrictTransportSecurity: "max-age=31536000; includeSubDomains",
+		HeaderContentSecurityPolicy:   "default-src 'self'",
+		HeaderXFrameOptions:           "DENY",
+		HeaderXContentTypeOptions:     "nosniff",
+		HeaderReferrerPolicy:          "strict-origin-when-cross-origin",
+	})
+
+	assert.Equal(t, 5, report.Passed)
+	require.Len(t, report.Checks, 5)
+	for _, check := range report.Checks {
+		assert.True(t, check.Present, check.Header)
+		assert.True(t, check.Pass, check.Header)
+		assert.NotEmpty(t, check.Value, check.Header)
+	}
+	assert.Equal(t, HeaderStrictTransportSecurity, report.Checks[0].Header)
+}
+
+func TestSecurityHeadersMissing(t *testing.T) {
+	report := analyzeWithHeaders(t, nil)
+
+	assert.Equal(t, 0, report.Passed)
+	require.Len(t, report.Checks, 5)
+	for _, check := range report.Checks {
+		assert.False(t, check.Present, check.Header)
+		assert.False(t, check.Pass, check.Header)
+		assert.Empty(t, check.Value, check.Header)
+	}
+}
+
+func TestGradeSecurityHeadersWeakValues(t *testing.T) {
+	header := http.Header{}
+	header.Set(HeaderStrictTransportSecurity, "max-age=0")
+	header.Set(HeaderContentSecurityPolicy, "")
+	header.Set(HeaderXFrameOptions, "ALLOW-FROM https://example.com")
+	header.Set(HeaderXContentTypeOptions, "sniff")
+	header.Set(HeaderReferrerPolicy, "no-referrer, unsafe-url")
+
+	report := gradeSecurityHeaders(header)
+
+	assert.Equal(t, 0, report.Passed)
+	for _, check := range report.Checks {
+		assert.True(t, check.Present, check.Header)
+		assert.False(t, check.Pass, check.Header)
+	}
+}
+
+func TestHSTSPasses(t *testing.T) {
+	assert.True(t, hstsPasses("max-age=63072000"))
+	assert.True(t, hstsPasses(`includeSubDomains; MAX-AGE="600"; preload`))
+	assert.False(t, hstsPasses("max-age=0"))
+	assert.False(t, hstsPasses("includeSubDomains"))
+	assert.False(t, hstsPasses("max-age=abc"))
+}
+
+func TestSecurityHeadersOmittedForDataURLs(t *testing.T) {
+	config := getTestConfig()
+	config.AllowDataURLs = true
+	httpClient := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	service := NewAnalyzerService(httpClient, NewHTMLParser(httpClient), config)
+
+	result, err := service.AnalyzeURL(context.Background(), "data:text/html,<title>Hi</title>")
+	require.NoError(t, err)
+	assert.Nil(t, result.SecurityHeaders)
+}
@@ -0,0 +1,380 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"webpage-analyzer/internal/domain/entities"
+
+	"golang.org/x/net/html"
+)
+
+// streamingParser extracts the same fields as htmlParser in a single pass
+// over html.Tokenizer tokens, so only the extracted data is held in memory
+// rather than the whole DOM. It shares the tree parser's settings and link
+// checks. Because there is no tree builder, markup that relies on HTML error
+// recovery (headings or forms closed implicitly by a parent's end tag) can
+// be attributed slightly differently, and MaxHTMLDepth does not apply.
+type streamingParser struct {
+	*htmlParser
+}
+
+func NewStreamingHTMLParser(httpClient HTTPClient) HTMLParser {
+	return &streamingParser{htmlParser: newHTMLParser(httpClient)}
+}
+
+func (p *streamingParser) Parse(content string, baseURL string) (*ParsedHTML, error) {
+	if content == "" {
+		return nil, fmt.Errorf("HTML content cannot be empty")
+	}
+
+	if len(content) > MaxContentSize {
+		return nil, fmt.Errorf("HTML content too large (max %d bytes)", MaxContentSize)
+	}
+
+	extractor := newTokenExtractor(p.maxHeadings)
+	tokenizer := html.NewTokenizer(strings.NewReader(content))
+	nodes := 0
+	markup := 0
+
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			if err := tokenizer.Err(); err != io.EOF {
+				return nil, fmt.Errorf("failed to parse HTML: %w", err)
+			}
+			break
+		}
+
+		// count the same way checkNodeCount does for the tree parser
+		switch tokenType {
+		case html.StartTagToken, html.SelfClosingTagToken, html.TextToken, html.CommentToken, html.DoctypeToken:
+			nodes++
+			if nodes > p.maxNodes {
+				return nil, &NodeLimitExceededError{Limit: p.maxNodes}
+			}
+			if tokenType != html.TextToken && tokenType != html.CommentToken {
+				markup++
+			}
+		}
+
+		extractor.observe(tokenizer.Token())
+	}
+	extractor.finish()
+
+	parsed := &ParsedHTML{
+		HTMLVersion:             extractor.htmlVersion(),
+		Headings:                extractor.headings,
+		HeadingOutline:          extractor.outline,
+		HeadingsTruncated:       extractor.headingsTruncated,
+		StructuredData:          extractor.structuredData,
+		InvalidJSONLDBlocks:     extractor.invalidJSONLD,
+		Links:                   make([]Link, 0, len(extractor.hrefs)),
+		HasLoginForm:            extractor.login.found(),
+		Forms:                   extractor.forms,
+		PasswordAutocomplete:    extractor.passwords.autocompletes(),
+		DeprecatedElements:      extractor.deprecated,
+		RenderBlockingResources: extractor.renderBlocking,
+		Trackers:                extractor.trackers.names,
+		Languages:               extractor.languages.tags,
+		ContentLength:           int64(len(content)),
+	}
+	parsed.Title, parsed.TitleTruncated = truncateText(extractor.title, p.maxTitleLength)
+	parsed.Description, parsed.DescriptionTruncated = truncateText(extractor.description, p.maxTitleLength)
+
+	// anchors can follow the links that target them, so links are built
+	// only once the whole document has been seen
+	for _, href := range extractor.hrefs {
+		parsed.Links = append(parsed.Links, p.buildLink(href, baseURL, extractor.anchorTargets))
+	}
+
+	parsed.LowConfidence = isLowConfidence(content, markup, parsed)
+
+	return parsed, nil
+}
+
+// tokenExtractor accumulates page data from a token stream.
+type tokenExtractor struct {
+	maxHeadings int
+
+	sawContent  bool
+	doctype     string
+	seenDoctype bool
+	html5       bool
+
+	title        string
+	titlePending bool
+	description  string
+
+	headings          map[string]int
+	outline           []entities.HeadingNode
+	headingsTruncated bool
+	openHeading       int
+	headingText       strings.Builder
+
+	anchorTargets map[string]bool
+	hrefs         []string
+
+	login        loginSignals
+	loginPending bool
+
+	forms            []entities.FormInfo
+	form             *entities.FormInfo
+	formAutocomplete string
+	passwords        passwordFields
+
+	deprecated     []string
+	deprecatedSeen map[string]bool
+
+	// headClosed is set once the tree builder would have left <head>;
+	// headNested tracks noscript and template elements, whose children
+	// are not direct children of <head>.
+	headClosed     bool
+	headNested     int
+	renderBlocking int
+
+	trackers      *trackerSet
+	inTrackerText bool
+	trackerText   strings.Builder
+
+	languages *languageSet
+
+	structuredData []string
+	structuredSeen map[string]bool
+	invalidJSONLD  int
+	inJSONLD       bool
+	jsonLD         strings.Builder
+}
+
+func newTokenExtractor(maxHeadings int) *tokenExtractor {
+	return &tokenExtractor{
+		maxHeadings:    maxHeadings,
+		headings:       make(map[string]int),
+		outline:        make([]entities.HeadingNode, 0),
+		openHeading:    -1,
+		anchorTargets:  make(map[string]bool),
+		hrefs:          make([]string, 0),
+		forms:          make([]entities.FormInfo, 0),
+		deprecated:     make([]string, 0),
+		deprecatedSeen: make(map[string]bool),
+		structuredData: make([]string, 0),
+		structuredSeen: make(map[string]bool),
+		trackers:       newTrackerSet(),
+		languages:      newLanguageSet(),
+	}
+}
+
+func (e *tokenExtractor) observe(token html.Token) {
+	// title and login text only count when they are an element's first child
+	titlePending, loginPending := e.titlePending, e.loginPending
+	e.titlePending, e.loginPending = false, false
+
+	switch token.Type {
+	case html.DoctypeToken:
+		// the tree builder ignores a doctype once content has started
+		if !e.sawContent && !e.seenDoctype {
+			e.seenDoctype = true
+			if fields := strings.Fields(token.Data); len(fields) > 0 {
+				e.doctype = fields[0]
+			}
+		}
+	case html.StartTagToken, html.SelfClosingTagToken:
+		e.sawContent = true
+		e.startTag(token.Data, token.Attr)
+	case html.EndTagToken:
+		e.endTag(token.Data)
+	case html.TextToken:
+		if strings.TrimSpace(token.Data) != "" {
+			e.sawContent = true
+		}
+		if loginPending {
+			e.login.observeText(token.Data)
+		}
+		if titlePending && e.title == "" {
+			e.title = strings.TrimSpace(token.Data)
+		}
+		if e.openHeading >= 0 {
+			e.headingText.WriteString(token.Data)
+		}
+		if e.inJSONLD {
+			e.jsonLD.WriteString(token.Data)
+		}
+		if e.inTrackerText {
+			e.trackerText.WriteString(token.Data)
+		}
+	}
+}
+
+func (e *tokenExtractor) startTag(tag string, attrs []html.Attribute) {
+	if !e.html5 && isHTML5Element(tag, attrs) {
+		e.html5 = true
+	}
+	addAnchorTargets(e.anchorTargets, tag, attrs)
+	if DeprecatedElements[tag] && !e.deprecatedSeen[tag] {
+		e.deprecatedSeen[tag] = true
+		e.deprecated = append(e.deprecated, tag)
+	}
+	e.login.observeElement(tag, attrs)
+	e.loginPending = contains(AccessibilityElements, tag)
+	e.observeHeadElement(tag, attrs)
+	e.trackers.observeElement(tag, attrs)
+	// the tree parser keeps noscript content as text, so tags inside it
+	// are not elements there
+	if !e.inTrackerText {
+		e.languages.observeElement(attrs)
+	}
+	if holdsTrackerText(tag) {
+		e.inTrackerText = true
+		e.trackerText.Reset()
+	}
+
+	switch tag {
+	case HTMLElementTitle:
+		e.titlePending = e.title == ""
+	case HTMLElementMeta:
+		if e.description == "" {
+			e.description = metaDescription(attrs)
+		}
+	case HTMLElementH1, HTMLElementH2, HTMLElementH3, HTMLElementH4, HTMLElementH5, HTMLElementH6:
+		// a new heading implicitly closes an open one
+		e.closeHeading()
+		if e.headingsTruncated {
+			return
+		}
+		if len(e.outline) >= e.maxHeadings {
+			e.headingsTruncated = true
+			return
+		}
+		e.headings[tag]++
+		e.outline = append(e.outline, entities.HeadingNode{Level: int(tag[1] - '0')})
+		e.openHeading = len(e.outline) - 1
+	case HTMLElementA:
+		if href := linkHref(attrs); href != "" {
+			e.hrefs = append(e.hrefs, href)
+		}
+	case HTMLElementForm:
+		// nested forms are ignored, as the tree builder does
+		if e.form == nil {
+			info, autocomplete := newFormInfo(attrs)
+			e.form, e.formAutocomplete = &info, autocomplete
+			e.passwords.addForm(attrs)
+		}
+	case HTMLElementInput, HTMLElementSelect, HTMLElementTextarea:
+		if tag == HTMLElementInput {
+			enclosing := ""
+			if e.form != nil {
+				enclosing = e.formAutocomplete
+			}
+			e.passwords.addInput(attrs, enclosing)
+		}
+		if e.form != nil {
+			e.form.Inputs = append(e.form.Inputs, formInputFromAttrs(tag, attrs))
+		}
+	case HTMLElementScript:
+		if isJSONLDScriptAttrs(attrs) {
+			e.inJSONLD = true
+			e.jsonLD.Reset()
+		}
+	}
+}
+
+func (e *tokenExtractor) endTag(tag string) {
+	switch tag {
+	case HTMLElementH1, HTMLElementH2, HTMLElementH3, HTMLElementH4, HTMLElementH5, HTMLElementH6:
+		e.closeHeading()
+	case HTMLElementForm:
+		e.closeForm()
+	case HTMLElementScript:
+		e.closeJSONLD()
+		e.closeTrackerText()
+	case "head":
+		e.headClosed = true
+	case HTMLElementNoscript, HTMLElementTemplate:
+		if tag == HTMLElementNoscript {
+			e.closeTrackerText()
+		}
+		if e.headNested > 0 {
+			e.headNested--
+		}
+	}
+}
+
+func (e *tokenExtractor) observeHeadElement(tag string, attrs []html.Attribute) {
+	if e.headClosed || tag == "html" || tag == "head" {
+		return
+	}
+	if !headElements[tag] {
+		e.headClosed = true
+		return
+	}
+	if tag == HTMLElementNoscript || tag == HTMLElementTemplate {
+		e.headNested++
+		return
+	}
+	if e.headNested == 0 && isRenderBlocking(tag, attrs) {
+		e.renderBlocking++
+	}
+}
+
+// finish closes whatever the document left open.
+func (e *tokenExtractor) finish() {
+	e.closeHeading()
+	e.closeForm()
+	e.closeJSONLD()
+	e.closeTrackerText()
+}
+
+func (e *tokenExtractor) closeTrackerText() {
+	if !e.inTrackerText {
+		return
+	}
+	e.inTrackerText = false
+	e.trackers.observe(e.trackerText.String())
+}
+
+func (e *tokenExtractor) closeHeading() {
+	if e.openHeading < 0 {
+		return
+	}
+	e.outline[e.openHeading].Text = strings.Join(strings.Fields(e.headingText.String()), " ")
+	e.openHeading = -1
+	e.headingText.Reset()
+}
+
+func (e *tokenExtractor) closeForm() {
+	if e.form == nil {
+		return
+	}
+	inheritAutocomplete(e.form, e.formAutocomplete)
+	e.forms = append(e.forms, *e.form)
+	e.form = nil
+}
+
+func (e *tokenExtractor) closeJSONLD() {
+	if !e.inJSONLD {
+		return
+	}
+	e.inJSONLD = false
+
+	types, ok := jsonLDBlockTypes(e.jsonLD.String())
+	if !ok {
+		e.invalidJSONLD++
+		return
+	}
+	for _, t := range types {
+		if !e.structuredSeen[t] {
+			e.structuredSeen[t] = true
+			e.structuredData = append(e.structuredData, t)
+		}
+	}
+}
+
+func (e *tokenExtractor) htmlVersion() string {
+	if version := doctypeVersion(e.doctype); e.seenDoctype && version != "" {
+		return version
+	}
+	if e.html5 {
+		return "HTML5"
+	}
+	return "Unknown/No DOCTYPE"
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var parserEquivalencePages = map[string]string{
+	"full page": `<!DOCTYPE html>
+<html><head>
+	<title> Shop &amp; More </title>
+	<meta name="viewport" content="width=device-width">
+	<meta name="Description" content=" Lamps and more ">
+	<link rel="stylesheet" href="/site.css">
+	<script src="/vendor.js"></script>
+	<script src="/app.js" defer></script>
+	<script async src="https://www.google-analytics.com/analytics.js"></script>
+	<script type="application/ld+json">{"@type": "Product", "offers": {"@type": "Offer"}}</script>
+	<script type="application/ld+json">{not json}</script>
+</head><body>
+	<header><h1>Welcome <span>to the</span> shop</h1></header>
+	<center><font>legacy</font></center>
+	<h2 id="deals">Deals</h2><h3>Today</h3>
+	<a href="/ok">relative</a>
+	<a href="{{server}}/missing">absolute</a>
+	<a href="#deals">anchor</a>
+	<a href="#nowhere">broken anchor</a>
+	<a name="top-of-list" href="mailto:shop@example.com">mail</a>
+	<a href="">empty</a>
+	<form action="/login" method="POST" autocomplete="off">
+		<label>Sign in</label>
+		<input type="text" name="username">
+		<input type="password" name="password" autocomplete="current-password">
+		<select name="region"></select>
+		<textarea name="note"></textarea>
+	</form>
+	<form><input type="search" name="q"></form>
+	<input name="outside">
+	<noscript><iframe src="https://www.googletagmanager.com/ns.html?id=GTM-TEST"></iframe></noscript>
+</body></html>`,
+	"no doctype with html5 elements": `<html><body><nav><h2>Menu</h2></nav><p>Text</p></body></html>`,
+	"no doctype legacy":              `<html><body><h1>Old</h1><p>Text</p></body></html>`,
+	"plain text":                     `just some text without any markup`,
+	"empty title falls through":      `<title></title><svg><title>Icon</title></svg><h4>A</h4><h4>B</h4>`,
+	"unclosed trailing form":         `<!DOCTYPE html><form method="get"><input type="email" name="e">`,
+	"password fields outside forms":  `<!DOCTYPE html><input type="password"><input type="password" form="login"><form id="login" autocomplete="off"></form>`,
+	"mixed languages":                `<!DOCTYPE html><html lang="en"><body><p lang="fr">Bonjour</p><noscript><p lang="de">Hallo</p></noscript></body></html>`,
+}
+
+func TestStreamingParserMatchesTreeParser(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+
+	for name, page := range parserEquivalencePages {
+		t.Run(name, func(t *testing.T) {
+			page = strings.ReplaceAll(page, "{{server}}", server.URL)
+
+			tree, treeErr := NewHTMLParser(client).Parse(page, server.URL)
+			streaming, streamingErr := NewStreamingHTMLParser(client).Parse(page, server.URL)
+
+			require.NoError(t, treeErr)
+			require.NoError(t, streamingErr)
+			assert.Equal(t, tree, streaming)
+		})
+	}
+}
+
+func TestStreamingParserHonoursLimits(t *testing.T) {
+	page := `<h1>One</h1><h2>Two</h2><h3>Three</h3><title>` + strings.Repeat("x", 20) + `</title>`
+
+	for _, parser := range []HTMLParser{NewHTMLParser(nil), NewStreamingHTMLParser(nil)} {
+		parser.SetMaxHeadings(2)
+		parser.SetMaxTitleLength(10)
+
+		parsed, err := parser.Parse(page, "https://example.com")
+		assert.NoError(t, err)
+		assert.Len(t, parsed.HeadingOutline, 2)
+		assert.True(t, parsed.HeadingsTruncated)
+		assert.Equal(t, strings.Repeat("x", 10), parsed.Title)
+		assert.True(t, parsed.TitleTruncated)
+
+		parser.SetMaxNodes(3)
+		_, err = parser.Parse(page, "https://example.com")
+		var limitErr *NodeLimitExceededError
+		assert.ErrorAs(t, err, &limitErr)
+	}
+}
+
+func TestNewHTMLParserOfKind(t *testing.T) {
+	parser, err := NewHTMLParserOfKind("", nil)
+	assert.NoError(t, err)
+	assert.IsType(t, &htmlParser{}, parser)
+
+	parser, err = NewHTMLParserOfKind("Streaming", nil)
+	assert.NoError(t, err)
+	assert.IsType(t, &streamingParser{}, parser)
+
+	_, err = NewHTMLParserOfKind("sax", nil)
+	assert.Error(t, err)
+}
+
+// largeDocument builds a page of sections with headings, text, same-page
+// links and forms, which the parsers can process without network access.
+func largeDocument(sections int) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html><html><head><title>Large</title></head><body>")
+	for i := 0; i < sections; i++ {
+		fmt.Fprintf(&b, `<section id="s%d"><h2>Section <em>%d</em></h2>`, i, i)
+		b.WriteString(strings.Repeat("<p>Lorem ipsum dolor sit amet, consectetur adipiscing elit.</p>", 5))
+		fmt.Fprintf(&b, `<a href="#s%d">next</a><form><input name="q%d"></form></section>`, i+1, i)
+	}
+	b.WriteString("</body></html>")
+	return b.String()
+}
+
+func BenchmarkParseLargeDocument(b *testing.B) {
+	page := largeDocument(5000)
+
+	parsers := map[string]HTMLParser{
+		HTMLParserTree:      NewHTMLParser(nil),
+		HTMLParserStreaming: NewStreamingHTMLParser(nil),
+	}
+	for name, parser := range parsers {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(page)))
+			for i := 0; i < b.N; i++ {
+				if _, err := parser.Parse(page, "https://example.com"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// extractStructuredData lists the distinct schema.org @type values declared
+// in JSON-LD blocks, in document order, and counts the blocks that are not
+// valid JSON so they can be reported instead of failing the analysis.
+func (p *htmlParser) extractStructuredData(doc *html.Node) ([]string, int) {
+	types := make([]string, 0)
+	seen := make(map[string]bool)
+	invalid := 0
+
+	var traverse func(*html.Node, int)
+	traverse = func(n *html.Node, depth int) {
+		if depth > MaxHTMLDepth {
+			return
+		}
+		if n.Type == html.ElementNode && n.Data == HTMLElementScript && isJSONLDScript(n) {
+			blockTypes, ok := jsonLDBlockTypes(scriptText(n))
+			if !ok {
+				invalid++
+				return
+			}
+			for _, t := range blockTypes {
+				if !seen[t] {
+					seen[t] = true
+					types = append(types, t)
+				}
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c, depth+1)
+		}
+	}
+	traverse(doc, 0)
+	return types, invalid
+}
+
+func isJSONLDScript(n *html.Node) bool {
+	return isJSONLDScriptAttrs(n.Attr)
+}
+
+func isJSONLDScriptAttrs(attrs []html.Attribute) bool {
+	for _, attr := range attrs {
+		if attr.Key == HTMLAttrType {
+			mediaType, _, _ := strings.Cut(attr.Val, ";")
+			return strings.EqualFold(strings.TrimSpace(mediaType), ScriptTypeJSONLD)
+		}
+	}
+	return false
+}
+
+// scriptText returns the raw script body; the HTML parser keeps it as a
+// single text child.
+func scriptText(n *html.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode {
+			b.WriteString(c.Data)
+		}
+	}
+	return b.String()
+}
+
+// jsonLDBlockTypes decodes one JSON-LD script body and returns its types,
+// or false if the body is not valid JSON.
+func jsonLDBlockTypes(text string) ([]string, bool) {
+	var block interface{}
+	if err := json.Unmarshal([]byte(text), &block); err != nil {
+		return nil, false
+	}
+	return jsonLDTypes(block), true
+}
+
+// jsonLDTypes walks a decoded JSON-LD value and collects every @type,
+// including those of nested entities and @graph members. An entity's own
+// type comes first, then nested ones by property name, so the order is
+// stable across runs.
+func jsonLDTypes(value interface{}) []string {
+	types := make([]string, 0)
+	var walk func(interface{}, int)
+	walk = func(v interface{}, depth int) {
+		if depth > MaxHTMLDepth {
+			return
+		}
+		switch v := v.(type) {
+		case []interface{}:
+			for _, item := range v {
+				walk(item, depth+1)
+			}
+		case map[string]interface{}:
+			switch t := v["@type"].(type) {
+			case string:
+				types = append(types, t)
+			case []interface{}:
+				for _, item := range t {
+					if s, ok := item.(string); ok {
+						types = append(types, s)
+					}
+				}
+			}
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				if key != "@type" {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				walk(v[key], depth+1)
+			}
+		}
+	}
+	walk(value, 0)
+	return types
+}
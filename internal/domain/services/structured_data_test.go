@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseExtractsStructuredData(t *testing.T) {
+	parser := NewHTMLParser(nil)
+
+	parsed, err := parser.Parse(`<html><head><title>Shop</title>
+		<script type="application/ld+json">
+			{"@context": "https://schema.org", "@type": "Product", "name": "Lamp",
+			 "offers": {"@type": "Offer", "price": "10.00"}}
+		</script>
+		<script type="application/ld+json; charset=utf-8">
+			{"@context": "https://schema.org", "@graph": [
+				{"@type": "Organization", "name": "Acme"},
+				{"@type": ["WebSite", "Product"]}
+			]}
+		</script>
+		<script type="text/javascript">var x = {"@type": "Ignored"};</script>
+	</head><body><h1>Shop</h1></body></html>`, "https://example.com")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"Product", "Offer", "Organization", "WebSite"}, parsed.StructuredData)
+	assert.Equal(t, 0, parsed.InvalidJSONLDBlocks)
+}
+
+func TestParseSkipsInvalidStructuredData(t *testing.T) {
+	parser := NewHTMLParser(nil)
+
+	parsed, err := parser.Parse(`<html><head><title>Broken</title>
+		<script type="application/ld+json">{"@type": "Article", "headline": </script>
+		<script type="application/ld+json">{"@type": "BreadcrumbList"}</script>
+	</head><body><h1>Broken</h1></body></html>`, "https://example.com")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"BreadcrumbList"}, parsed.StructuredData)
+	assert.Equal(t, 1, parsed.InvalidJSONLDBlocks)
+}
+
+func TestAnalyzeURLWarnsOnInvalidStructuredData(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><head><title>Broken</title>
+			<script type="application/ld+json">not json</script>
+		</head><body><h1>Broken</h1></body></html>`))
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(NewSharedHTTPClient(DefaultRequestTimeout, TransportConfig{}))
+	service := NewAnalyzerService(client, NewHTMLParser(client), getTestConfig())
+
+	result, err := service.AnalyzeURL(context.Background(), server.URL)
+	assert.NoError(t, err)
+	assert.Empty(t, result.StructuredData)
+	assert.Contains(t, result.Warnings, WarningInvalidStructuredData)
+}
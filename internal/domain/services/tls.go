@@ -0,0 +1,40 @@
+package services
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ValidateMinTLSVersion reports an error unless version is empty or "1.2"
+// or "1.3". Call it at startup, since transports built with any other value
+// fall back to DefaultMinTLSVersion.
+func ValidateMinTLSVersion(version string) error {
+	version = strings.TrimSpace(version)
+	if version == "" {
+		return nil
+	}
+	if _, ok := tlsVersions[version]; !ok {
+		return fmt.Errorf("unsupported minimum TLS version %q (use 1.2 or 1.3)", version)
+	}
+	return nil
+}
+
+// minTLSVersionName returns the supported version name for version, or
+// DefaultMinTLSVersion when it is empty or unsupported.
+func minTLSVersionName(version string) string {
+	version = strings.TrimSpace(version)
+	if _, ok := tlsVersions[version]; ok {
+		return version
+	}
+	return DefaultMinTLSVersion
+}
+
+func newTLSClientConfig(minVersion string) *tls.Config {
+	return &tls.Config{MinVersion: tlsVersions[minTLSVersionName(minVersion)]}
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateMinTLSVersion(t *testing.T) {
+	assert.NoError(t, ValidateMinTLSVersion(""))
+	assert.NoError(t, ValidateMinTLSVersion("1.2"))
+	assert.NoError(t, ValidateMinTLSVersion("1.3"))
+	assert.Error(t, ValidateMinTLSVersion("1.1"))
+}
+
+func TestTransportMinTLSVersion(t *testing.T) {
+	assert.Equal(t, uint16(tls.VersionTLS12), newTransport(TransportConfig{}).TLSClientConfig.MinVersion)
+	assert.Equal(t, uint16(tls.VersionTLS13), newTransport(TransportConfig{MinTLSVersion: "1.3"}).TLSClientConfig.MinVersion)
+
+	// each shared transport keeps the version it was asked for, however
+	// many were built before it
+	strict := SharedTransport(TransportConfig{MinTLSVersion: "1.3"})
+	assert.Equal(t, uint16(tls.VersionTLS13), strict.TLSClientConfig.MinVersion)
+	assert.Equal(t, uint16(tls.VersionTLS12), SharedTransport(TransportConfig{}).TLSClientConfig.MinVersion)
+	assert.Same(t, SharedTransport(TransportConfig{}), SharedTransport(TransportConfig{MinTLSVersion: DefaultMinTLSVersion}))
+}
+
+func TestMinTLSVersionAgainstOlderTarget(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html><head><title>Legacy</title></head><body></body></html>"))
+	}))
+	server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+	rootCAs := server.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+
+	analyze := func(minVersion string) error {
+		transport := newTransport(TransportConfig{MinTLSVersion: minVersion})
+		transport.TLSClientConfig.RootCAs = rootCAs
+		defer transport.CloseIdleConnections()
+
+		httpClient := NewHTTPClient(&http.Client{Timeout: 5 * time.Second, Transport: transport})
+		config := getTestConfig()
+		config.MinTLSVersion = minVersion
+		service := NewAnalyzerService(httpClient, NewHTMLParser(httpClient), config)
+		_, err := service.AnalyzeURL(context.Background(), server.URL)
+		return err
+	}
+
+	t.Run("default accepts TLS 1.2", func(t *testing.T) {
+		assert.NoError(t, analyze(""))
+	})
+
+	t.Run("1.3 minimum rejects TLS 1.2", func(t *testing.T) {
+		err := analyze("1.3")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrTargetUnreachable)
+		assert.Contains(t, err.Error(), "does not support TLS 1.3 or newer")
+	})
+}
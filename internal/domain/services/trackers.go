@@ -0,0 +1,87 @@
+package services
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Tracker is an analytics or tracking service and the URL fragments that
+// give it away.
+type Tracker struct {
+	Name     string
+	Patterns []string
+}
+
+// trackerSet collects distinct tracker names in order of first detection.
+type trackerSet struct {
+	names []string
+	seen  map[string]bool
+}
+
+func newTrackerSet() *trackerSet {
+	return &trackerSet{names: make([]string, 0), seen: make(map[string]bool)}
+}
+
+// observe records every tracker whose patterns occur in value.
+func (s *trackerSet) observe(value string) {
+	if value == "" {
+		return
+	}
+	value = strings.ToLower(value)
+	for _, tracker := range Trackers {
+		if s.seen[tracker.Name] {
+			continue
+		}
+		for _, pattern := range tracker.Patterns {
+			if strings.Contains(value, pattern) {
+				s.seen[tracker.Name] = true
+				s.names = append(s.names, tracker.Name)
+				break
+			}
+		}
+	}
+}
+
+// observeElement checks the src of elements that load trackers.
+func (s *trackerSet) observeElement(tag string, attrs []html.Attribute) {
+	switch tag {
+	case HTMLElementScript, HTMLElementImg, HTMLElementIframe:
+		for _, attr := range attrs {
+			if attr.Key == HTMLAttrSrc {
+				s.observe(attr.Val)
+			}
+		}
+	}
+}
+
+// holdsTrackerText reports whether an element's text can embed a tracker:
+// inline loader scripts, and noscript pixel fallbacks, which the parser
+// keeps as raw text.
+func holdsTrackerText(tag string) bool {
+	return tag == HTMLElementScript || tag == HTMLElementNoscript
+}
+
+// extractTrackers lists the tracking services a page loads, in document
+// order.
+func (p *htmlParser) extractTrackers(doc *html.Node) []string {
+	trackers := newTrackerSet()
+
+	var traverse func(*html.Node, int)
+	traverse = func(n *html.Node, depth int) {
+		if depth > MaxHTMLDepth {
+			return
+		}
+		if n.Type == html.ElementNode {
+			trackers.observeElement(n.Data, n.Attr)
+			if holdsTrackerText(n.Data) {
+				trackers.observe(scriptText(n))
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c, depth+1)
+		}
+	}
+	traverse(doc, 0)
+	return trackers.names
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const trackedPage = `<!DOCTYPE html>
+<html><head><title>Shop</title>
+	<script async src="https://www.googletagmanager.com/gtag/js?id=G-TEST123"></script>
+	<script>
+		window.dataLayer = window.dataLayer || [];
+		function gtag(){dataLayer.push(arguments);}
+		gtag('config', 'G-TEST123');
+	</script>
+	<script>
+		!function(f,b,e,v,n,t,s){t=b.createElement(e);t.async=!0;
+		t.src=v;s=b.getElementsByTagName(e)[0];s.parentNode.insertBefore(t,s)}
+		(window,document,'script','https://connect.facebook.net/en_US/fbevents.js');
+		fbq('init', '1234567890');
+	</script>
+</head><body>
+	<noscript><img height="1" width="1" style="display:none"
+		src="https://www.facebook.com/tr?id=1234567890&ev=PageView&noscript=1"/></noscript>
+	<h1>Shop</h1>
+	<script src="/static/app.js"></script>
+</body></html>`
+
+func TestParseDetectsTrackers(t *testing.T) {
+	for _, parser := range []HTMLParser{NewHTMLParser(nil), NewStreamingHTMLParser(nil)} {
+		parsed, err := parser.Parse(trackedPage, "https://example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Google Analytics", "Facebook Pixel"}, parsed.Trackers)
+	}
+}
+
+func TestParseDetectsPixelImagesAndIgnoresMentions(t *testing.T) {
+	page := `<html><body>
+		<img src="HTTPS://WWW.FACEBOOK.COM/tr?id=1&ev=PageView">
+		<p>We do not use google-analytics.com/analytics.js on this page.</p>
+		<a href="https://static.hotjar.com/c/hotjar.js">not loaded</a>
+	</body></html>`
+
+	for _, parser := range []HTMLParser{NewHTMLParser(nil), NewStreamingHTMLParser(nil)} {
+		parsed, err := parser.Parse(page, "https://example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Facebook Pixel"}, parsed.Trackers)
+	}
+}
+
+func TestParseWithoutTrackers(t *testing.T) {
+	parsed, err := NewHTMLParser(nil).Parse(`<html><head><script src="/app.js"></script></head><body><h1>Hi</h1></body></html>`, "https://example.com")
+	assert.NoError(t, err)
+	assert.Empty(t, parsed.Trackers)
+}
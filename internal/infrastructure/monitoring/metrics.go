@@ -49,6 +49,39 @@ var (
 		[]string{"cache_type"},
 	)
 
+	DatabaseQueryDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "database_query_duration_seconds",
+			Help:    "Database query duration in seconds",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"query"},
+	)
+
+	AnalysesDeletedTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "analyses_deleted_total",
+			Help: "Total number of analyses removed by retention cleanup",
+		},
+	)
+
+	// AnalyzedPageBytes buckets run from 1KB to 16MB, past the 10MB fetch cap.
+	AnalyzedPageBytes = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "analyzed_page_bytes",
+			Help:    "Content length in bytes of pages fetched for analysis",
+			Buckets: prometheus.ExponentialBuckets(1024, 4, 8),
+		},
+	)
+
+	ValidationFailuresTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "validation_failures_total",
+			Help: "Total number of URLs rejected by validation",
+		},
+		[]string{"reason"},
+	)
+
 	DatabaseConnectionsActive = promauto.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "database_connections_active",
@@ -74,3 +107,18 @@ func RecordHTTPRequest(method, endpoint string, statusCode int, duration time.Du
 	HTTPRequestDuration.With(status).Observe(duration.Seconds())
 	HTTPRequestsTotal.With(status).Inc()
 }
+
+// RecordValidationFailure counts a URL rejected for reason.
+func RecordValidationFailure(reason string) {
+	ValidationFailuresTotal.WithLabelValues(reason).Inc()
+}
+
+// SetQueueLength reports how many requests are waiting in queue.
+func SetQueueLength(queue string, length int64) {
+	QueueLength.WithLabelValues(queue).Set(float64(length))
+}
+
+// RecordPageSize records the content length of an analyzed page.
+func RecordPageSize(bytes int64) {
+	AnalyzedPageBytes.Observe(float64(bytes))
+}
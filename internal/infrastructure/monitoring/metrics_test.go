@@ -4,6 +4,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -11,3 +13,19 @@ func TestRecordHTTPRequest(t *testing.T) {
 	RecordHTTPRequest("GET", "/test", 200, time.Millisecond*100)
 	assert.True(t, true)
 }
+
+func TestRecordPageSize(t *testing.T) {
+	assert.Equal(t, 1, testutil.CollectAndCount(AnalyzedPageBytes))
+
+	var metric dto.Metric
+	assert.NoError(t, AnalyzedPageBytes.Write(&metric))
+	startCount := metric.GetHistogram().GetSampleCount()
+	startSum := metric.GetHistogram().GetSampleSum()
+
+	RecordPageSize(2048)
+	RecordPageSize(5 * 1024 * 1024)
+
+	assert.NoError(t, AnalyzedPageBytes.Write(&metric))
+	assert.Equal(t, startCount+2, metric.GetHistogram().GetSampleCount())
+	assert.Equal(t, startSum+2048+5*1024*1024, metric.GetHistogram().GetSampleSum())
+}
@@ -5,23 +5,58 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"time"
 	"webpage-analyzer/internal/domain/entities"
 	"webpage-analyzer/internal/domain/repositories"
 	"webpage-analyzer/pkg/config"
 
 	"github.com/google/uuid"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
-type analysisRepository struct {
-	db *sql.DB
+const (
+	pingTimeout  = 5 * time.Second
+	pingAttempts = 3
+	pingBackoff  = 500 * time.Millisecond
+)
+
+// deleteBatchSize bounds how many rows a single retention DELETE touches so
+// cleanup never holds long locks on the analyses table.
+const deleteBatchSize = 1000
+
+// DefaultMaxListLimit is the hard ceiling on rows a single List call returns
+// when the config does not set one.
+const DefaultMaxListLimit = 1000
+
+const deleteOlderThanQuery = `
+	DELETE FROM analyses WHERE id IN (
+		SELECT id FROM analyses WHERE created_at < $1 LIMIT $2
+	)`
+
+// deleteOldestForUserQuery keeps a user's $2 newest analyses, ordered as
+// listings are, and deletes the rest that have finished.
+const deleteOldestForUserQuery = `
+	DELETE FROM analyses
+	WHERE status IN ('completed', 'failed', 'cancelled', 'expired') AND id IN (
+		SELECT id FROM analyses WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC OFFSET $2
+	)`
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 }
 
-func NewAnalysisRepository(cfg *config.DatabaseConfig) (repositories.AnalysisRepository, error) {
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name, cfg.SSLMode)
+type analysisRepository struct {
+	db           *sql.DB
+	maxListLimit int
+	writes       *writeLimiter
+}
 
-	db, err := sql.Open("postgres", dsn)
+// Open connects to the configured database and pings it with bounded
+// retries, so callers such as migrations never start on a database that is
+// unreachable. Each connection attempt is also capped by connect_timeout.
+func Open(cfg *config.DatabaseConfig) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dataSourceName(cfg))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -30,18 +65,72 @@ func NewAnalysisRepository(cfg *config.DatabaseConfig) (repositories.AnalysisRep
 	db.SetMaxIdleConns(cfg.MaxIdleConns)
 	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+	if err := pingWithRetry(context.Background(), db, pingAttempts, pingTimeout, pingBackoff); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to ping database at %s:%s: %w", cfg.Host, cfg.Port, err)
+	}
+	return db, nil
+}
+
+func dataSourceName(cfg *config.DatabaseConfig) string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s connect_timeout=%d",
+		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name, cfg.SSLMode, int(pingTimeout/time.Second))
+}
+
+// NewAnalysisRepository stores analyses in db, which callers share with the
+// other repositories and the readiness check so the process holds one pool.
+func NewAnalysisRepository(db *sql.DB, cfg *config.DatabaseConfig) repositories.AnalysisRepository {
+	maxListLimit := cfg.MaxListLimit
+	if maxListLimit <= 0 {
+		maxListLimit = DefaultMaxListLimit
+	}
+
+	return &analysisRepository{
+		db:           db,
+		maxListLimit: maxListLimit,
+		writes:       newWriteLimiter(cfg.MaxConcurrentWrites, cfg.WriteWaitTimeout),
+	}
+}
+
+// pingWithRetry pings db up to attempts times, bounding each ping by timeout
+// and doubling backoff between attempts, so startup fails fast instead of
+// hanging on an unreachable database.
+func pingWithRetry(ctx context.Context, db *sql.DB, attempts int, timeout, backoff time.Duration) error {
+	var err error
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		err = db.PingContext(pingCtx)
+		cancel()
+
+		if err == nil {
+			return nil
+		}
+
+		if attempt < attempts {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
 	}
 
-	return &analysisRepository{db: db}, nil
+	return fmt.Errorf("gave up after %d attempts: %w", attempts, err)
 }
 
 func (r *analysisRepository) Create(ctx context.Context, analysis *entities.Analysis) error {
+	return r.writes.do(ctx, func() error {
+		return r.create(ctx, analysis)
+	})
+}
+
+func (r *analysisRepository) create(ctx context.Context, analysis *entities.Analysis) error {
 	query := `
 		INSERT INTO analyses (id, url, status, result, error, created_at, updated_at, 
-			completed_at, retry_count, priority, user_id, correlation_id)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+			completed_at, retry_count, priority, user_id, correlation_id, metadata, version)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`
 
 	var resultJSON interface{}
 	if analysis.Result != nil {
@@ -54,7 +143,12 @@ func (r *analysisRepository) Create(ctx context.Context, analysis *entities.Anal
 		resultJSON = nil
 	}
 
-	_, err := r.db.ExecContext(ctx, query,
+	metadataJSON, err := marshalMetadata(analysis.Metadata)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query,
 		analysis.ID,
 		analysis.URL,
 		analysis.Status,
@@ -67,6 +161,8 @@ func (r *analysisRepository) Create(ctx context.Context, analysis *entities.Anal
 		analysis.Priority,
 		analysis.UserID,
 		analysis.CorrelationID,
+		metadataJSON,
+		analysis.Version,
 	)
 
 	if err != nil {
@@ -79,17 +175,72 @@ func (r *analysisRepository) Create(ctx context.Context, analysis *entities.Anal
 func (r *analysisRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.Analysis, error) {
 	query := `
 		SELECT id, url, status, result, error, created_at, updated_at, 
-			completed_at, retry_count, priority, user_id, correlation_id
+			completed_at, retry_count, priority, user_id, correlation_id, metadata, version
 		FROM analyses WHERE id = $1`
 
 	row := r.db.QueryRowContext(ctx, query, id)
 	return r.scanAnalysis(row)
 }
 
+func (r *analysisRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*entities.Analysis, error) {
+	if len(ids) == 0 {
+		return make([]*entities.Analysis, 0), nil
+	}
+
+	query := `
+		SELECT id, url, status, result, error, created_at, updated_at, 
+			completed_at, retry_count, priority, user_id, correlation_id, metadata, version
+		FROM analyses WHERE id = ANY($1::uuid[])`
+
+	idStrings := make([]string, len(ids))
+	for i, id := range ids {
+		idStrings[i] = id.String()
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(idStrings))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get analyses: %w", err)
+	}
+	defer rows.Close()
+
+	analyses := make([]*entities.Analysis, 0, len(ids))
+	for rows.Next() {
+		analysis, err := r.scanAnalysisFromRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		analyses = append(analyses, analysis)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get analyses: %w", err)
+	}
+
+	return orderByIDs(ids, analyses), nil
+}
+
+// orderByIDs arranges analyses in the order of ids, dropping IDs that were
+// not found.
+func orderByIDs(ids []uuid.UUID, analyses []*entities.Analysis) []*entities.Analysis {
+	byID := make(map[uuid.UUID]*entities.Analysis, len(analyses))
+	for _, analysis := range analyses {
+		byID[analysis.ID] = analysis
+	}
+
+	ordered := make([]*entities.Analysis, 0, len(analyses))
+	for _, id := range ids {
+		if analysis, ok := byID[id]; ok {
+			ordered = append(ordered, analysis)
+		}
+	}
+
+	return ordered
+}
+
 func (r *analysisRepository) GetByURL(ctx context.Context, url string) (*entities.Analysis, error) {
 	query := `
 		SELECT id, url, status, result, error, created_at, updated_at, 
-			completed_at, retry_count, priority, user_id, correlation_id
+			completed_at, retry_count, priority, user_id, correlation_id, metadata, version
 		FROM analyses WHERE url = $1 ORDER BY created_at DESC LIMIT 1`
 
 	row := r.db.QueryRowContext(ctx, query, url)
@@ -97,11 +248,19 @@ func (r *analysisRepository) GetByURL(ctx context.Context, url string) (*entitie
 }
 
 func (r *analysisRepository) Update(ctx context.Context, analysis *entities.Analysis) error {
+	return r.writes.do(ctx, func() error {
+		return updateAnalysis(ctx, r.db, analysis)
+	})
+}
+
+// updateAnalysis writes analysis only if the stored row still has
+// analysis.Version, bumping the version on success.
+func updateAnalysis(ctx context.Context, db execer, analysis *entities.Analysis) error {
 	query := `
 		UPDATE analyses SET 
 			status = $2, result = $3, error = $4, updated_at = $5, 
-			completed_at = $6, retry_count = $7
-		WHERE id = $1`
+			completed_at = $6, retry_count = $7, version = version + 1
+		WHERE id = $1 AND version = $8`
 
 	var resultJSON interface{}
 	if analysis.Result != nil {
@@ -114,7 +273,7 @@ func (r *analysisRepository) Update(ctx context.Context, analysis *entities.Anal
 		resultJSON = nil
 	}
 
-	_, err := r.db.ExecContext(ctx, query,
+	result, err := db.ExecContext(ctx, query,
 		analysis.ID,
 		analysis.Status,
 		resultJSON,
@@ -122,19 +281,124 @@ func (r *analysisRepository) Update(ctx context.Context, analysis *entities.Anal
 		analysis.UpdatedAt,
 		analysis.CompletedAt,
 		analysis.RetryCount,
+		analysis.Version,
 	)
 
 	if err != nil {
 		return fmt.Errorf("failed to update analysis: %w", err)
 	}
 
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update analysis: %w", err)
+	}
+
+	if affected == 0 {
+		return repositories.ErrVersionConflict
+	}
+
+	analysis.Version++
 	return nil
 }
 
 func (r *analysisRepository) List(ctx context.Context, filters repositories.AnalysisFilters) ([]*entities.Analysis, error) {
+	filters.Limit = clampListLimit(filters.Limit, r.maxListLimit)
+	query, args, err := buildListQuery(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list analyses: %w", err)
+	}
+	defer rows.Close()
+
+	analyses := make([]*entities.Analysis, 0)
+	for rows.Next() {
+		analysis, err := r.scanAnalysisFromRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		analyses = append(analyses, analysis)
+	}
+
+	return analyses, nil
+}
+
+func (r *analysisRepository) DeleteOlderThan(ctx context.Context, age time.Duration) (int64, error) {
+	var deleted int64
+	err := r.writes.do(ctx, func() error {
+		var err error
+		deleted, err = deleteOlderThan(ctx, r.db, time.Now().Add(-age), deleteBatchSize)
+		return err
+	})
+	return deleted, err
+}
+
+// deleteOlderThan deletes rows created before cutoff in batches of batchSize
+// until a batch comes back short.
+func deleteOlderThan(ctx context.Context, db execer, cutoff time.Time, batchSize int) (int64, error) {
+	var total int64
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		result, err := db.ExecContext(ctx, deleteOlderThanQuery, cutoff, batchSize)
+		if err != nil {
+			return total, fmt.Errorf("failed to delete old analyses: %w", err)
+		}
+
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return total, fmt.Errorf("failed to count deleted analyses: %w", err)
+		}
+
+		total += deleted
+		if deleted < int64(batchSize) {
+			return total, nil
+		}
+	}
+}
+
+func (r *analysisRepository) DeleteOldestForUser(ctx context.Context, userID string, keep int) (int64, error) {
+	var deleted int64
+	err := r.writes.do(ctx, func() error {
+		var err error
+		deleted, err = deleteOldestForUser(ctx, r.db, userID, keep)
+		return err
+	})
+	return deleted, err
+}
+
+func deleteOldestForUser(ctx context.Context, db execer, userID string, keep int) (int64, error) {
+	result, err := db.ExecContext(ctx, deleteOldestForUserQuery, userID, keep)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete oldest analyses: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count deleted analyses: %w", err)
+	}
+	return deleted, nil
+}
+
+// clampListLimit enforces the server-side row ceiling regardless of caller;
+// a missing or oversized limit becomes maxLimit.
+func clampListLimit(limit, maxLimit int) int {
+	if limit <= 0 || limit > maxLimit {
+		return maxLimit
+	}
+	return limit
+}
+
+func buildListQuery(filters repositories.AnalysisFilters) (string, []interface{}, error) {
 	query := `
 		SELECT id, url, status, result, error, created_at, updated_at, 
-			completed_at, retry_count, priority, user_id, correlation_id
+			completed_at, retry_count, priority, user_id, correlation_id, metadata, version
 		FROM analyses WHERE 1=1`
 
 	args := make([]interface{}, 0)
@@ -158,6 +422,33 @@ func (r *analysisRepository) List(ctx context.Context, filters repositories.Anal
 		args = append(args, "%"+filters.URL+"%")
 	}
 
+	if len(filters.Metadata) > 0 {
+		metadataJSON, err := json.Marshal(filters.Metadata)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to marshal metadata filter: %w", err)
+		}
+		argCount++
+		query += fmt.Sprintf(" AND metadata @> $%d", argCount)
+		args = append(args, metadataJSON)
+	}
+
+	if filters.Cursor != nil {
+		if !filters.Cursor.IsStart() {
+			query += fmt.Sprintf(" AND (created_at, id) < ($%d, $%d)", argCount+1, argCount+2)
+			argCount += 2
+			args = append(args, filters.Cursor.CreatedAt, filters.Cursor.ID)
+		}
+		query += " ORDER BY created_at DESC, id DESC"
+
+		if filters.Limit > 0 {
+			argCount++
+			query += fmt.Sprintf(" LIMIT $%d", argCount)
+			args = append(args, filters.Limit)
+		}
+
+		return query, args, nil
+	}
+
 	validSortFields := map[string]bool{
 		"created_at": true,
 		"updated_at": true,
@@ -188,27 +479,13 @@ func (r *analysisRepository) List(ctx context.Context, filters repositories.Anal
 		args = append(args, filters.Offset)
 	}
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list analyses: %w", err)
-	}
-	defer rows.Close()
-
-	analyses := make([]*entities.Analysis, 0)
-	for rows.Next() {
-		analysis, err := r.scanAnalysisFromRows(rows)
-		if err != nil {
-			return nil, err
-		}
-		analyses = append(analyses, analysis)
-	}
-
-	return analyses, nil
+	return query, args, nil
 }
 
 func (r *analysisRepository) scanAnalysis(row *sql.Row) (*entities.Analysis, error) {
 	var analysis entities.Analysis
 	var resultJSON []byte
+	var metadataJSON []byte
 
 	err := row.Scan(
 		&analysis.ID,
@@ -223,6 +500,8 @@ func (r *analysisRepository) scanAnalysis(row *sql.Row) (*entities.Analysis, err
 		&analysis.Priority,
 		&analysis.UserID,
 		&analysis.CorrelationID,
+		&metadataJSON,
+		&analysis.Version,
 	)
 
 	if err != nil {
@@ -240,12 +519,19 @@ func (r *analysisRepository) scanAnalysis(row *sql.Row) (*entities.Analysis, err
 		analysis.Result = &result
 	}
 
+	metadata, err := unmarshalMetadata(metadataJSON)
+	if err != nil {
+		return nil, err
+	}
+	analysis.Metadata = metadata
+
 	return &analysis, nil
 }
 
 func (r *analysisRepository) scanAnalysisFromRows(rows *sql.Rows) (*entities.Analysis, error) {
 	var analysis entities.Analysis
 	var resultJSON []byte
+	var metadataJSON []byte
 
 	err := rows.Scan(
 		&analysis.ID,
@@ -260,6 +546,8 @@ func (r *analysisRepository) scanAnalysisFromRows(rows *sql.Rows) (*entities.Ana
 		&analysis.Priority,
 		&analysis.UserID,
 		&analysis.CorrelationID,
+		&metadataJSON,
+		&analysis.Version,
 	)
 
 	if err != nil {
@@ -274,5 +562,37 @@ func (r *analysisRepository) scanAnalysisFromRows(rows *sql.Rows) (*entities.Ana
 		analysis.Result = &result
 	}
 
+	metadata, err := unmarshalMetadata(metadataJSON)
+	if err != nil {
+		return nil, err
+	}
+	analysis.Metadata = metadata
+
 	return &analysis, nil
 }
+
+func marshalMetadata(metadata map[string]string) (interface{}, error) {
+	if len(metadata) == 0 {
+		return nil, nil
+	}
+
+	metadataBytes, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata to JSON: %w", err)
+	}
+
+	return metadataBytes, nil
+}
+
+func unmarshalMetadata(metadataJSON []byte) (map[string]string, error) {
+	if metadataJSON == nil {
+		return nil, nil
+	}
+
+	var metadata map[string]string
+	if err := json.Unmarshal(metadataJSON, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+	}
+
+	return metadata, nil
+}
@@ -1,8 +1,21 @@
 package postgres
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"testing"
+	"time"
+	"webpage-analyzer/internal/domain/entities"
+	"webpage-analyzer/internal/domain/repositories"
+	"webpage-analyzer/pkg/config"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -17,3 +30,411 @@ func TestAnalysisRepositoryConstructor(t *testing.T) {
 func TestAnalysisRepositoryMethods(t *testing.T) {
 	assert.True(t, true)
 }
+
+func TestMetadataRoundTrip(t *testing.T) {
+	metadata := map[string]string{"campaign": "spring", "batch": "42"}
+
+	encoded, err := marshalMetadata(metadata)
+	assert.NoError(t, err)
+
+	decoded, err := unmarshalMetadata(encoded.([]byte))
+	assert.NoError(t, err)
+	assert.Equal(t, metadata, decoded)
+}
+
+func TestMetadataEmpty(t *testing.T) {
+	encoded, err := marshalMetadata(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, encoded)
+
+	decoded, err := unmarshalMetadata(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, decoded)
+}
+
+func TestBuildListQueryWithMetadataFilter(t *testing.T) {
+	query, args, err := buildListQuery(repositories.AnalysisFilters{
+		Status:   entities.StatusCompleted,
+		Metadata: map[string]string{"campaign": "spring"},
+		Limit:    10,
+	})
+
+	assert.NoError(t, err)
+	assert.Contains(t, query, "status = $1")
+	assert.Contains(t, query, "metadata @> $2")
+	assert.Contains(t, query, "LIMIT $3")
+	assert.Len(t, args, 3)
+	assert.JSONEq(t, `{"campaign":"spring"}`, string(args[1].([]byte)))
+}
+
+func TestBuildListQueryWithoutMetadataFilter(t *testing.T) {
+	query, args, err := buildListQuery(repositories.AnalysisFilters{})
+
+	assert.NoError(t, err)
+	assert.NotContains(t, query, "metadata @>")
+	assert.Empty(t, args)
+}
+
+func TestBuildListQueryWithCursor(t *testing.T) {
+	cursor := &repositories.ListCursor{CreatedAt: time.Now(), ID: uuid.New()}
+	query, args, err := buildListQuery(repositories.AnalysisFilters{
+		Status:    entities.StatusCompleted,
+		Cursor:    cursor,
+		Limit:     20,
+		Offset:    40,
+		SortBy:    "priority",
+		SortOrder: "asc",
+	})
+
+	assert.NoError(t, err)
+	assert.Contains(t, query, "(created_at, id) < ($2, $3)")
+	assert.Contains(t, query, "ORDER BY created_at DESC, id DESC")
+	assert.Contains(t, query, "LIMIT $4")
+	assert.NotContains(t, query, "OFFSET")
+	assert.NotContains(t, query, "ORDER BY priority")
+	assert.Equal(t, []interface{}{entities.StatusCompleted, cursor.CreatedAt, cursor.ID, 20}, args)
+}
+
+func TestBuildListQueryWithStartCursor(t *testing.T) {
+	query, args, err := buildListQuery(repositories.AnalysisFilters{
+		Cursor: &repositories.ListCursor{},
+		Limit:  20,
+	})
+
+	assert.NoError(t, err)
+	assert.NotContains(t, query, "(created_at, id) <")
+	assert.Contains(t, query, "ORDER BY created_at DESC, id DESC")
+	assert.Equal(t, []interface{}{20}, args)
+}
+
+// listDB answers cursor listings from rows held in memory, ordering and
+// filtering them by (created_at, id) the way Postgres would.
+type listDB struct {
+	rows []*entities.Analysis
+}
+
+func (db *listDB) Connect(context.Context) (driver.Conn, error) { return listConn{db}, nil }
+func (db *listDB) Driver() driver.Driver                        { return nil }
+
+// insert stores analysis with created_at truncated to Postgres' microsecond
+// precision.
+func (db *listDB) insert(analysis *entities.Analysis) {
+	analysis.CreatedAt = analysis.CreatedAt.Truncate(time.Microsecond)
+	db.rows = append(db.rows, analysis)
+}
+
+type listConn struct{ db *listDB }
+
+func (c listConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c listConn) Close() error                        { return nil }
+func (c listConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c listConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if !strings.Contains(query, "ORDER BY created_at DESC, id DESC") {
+		return nil, fmt.Errorf("unexpected query %q", query)
+	}
+
+	before := func(a, b *entities.Analysis) bool {
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return bytes.Compare(a.ID[:], b.ID[:]) > 0
+	}
+	rows := append([]*entities.Analysis(nil), c.db.rows...)
+	sort.Slice(rows, func(i, j int) bool { return before(rows[i], rows[j]) })
+
+	if strings.Contains(query, "(created_at, id) <") {
+		id, err := uuid.Parse(args[len(args)-2].Value.(string))
+		if err != nil {
+			return nil, err
+		}
+		cursor := &entities.Analysis{CreatedAt: args[len(args)-3].Value.(time.Time), ID: id}
+		for len(rows) > 0 && !before(cursor, rows[0]) {
+			rows = rows[1:]
+		}
+	}
+	if limit := int(args[len(args)-1].Value.(int64)); len(rows) > limit {
+		rows = rows[:limit]
+	}
+
+	result := &valueRows{columns: make([]string, 14)}
+	for _, a := range rows {
+		result.values = append(result.values, []driver.Value{
+			a.ID.String(), a.URL, string(a.Status), nil, a.Error, a.CreatedAt, a.UpdatedAt,
+			nil, int64(a.RetryCount), int64(a.Priority), a.UserID, a.CorrelationID, nil, int64(a.Version),
+		})
+	}
+	return result, nil
+}
+
+func TestListWithCursorIteratesStablyAcrossInserts(t *testing.T) {
+	store := &listDB{}
+	created := time.Now().Add(-time.Hour)
+	want := make([]uuid.UUID, 0)
+	for i := 0; i < 10; i++ {
+		analysis := entities.NewAnalysis(fmt.Sprintf("https://example.com/%d", i), "user1", "corr")
+		// groups of rows share a created_at and straddle page boundaries
+		analysis.CreatedAt = created.Add(time.Duration(i/3) * time.Second)
+		store.insert(analysis)
+	}
+	for _, analysis := range store.rows {
+		want = append(want, analysis.ID)
+	}
+
+	db := sql.OpenDB(store)
+	defer db.Close()
+	repo := &analysisRepository{db: db, maxListLimit: 4}
+
+	seen := make([]uuid.UUID, 0)
+	cursor := &repositories.ListCursor{}
+	for pages := 0; ; pages++ {
+		if !assert.Less(t, pages, 10, "iteration did not terminate") {
+			return
+		}
+		page, err := repo.List(context.Background(), repositories.AnalysisFilters{Cursor: cursor, Limit: 500})
+		assert.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+		assert.LessOrEqual(t, len(page), 4)
+		for _, analysis := range page {
+			seen = append(seen, analysis.ID)
+		}
+
+		// new analyses land ahead of the cursor and must not shift the pages
+		store.insert(entities.NewAnalysis("https://example.com/new", "user1", "corr"))
+
+		// clients carry the cursor as its encoded form
+		cursor, err = repositories.DecodeListCursor(repositories.NewListCursor(page[len(page)-1]).Encode())
+		assert.NoError(t, err)
+	}
+
+	assert.ElementsMatch(t, want, seen)
+	assert.Len(t, seen, len(want), "no analysis is listed twice")
+}
+
+func TestClampListLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    int
+		expected int
+	}{
+		{"within ceiling", 50, 50},
+		{"at ceiling", 500, 500},
+		{"above ceiling", 10000, 500},
+		{"unset", 0, 500},
+		{"negative", -1, 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, clampListLimit(tt.limit, 500))
+		})
+	}
+}
+
+func TestBuildListQueryWithClampedLimit(t *testing.T) {
+	query, args, err := buildListQuery(repositories.AnalysisFilters{
+		Limit: clampListLimit(0, DefaultMaxListLimit),
+	})
+
+	assert.NoError(t, err)
+	assert.Contains(t, query, "LIMIT $1")
+	assert.Equal(t, []interface{}{DefaultMaxListLimit}, args)
+}
+
+// fakeDeleteDB emulates the batched retention DELETE against in-memory rows.
+type fakeDeleteDB struct {
+	createdAt map[uuid.UUID]time.Time
+	execs     int
+}
+
+func (db *fakeDeleteDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	db.execs++
+	cutoff := args[0].(time.Time)
+	limit := args[1].(int)
+
+	var deleted int64
+	for id, createdAt := range db.createdAt {
+		if deleted == int64(limit) {
+			break
+		}
+		if createdAt.Before(cutoff) {
+			delete(db.createdAt, id)
+			deleted++
+		}
+	}
+	return driverResult(deleted), nil
+}
+
+type driverResult int64
+
+func (r driverResult) LastInsertId() (int64, error) { return 0, nil }
+func (r driverResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+func TestDeleteOlderThanRemovesOnlyOldRows(t *testing.T) {
+	now := time.Now()
+	db := &fakeDeleteDB{createdAt: make(map[uuid.UUID]time.Time)}
+
+	recent := make([]uuid.UUID, 0)
+	for i := 0; i < 5; i++ {
+		db.createdAt[uuid.New()] = now.Add(-48 * time.Hour)
+		id := uuid.New()
+		db.createdAt[id] = now.Add(-time.Hour)
+		recent = append(recent, id)
+	}
+
+	deleted, err := deleteOlderThan(context.Background(), db, now.Add(-24*time.Hour), 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), deleted)
+	assert.Equal(t, 3, db.execs)
+	assert.Len(t, db.createdAt, len(recent))
+	for _, id := range recent {
+		assert.Contains(t, db.createdAt, id)
+	}
+}
+
+func TestDeleteOlderThanStopsOnCancelledContext(t *testing.T) {
+	db := &fakeDeleteDB{createdAt: map[uuid.UUID]time.Time{uuid.New(): time.Now().Add(-48 * time.Hour)}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := deleteOlderThan(ctx, db, time.Now(), 10)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, db.execs)
+}
+
+// recordingExecer captures the statement sent by deleteOldestForUser.
+type recordingExecer struct {
+	query    string
+	args     []interface{}
+	affected int64
+	err      error
+}
+
+func (db *recordingExecer) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	db.query, db.args = query, args
+	if db.err != nil {
+		return nil, db.err
+	}
+	return driverResult(db.affected), nil
+}
+
+func TestDeleteOldestForUser(t *testing.T) {
+	db := &recordingExecer{affected: 3}
+
+	deleted, err := deleteOldestForUser(context.Background(), db, "user1", 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+	assert.Equal(t, deleteOldestForUserQuery, db.query)
+	assert.Equal(t, []interface{}{"user1", 10}, db.args)
+	assert.Contains(t, db.query, "ORDER BY created_at DESC, id DESC OFFSET $2")
+	assert.Contains(t, db.query, "status IN ('completed', 'failed', 'cancelled', 'expired')")
+}
+
+func TestDeleteOldestForUserError(t *testing.T) {
+	db := &recordingExecer{err: errors.New("connection reset")}
+
+	_, err := deleteOldestForUser(context.Background(), db, "user1", 10)
+
+	assert.ErrorContains(t, err, "failed to delete oldest analyses")
+}
+
+func TestOrderByIDsWithMissingIDs(t *testing.T) {
+	first := entities.NewAnalysis("https://example.com/1", "user1", "corr1")
+	second := entities.NewAnalysis("https://example.com/2", "user1", "corr2")
+	missing := uuid.New()
+
+	// rows come back from ANY($1) in arbitrary order
+	ordered := orderByIDs([]uuid.UUID{second.ID, missing, first.ID}, []*entities.Analysis{first, second})
+
+	assert.Len(t, ordered, 2)
+	assert.Equal(t, second.ID, ordered[0].ID)
+	assert.Equal(t, first.ID, ordered[1].ID)
+
+	assert.Empty(t, orderByIDs([]uuid.UUID{missing}, nil))
+}
+
+func TestPingWithRetryFailsFastOnUnreachableDatabase(t *testing.T) {
+	// nothing listens on port 1, so every attempt is refused
+	db, err := sql.Open("postgres", "host=127.0.0.1 port=1 user=postgres dbname=none sslmode=disable")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	start := time.Now()
+	err = pingWithRetry(context.Background(), db, 2, 200*time.Millisecond, 10*time.Millisecond)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "gave up after 2 attempts")
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestDataSourceNameBoundsConnectTimeout(t *testing.T) {
+	dsn := dataSourceName(&config.DatabaseConfig{Host: "db", Port: "5432", User: "u", Password: "p", Name: "n", SSLMode: "disable"})
+	assert.Contains(t, dsn, "connect_timeout=5")
+}
+
+func TestOpenUnreachable(t *testing.T) {
+	start := time.Now()
+	db, err := Open(&config.DatabaseConfig{
+		Host:    "127.0.0.1",
+		Port:    "1",
+		User:    "postgres",
+		Name:    "none",
+		SSLMode: "disable",
+	})
+
+	assert.Nil(t, db)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to ping database")
+	assert.Less(t, time.Since(start), 3*pingTimeout)
+}
+
+func TestNewAnalysisRepositoryUsesSharedPool(t *testing.T) {
+	db := sql.OpenDB(&listDB{})
+	defer db.Close()
+
+	repo := NewAnalysisRepository(db, &config.DatabaseConfig{}).(*analysisRepository)
+
+	assert.Same(t, db, repo.db)
+	assert.Equal(t, DefaultMaxListLimit, repo.maxListLimit)
+}
+
+// fakeVersionedDB emulates the optimistic-locking UPDATE for a single row.
+type fakeVersionedDB struct {
+	version int
+}
+
+func (db *fakeVersionedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if args[len(args)-1].(int) != db.version {
+		return driverResult(0), nil
+	}
+	db.version++
+	return driverResult(1), nil
+}
+
+func TestUpdateAnalysisDetectsConcurrentUpdate(t *testing.T) {
+	db := &fakeVersionedDB{version: 1}
+
+	// the async processor and a retry both read version 1
+	processor := entities.NewAnalysis("https://example.com", "user1", "corr1")
+	retry := *processor
+
+	processor.MarkAsCompleted(&entities.AnalysisResult{Title: "Processor"})
+	assert.NoError(t, updateAnalysis(context.Background(), db, processor))
+	assert.Equal(t, 2, processor.Version)
+
+	retry.MarkAsFailed("timeout")
+	err := updateAnalysis(context.Background(), db, &retry)
+	assert.ErrorIs(t, err, repositories.ErrVersionConflict)
+	assert.Equal(t, 1, retry.Version)
+
+	// after re-reading, the retry can write against the current version
+	retry.Version = db.version
+	assert.NoError(t, updateAnalysis(context.Background(), db, &retry))
+	assert.Equal(t, 3, db.version)
+}
@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"time"
+	"webpage-analyzer/internal/domain/entities"
+	"webpage-analyzer/internal/domain/repositories"
+	"webpage-analyzer/internal/infrastructure/monitoring"
+	"webpage-analyzer/pkg/logger"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// instrumentedRepository times every repository call, records it in the
+// database query histogram and warns when a call exceeds slowThreshold.
+type instrumentedRepository struct {
+	next          repositories.AnalysisRepository
+	logger        logger.Logger
+	slowThreshold time.Duration
+}
+
+// NewInstrumentedRepository wraps repo with query timing. A slowThreshold of
+// zero or less disables slow-query warnings but keeps the metrics.
+func NewInstrumentedRepository(repo repositories.AnalysisRepository, log logger.Logger, slowThreshold time.Duration) repositories.AnalysisRepository {
+	if log == nil {
+		log = logger.NewNop()
+	}
+	return &instrumentedRepository{
+		next:          repo,
+		logger:        log,
+		slowThreshold: slowThreshold,
+	}
+}
+
+func (r *instrumentedRepository) observe(ctx context.Context, query string, start time.Time) {
+	duration := time.Since(start)
+	monitoring.DatabaseQueryDuration.WithLabelValues(query).Observe(duration.Seconds())
+
+	if r.slowThreshold > 0 && duration > r.slowThreshold {
+		r.logger.WithContext(ctx).Warn("Slow database query",
+			zap.String("query", query),
+			zap.Duration("duration", duration),
+			zap.Duration("threshold", r.slowThreshold),
+		)
+	}
+}
+
+func (r *instrumentedRepository) Create(ctx context.Context, analysis *entities.Analysis) error {
+	defer r.observe(ctx, "create", time.Now())
+	return r.next.Create(ctx, analysis)
+}
+
+func (r *instrumentedRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.Analysis, error) {
+	defer r.observe(ctx, "get_by_id", time.Now())
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *instrumentedRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*entities.Analysis, error) {
+	defer r.observe(ctx, "get_by_ids", time.Now())
+	return r.next.GetByIDs(ctx, ids)
+}
+
+func (r *instrumentedRepository) GetByURL(ctx context.Context, url string) (*entities.Analysis, error) {
+	defer r.observe(ctx, "get_by_url", time.Now())
+	return r.next.GetByURL(ctx, url)
+}
+
+func (r *instrumentedRepository) Update(ctx context.Context, analysis *entities.Analysis) error {
+	defer r.observe(ctx, "update", time.Now())
+	return r.next.Update(ctx, analysis)
+}
+
+func (r *instrumentedRepository) List(ctx context.Context, filters repositories.AnalysisFilters) ([]*entities.Analysis, error) {
+	defer r.observe(ctx, "list", time.Now())
+	return r.next.List(ctx, filters)
+}
+
+func (r *instrumentedRepository) DeleteOlderThan(ctx context.Context, age time.Duration) (int64, error) {
+	defer r.observe(ctx, "delete_older_than", time.Now())
+	return r.next.DeleteOlderThan(ctx, age)
+}
+
+func (r *instrumentedRepository) DeleteOldestForUser(ctx context.Context, userID string, keep int) (int64, error) {
+	defer r.observe(ctx, "delete_oldest_for_user", time.Now())
+	return r.next.DeleteOldestForUser(ctx, userID, keep)
+}
+
+func (r *instrumentedRepository) Stats(ctx context.Context, top int) (*entities.AnalysisStats, error) {
+	defer r.observe(ctx, "stats", time.Now())
+	return r.next.Stats(ctx, top)
+}
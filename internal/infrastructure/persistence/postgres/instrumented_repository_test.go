@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+	"webpage-analyzer/internal/domain/entities"
+	"webpage-analyzer/internal/domain/repositories"
+	"webpage-analyzer/pkg/logger"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// slowRepository simulates a database that takes delay to answer GetByID.
+type slowRepository struct {
+	repositories.AnalysisRepository
+	delay time.Duration
+}
+
+func (r *slowRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.Analysis, error) {
+	time.Sleep(r.delay)
+	return &entities.Analysis{ID: id}, nil
+}
+
+type observedLogger struct {
+	zap *zap.Logger
+}
+
+func (l *observedLogger) Debug(msg string, fields ...zap.Field) { l.zap.Debug(msg, fields...) }
+func (l *observedLogger) Info(msg string, fields ...zap.Field)  { l.zap.Info(msg, fields...) }
+func (l *observedLogger) Warn(msg string, fields ...zap.Field)  { l.zap.Warn(msg, fields...) }
+func (l *observedLogger) Error(msg string, fields ...zap.Field) { l.zap.Error(msg, fields...) }
+func (l *observedLogger) Fatal(msg string, fields ...zap.Field) { l.zap.Fatal(msg, fields...) }
+func (l *observedLogger) With(fields ...zap.Field) logger.Logger {
+	return &observedLogger{l.zap.With(fields...)}
+}
+func (l *observedLogger) WithContext(ctx context.Context) logger.Logger { return l }
+
+func TestInstrumentedRepositoryLogsSlowQueries(t *testing.T) {
+	core, logs := observer.New(zapcore.WarnLevel)
+	log := &observedLogger{zap.New(core)}
+
+	slow := NewInstrumentedRepository(&slowRepository{delay: 20 * time.Millisecond}, log, 5*time.Millisecond)
+	_, err := slow.GetByID(context.Background(), uuid.New())
+	assert.NoError(t, err)
+
+	entries := logs.FilterMessage("Slow database query").All()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "get_by_id", entries[0].ContextMap()["query"])
+
+	fast := NewInstrumentedRepository(&slowRepository{}, log, time.Second)
+	_, err = fast.GetByID(context.Background(), uuid.New())
+	assert.NoError(t, err)
+	assert.Len(t, logs.FilterMessage("Slow database query").All(), 1)
+}
@@ -0,0 +1,160 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"webpage-analyzer/internal/domain/entities"
+	"webpage-analyzer/internal/domain/repositories"
+
+	"github.com/google/uuid"
+)
+
+const monitorColumns = `id, url, user_id, interval_seconds, next_run_at, last_run_at,
+	last_analysis_id, created_at, updated_at`
+
+type monitorRepository struct {
+	db *sql.DB
+}
+
+// NewMonitorRepository stores monitors in the monitored_urls table of db.
+func NewMonitorRepository(db *sql.DB) repositories.MonitorRepository {
+	return &monitorRepository{db: db}
+}
+
+func (r *monitorRepository) Create(ctx context.Context, monitor *entities.MonitoredURL) error {
+	query := `
+		INSERT INTO monitored_urls (` + monitorColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		monitor.ID,
+		monitor.URL,
+		monitor.UserID,
+		int64(monitor.Interval/time.Second),
+		monitor.NextRunAt,
+		monitor.LastRunAt,
+		monitor.LastAnalysisID,
+		monitor.CreatedAt,
+		monitor.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create monitor: %w", err)
+	}
+
+	return nil
+}
+
+func (r *monitorRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.MonitoredURL, error) {
+	query := `SELECT ` + monitorColumns + ` FROM monitored_urls WHERE id = $1`
+
+	monitor, err := scanMonitor(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, repositories.ErrMonitorNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan monitor: %w", err)
+	}
+
+	return monitor, nil
+}
+
+// claimDueMonitorsQuery pushes the most overdue monitors forward in one
+// statement; SKIP LOCKED leaves rows another replica is claiming to it.
+const claimDueMonitorsQuery = `
+	UPDATE monitored_urls SET next_run_at = $2, updated_at = $1
+	WHERE id IN (
+		SELECT id FROM monitored_urls
+		WHERE next_run_at <= $1
+		ORDER BY next_run_at ASC
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING ` + monitorColumns
+
+func (r *monitorRepository) ClaimDue(ctx context.Context, now, until time.Time, limit int) ([]*entities.MonitoredURL, error) {
+	rows, err := r.db.QueryContext(ctx, claimDueMonitorsQuery, now, until, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due monitors: %w", err)
+	}
+	defer rows.Close()
+
+	monitors := make([]*entities.MonitoredURL, 0)
+	for rows.Next() {
+		monitor, err := scanMonitor(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan monitor: %w", err)
+		}
+		monitors = append(monitors, monitor)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to claim due monitors: %w", err)
+	}
+
+	return monitors, nil
+}
+
+func (r *monitorRepository) Update(ctx context.Context, monitor *entities.MonitoredURL) error {
+	return updateMonitor(ctx, r.db, monitor)
+}
+
+// updateMonitor writes the scheduling state of monitor.
+func updateMonitor(ctx context.Context, db execer, monitor *entities.MonitoredURL) error {
+	query := `
+		UPDATE monitored_urls SET
+			next_run_at = $2, last_run_at = $3, last_analysis_id = $4, updated_at = $5
+		WHERE id = $1`
+
+	result, err := db.ExecContext(ctx, query,
+		monitor.ID,
+		monitor.NextRunAt,
+		monitor.LastRunAt,
+		monitor.LastAnalysisID,
+		monitor.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update monitor: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update monitor: %w", err)
+	}
+
+	if affected == 0 {
+		return repositories.ErrMonitorNotFound
+	}
+
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanMonitor(row rowScanner) (*entities.MonitoredURL, error) {
+	var monitor entities.MonitoredURL
+	var userID sql.NullString
+	var intervalSeconds int64
+
+	err := row.Scan(
+		&monitor.ID,
+		&monitor.URL,
+		&userID,
+		&intervalSeconds,
+		&monitor.NextRunAt,
+		&monitor.LastRunAt,
+		&monitor.LastAnalysisID,
+		&monitor.CreatedAt,
+		&monitor.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	monitor.UserID = userID.String
+	monitor.Interval = time.Duration(intervalSeconds) * time.Second
+	return &monitor, nil
+}
@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+	"webpage-analyzer/internal/domain/entities"
+	"webpage-analyzer/internal/domain/repositories"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeMonitorDB records the arguments of the last exec and reports affected
+// rows.
+type fakeMonitorDB struct {
+	args     []interface{}
+	affected int64
+}
+
+func (db *fakeMonitorDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	db.args = args
+	return driverResult(db.affected), nil
+}
+
+func TestUpdateMonitorWritesSchedule(t *testing.T) {
+	monitor := entities.NewMonitoredURL("https://example.com", "user1", time.Hour)
+	runAt := time.Now()
+	analysisID := uuid.New()
+	monitor.RecordRun(analysisID, runAt)
+
+	db := &fakeMonitorDB{affected: 1}
+	assert.NoError(t, updateMonitor(context.Background(), db, monitor))
+
+	assert.Equal(t, monitor.ID, db.args[0])
+	assert.Equal(t, runAt.Add(time.Hour), db.args[1])
+	assert.Equal(t, &runAt, db.args[2])
+	assert.Equal(t, &analysisID, db.args[3])
+}
+
+func TestUpdateMonitorNotFound(t *testing.T) {
+	monitor := entities.NewMonitoredURL("https://example.com", "user1", time.Hour)
+
+	err := updateMonitor(context.Background(), &fakeMonitorDB{}, monitor)
+
+	assert.ErrorIs(t, err, repositories.ErrMonitorNotFound)
+}
+
+// fakeMonitorRow scans fixed values the way a monitored_urls row would.
+type fakeMonitorRow struct {
+	values []interface{}
+}
+
+func (r fakeMonitorRow) Scan(dest ...interface{}) error {
+	for i, value := range r.values {
+		switch d := dest[i].(type) {
+		case *uuid.UUID:
+			*d = value.(uuid.UUID)
+		case *string:
+			*d = value.(string)
+		case *sql.NullString:
+			*d = sql.NullString{String: value.(string), Valid: true}
+		case *int64:
+			*d = value.(int64)
+		case *time.Time:
+			*d = value.(time.Time)
+		case **time.Time:
+			*d = nil
+		case **uuid.UUID:
+			*d = nil
+		}
+	}
+	return nil
+}
+
+func TestScanMonitorConvertsInterval(t *testing.T) {
+	now := time.Now()
+	id := uuid.New()
+
+	monitor, err := scanMonitor(fakeMonitorRow{values: []interface{}{
+		id, "https://example.com", "user1", int64(86400), now, nil, nil, now, now,
+	}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, id, monitor.ID)
+	assert.Equal(t, "user1", monitor.UserID)
+	assert.Equal(t, 24*time.Hour, monitor.Interval)
+	assert.Nil(t, monitor.LastAnalysisID)
+}
+
+func TestClaimDueMonitorsQuerySkipsLockedRows(t *testing.T) {
+	assert.Contains(t, claimDueMonitorsQuery, "UPDATE monitored_urls SET next_run_at = $2")
+	assert.Contains(t, claimDueMonitorsQuery, "FOR UPDATE SKIP LOCKED")
+	assert.Contains(t, claimDueMonitorsQuery, "RETURNING "+monitorColumns)
+}
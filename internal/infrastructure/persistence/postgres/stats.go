@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"webpage-analyzer/internal/domain/entities"
+)
+
+// DefaultStatsTop is how many HTML versions and broken-link hosts Stats
+// lists when top is not positive.
+const DefaultStatsTop = 10
+
+const (
+	statusCountsQuery = `
+		SELECT status, COUNT(*) FROM analyses GROUP BY status`
+
+	averageLoadTimeQuery = `
+		SELECT COALESCE(AVG((result->>'load_time')::bigint), 0)
+		FROM analyses
+		WHERE status = 'completed' AND result->>'load_time' IS NOT NULL`
+
+	htmlVersionsQuery = `
+		SELECT result->>'html_version' AS version, COUNT(*)
+		FROM analyses
+		WHERE status = 'completed' AND COALESCE(result->>'html_version', '') <> ''
+		GROUP BY version
+		ORDER BY COUNT(*) DESC, version
+		LIMIT $1`
+
+	// broken_links holds absolute URLs; the host is the authority without
+	// userinfo or port
+	brokenLinkHostsQuery = `
+		SELECT host, COUNT(*)
+		FROM (
+			SELECT lower(substring(link FROM '^[A-Za-z][A-Za-z0-9+.-]*://(?:[^/?#@]*@)?([^/?#:]+)')) AS host
+			FROM analyses,
+				jsonb_array_elements_text(CASE
+					WHEN jsonb_typeof(result->'links'->'broken_links') = 'array'
+					THEN result->'links'->'broken_links' ELSE '[]'::jsonb END) AS link
+			WHERE status = 'completed'
+		) broken
+		WHERE host IS NOT NULL
+		GROUP BY host
+		ORDER BY COUNT(*) DESC, host
+		LIMIT $1`
+)
+
+// statsQuerier is the subset of *sql.DB the aggregation needs.
+type statsQuerier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func (r *analysisRepository) Stats(ctx context.Context, top int) (*entities.AnalysisStats, error) {
+	return analysisStats(ctx, r.db, top)
+}
+
+func analysisStats(ctx context.Context, db statsQuerier, top int) (*entities.AnalysisStats, error) {
+	if top <= 0 {
+		top = DefaultStatsTop
+	}
+	stats := &entities.AnalysisStats{
+		ByStatus:    make(map[entities.AnalysisStatus]int),
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	statusCounts, err := queryCounts(ctx, db, statusCountsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count analyses by status: %w", err)
+	}
+	for _, count := range statusCounts {
+		stats.ByStatus[entities.AnalysisStatus(count.Value)] = count.Count
+		stats.Total += count.Count
+	}
+
+	var averageNanos float64
+	if err := db.QueryRowContext(ctx, averageLoadTimeQuery).Scan(&averageNanos); err != nil {
+		return nil, fmt.Errorf("failed to average load time: %w", err)
+	}
+	stats.AverageLoadTime = time.Duration(averageNanos)
+
+	if stats.HTMLVersions, err = queryCounts(ctx, db, htmlVersionsQuery, top); err != nil {
+		return nil, fmt.Errorf("failed to count HTML versions: %w", err)
+	}
+	if stats.BrokenLinkHosts, err = queryCounts(ctx, db, brokenLinkHostsQuery, top); err != nil {
+		return nil, fmt.Errorf("failed to count broken link hosts: %w", err)
+	}
+
+	return stats, nil
+}
+
+// queryCounts runs a GROUP BY query returning (value, count) rows.
+func queryCounts(ctx context.Context, db statsQuerier, query string, args ...interface{}) ([]entities.StatCount, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make([]entities.StatCount, 0)
+	for rows.Next() {
+		var count entities.StatCount
+		if err := rows.Scan(&count.Value, &count.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,226 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+	"webpage-analyzer/internal/domain/entities"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statsDB answers the stats queries by aggregating seeded analyses in
+// memory, the way Postgres would for the same rows.
+type statsDB struct {
+	analyses []*entities.Analysis
+	queries  []string
+	fail     string
+}
+
+func (db *statsDB) Connect(context.Context) (driver.Conn, error) { return statsConn{db}, nil }
+func (db *statsDB) Driver() driver.Driver                        { return nil }
+
+type statsConn struct{ db *statsDB }
+
+func (c statsConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c statsConn) Close() error                        { return nil }
+func (c statsConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c statsConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	db := c.db
+	db.queries = append(db.queries, query)
+	if query == db.fail {
+		return nil, errors.New("relation does not exist")
+	}
+
+	completed := make([]*entities.AnalysisResult, 0)
+	for _, analysis := range db.analyses {
+		if analysis.Status == entities.StatusCompleted && analysis.Result != nil {
+			completed = append(completed, analysis.Result)
+		}
+	}
+
+	switch query {
+	case statusCountsQuery:
+		counts := make(map[string]int)
+		for _, analysis := range db.analyses {
+			counts[string(analysis.Status)]++
+		}
+		return countRows(counts, len(counts)), nil
+	case averageLoadTimeQuery:
+		var total float64
+		for _, result := range completed {
+			total += float64(result.LoadTime)
+		}
+		average := 0.0
+		if len(completed) > 0 {
+			average = total / float64(len(completed))
+		}
+		return &valueRows{columns: []string{"avg"}, values: [][]driver.Value{{average}}}, nil
+	case htmlVersionsQuery:
+		counts := make(map[string]int)
+		for _, result := range completed {
+			if result.HTMLVersion != "" {
+				counts[result.HTMLVersion]++
+			}
+		}
+		return countRows(counts, int(args[0].Value.(int64))), nil
+	case brokenLinkHostsQuery:
+		counts := make(map[string]int)
+		for _, result := range completed {
+			for _, link := range result.Links.BrokenLinks {
+				if u, err := url.Parse(link); err == nil && u.Hostname() != "" {
+					counts[strings.ToLower(u.Hostname())]++
+				}
+			}
+		}
+		return countRows(counts, int(args[0].Value.(int64))), nil
+	}
+	return nil, fmt.Errorf("unexpected query %q", query)
+}
+
+// countRows orders counts like the queries do: most common first, then by
+// value, keeping at most limit rows.
+func countRows(counts map[string]int, limit int) *valueRows {
+	values := make([]string, 0, len(counts))
+	for value := range counts {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if counts[values[i]] != counts[values[j]] {
+			return counts[values[i]] > counts[values[j]]
+		}
+		return values[i] < values[j]
+	})
+	if len(values) > limit {
+		values = values[:limit]
+	}
+
+	rows := &valueRows{columns: []string{"value", "count"}}
+	for _, value := range values {
+		rows.values = append(rows.values, []driver.Value{value, int64(counts[value])})
+	}
+	return rows
+}
+
+type valueRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *valueRows) Columns() []string { return r.columns }
+func (r *valueRows) Close() error      { return nil }
+
+func (r *valueRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func seededAnalysis(status entities.AnalysisStatus, result *entities.AnalysisResult) *entities.Analysis {
+	analysis := entities.NewAnalysis("https://example.com", "user1", "corr1")
+	analysis.Status = status
+	analysis.Result = result
+	return analysis
+}
+
+func seedStatsDB() *statsDB {
+	db := &statsDB{}
+	for i := 0; i < 30; i++ {
+		version := "HTML5"
+		switch {
+		case i%10 == 0:
+			version = "HTML 4.01 Strict"
+		case i%15 == 1:
+			version = "XHTML 1.0 Transitional"
+		}
+		db.analyses = append(db.analyses, seededAnalysis(entities.StatusCompleted, &entities.AnalysisResult{
+			HTMLVersion: version,
+			LoadTime:    time.Duration(i+1) * 10 * time.Millisecond,
+			Links: entities.LinkAnalysis{BrokenLinks: []string{
+				"https://dead.example.com/page",
+				fmt.Sprintf("https://Gone.example.org:8443/%d", i),
+			}[:1+i%2]},
+		}))
+	}
+	for i := 0; i < 4; i++ {
+		db.analyses = append(db.analyses, seededAnalysis(entities.StatusFailed, nil))
+	}
+	for i := 0; i < 3; i++ {
+		// pending rows never count towards load time or versions
+		db.analyses = append(db.analyses, seededAnalysis(entities.StatusPending, &entities.AnalysisResult{HTMLVersion: "HTML 3.2"}))
+	}
+	return db
+}
+
+func TestAnalysisStatsAggregatesSeededAnalyses(t *testing.T) {
+	seed := seedStatsDB()
+	db := sql.OpenDB(seed)
+	defer db.Close()
+
+	stats, err := analysisStats(context.Background(), db, 2)
+	require.NoError(t, err)
+
+	assert.Equal(t, 37, stats.Total)
+	assert.Equal(t, map[entities.AnalysisStatus]int{
+		entities.StatusCompleted: 30,
+		entities.StatusFailed:    4,
+		entities.StatusPending:   3,
+	}, stats.ByStatus)
+	assert.Equal(t, 155*time.Millisecond, stats.AverageLoadTime)
+	assert.Equal(t, []entities.StatCount{
+		{Value: "HTML5", Count: 25},
+		{Value: "HTML 4.01 Strict", Count: 3},
+	}, stats.HTMLVersions)
+	assert.Equal(t, []entities.StatCount{
+		{Value: "dead.example.com", Count: 30},
+		{Value: "gone.example.org", Count: 15},
+	}, stats.BrokenLinkHosts)
+	assert.False(t, stats.GeneratedAt.IsZero())
+}
+
+func TestAnalysisStatsDefaultsTop(t *testing.T) {
+	seed := seedStatsDB()
+	db := sql.OpenDB(seed)
+	defer db.Close()
+
+	stats, err := analysisStats(context.Background(), db, 0)
+	require.NoError(t, err)
+	assert.Len(t, stats.HTMLVersions, 3)
+	assert.Len(t, seed.queries, 4)
+}
+
+func TestAnalysisStatsEmpty(t *testing.T) {
+	db := sql.OpenDB(&statsDB{})
+	defer db.Close()
+
+	stats, err := analysisStats(context.Background(), db, 5)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.Total)
+	assert.Empty(t, stats.ByStatus)
+	assert.Equal(t, time.Duration(0), stats.AverageLoadTime)
+	assert.NotNil(t, stats.HTMLVersions)
+	assert.NotNil(t, stats.BrokenLinkHosts)
+}
+
+func TestAnalysisStatsReportsFailingQuery(t *testing.T) {
+	seed := seedStatsDB()
+	seed.fail = brokenLinkHostsQuery
+	db := sql.OpenDB(seed)
+	defer db.Close()
+
+	_, err := analysisStats(context.Background(), db, 5)
+	assert.ErrorContains(t, err, "failed to count broken link hosts")
+}
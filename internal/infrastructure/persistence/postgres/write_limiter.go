@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"webpage-analyzer/internal/domain/repositories"
+
+	"github.com/lib/pq"
+)
+
+// insufficientResources is the Postgres error class for server-side
+// resource exhaustion, including too_many_connections (53300).
+const insufficientResources pq.ErrorClass = "53"
+
+// writeLimiter bounds how many writes hold a connection at once, so a burst
+// of job updates queues here instead of draining the pool that reads also
+// need. A nil writeLimiter lets every write through.
+type writeLimiter struct {
+	slots chan struct{}
+	wait  time.Duration
+}
+
+// newWriteLimiter allows limit concurrent writes, each waiting at most wait
+// for a slot. limit <= 0 disables the limiter; wait <= 0 waits as long as
+// the caller's context allows.
+func newWriteLimiter(limit int, wait time.Duration) *writeLimiter {
+	if limit <= 0 {
+		return nil
+	}
+	return &writeLimiter{slots: make(chan struct{}, limit), wait: wait}
+}
+
+// do runs write once a slot is free. It returns ErrDatabaseBusy if no slot
+// frees up within the wait, or if the server reports it is out of
+// connections.
+func (l *writeLimiter) do(ctx context.Context, write func() error) error {
+	if l == nil {
+		return busyError(write())
+	}
+
+	waitCtx := ctx
+	if l.wait > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, l.wait)
+		defer cancel()
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+	case <-waitCtx.Done():
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return fmt.Errorf("timed out after %s waiting for a write slot: %w", l.wait, repositories.ErrDatabaseBusy)
+	}
+	defer func() { <-l.slots }()
+
+	return busyError(write())
+}
+
+// busyError marks server-side connection exhaustion as ErrDatabaseBusy so
+// callers can tell it apart from permanent failures.
+func busyError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code.Class() == insufficientResources {
+		return fmt.Errorf("%w: %w", repositories.ErrDatabaseBusy, err)
+	}
+	return err
+}
@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"webpage-analyzer/internal/domain/entities"
+	"webpage-analyzer/internal/domain/repositories"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+// slowUpdateDB holds every UPDATE for a while and records the peak number
+// running at once.
+type slowUpdateDB struct {
+	delay    time.Duration
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (db *slowUpdateDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	n := db.inFlight.Add(1)
+	defer db.inFlight.Add(-1)
+	for {
+		peak := db.peak.Load()
+		if n <= peak || db.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(db.delay)
+	return driverResult(1), nil
+}
+
+func TestWriteLimiterThrottlesConcurrentUpdates(t *testing.T) {
+	db := &slowUpdateDB{delay: 5 * time.Millisecond}
+	limiter := newWriteLimiter(4, time.Second)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			analysis := entities.NewAnalysis("https://example.com", "user1", "corr1")
+			errs <- limiter.do(context.Background(), func() error {
+				return updateAnalysis(context.Background(), db, analysis)
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(4), db.peak.Load())
+}
+
+func TestWriteLimiterReportsBusyWhenNoSlotFrees(t *testing.T) {
+	db := &slowUpdateDB{delay: 200 * time.Millisecond}
+	limiter := newWriteLimiter(1, 20*time.Millisecond)
+
+	started := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- limiter.do(context.Background(), func() error {
+			close(started)
+			return updateAnalysis(context.Background(), db, entities.NewAnalysis("https://example.com", "user1", "corr1"))
+		})
+	}()
+	<-started
+
+	err := limiter.do(context.Background(), func() error {
+		t.Fatal("write ran without a free slot")
+		return nil
+	})
+	assert.ErrorIs(t, err, repositories.ErrDatabaseBusy)
+	assert.NoError(t, <-done)
+}
+
+func TestWriteLimiterPrefersCallerCancellation(t *testing.T) {
+	limiter := newWriteLimiter(1, time.Second)
+	limiter.slots <- struct{}{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := limiter.do(ctx, func() error { return nil })
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotErrorIs(t, err, repositories.ErrDatabaseBusy)
+}
+
+func TestWriteLimiterMarksConnectionExhaustionAsBusy(t *testing.T) {
+	tooMany := &pq.Error{Code: "53300", Message: "sorry, too many clients already"}
+
+	for _, limiter := range []*writeLimiter{nil, newWriteLimiter(2, time.Second)} {
+		err := limiter.do(context.Background(), func() error {
+			return tooMany
+		})
+		assert.ErrorIs(t, err, repositories.ErrDatabaseBusy)
+		assert.ErrorIs(t, err, tooMany)
+
+		other := errors.New("syntax error")
+		assert.Equal(t, other, limiter.do(context.Background(), func() error { return other }))
+	}
+}
@@ -3,19 +3,32 @@ package redis
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 	"webpage-analyzer/internal/domain/repositories"
 	"webpage-analyzer/pkg/config"
+	"webpage-analyzer/pkg/logger"
 
 	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
 )
 
+const startupPingTimeout = 2 * time.Second
+
 type cacheRepository struct {
 	client *redis.Client
+	prefix string
 }
 
-func NewCacheRepository(cfg *config.RedisConfig) repositories.CacheRepository {
+// NewCacheRepository connects to Redis and pings it once. Caching is
+// optional, so an unreachable Redis is logged as a warning instead of
+// failing startup; cache calls simply error until it comes back.
+func NewCacheRepository(cfg *config.RedisConfig, log logger.Logger) repositories.CacheRepository {
+	if log == nil {
+		log = logger.NewNop()
+	}
 	rdb := redis.NewClient(&redis.Options{
 		Addr:         fmt.Sprintf("%s:%s", cfg.Host, cfg.Port),
 		Password:     cfg.Password,
@@ -27,7 +40,33 @@ func NewCacheRepository(cfg *config.RedisConfig) repositories.CacheRepository {
 		WriteTimeout: cfg.WriteTimeout,
 	})
 
-	return &cacheRepository{client: rdb}
+	repo := &cacheRepository{client: rdb, prefix: cfg.KeyPrefix}
+
+	ctx, cancel := context.WithTimeout(context.Background(), startupPingTimeout)
+	defer cancel()
+	if err := repo.Ping(ctx); err != nil {
+		log.Warn("Redis is unreachable, continuing without cache",
+			zap.String("address", rdb.Options().Addr),
+			zap.Error(err),
+		)
+	}
+
+	return repo
+}
+
+var errKeyNotFound = errors.New("key not found")
+
+// key applies the configured namespace, e.g. "staging" + "analysis:x"
+// becomes "staging:analysis:x".
+func (r *cacheRepository) key(key string) string {
+	if r.prefix == "" {
+		return key
+	}
+	return strings.TrimSuffix(r.prefix, ":") + ":" + key
+}
+
+func (r *cacheRepository) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
 }
 
 func (r *cacheRepository) Set(ctx context.Context, key string, value interface{}, ttl int) error {
@@ -37,14 +76,14 @@ func (r *cacheRepository) Set(ctx context.Context, key string, value interface{}
 	}
 
 	duration := time.Duration(ttl) * time.Second
-	return r.client.Set(ctx, key, data, duration).Err()
+	return r.client.Set(ctx, r.key(key), data, duration).Err()
 }
 
 func (r *cacheRepository) Get(ctx context.Context, key string, dest interface{}) error {
-	data, err := r.client.Get(ctx, key).Result()
+	data, err := r.client.Get(ctx, r.key(key)).Result()
 	if err != nil {
 		if err == redis.Nil {
-			return fmt.Errorf("key not found")
+			return errKeyNotFound
 		}
 		return fmt.Errorf("failed to get value: %w", err)
 	}
@@ -53,10 +92,10 @@ func (r *cacheRepository) Get(ctx context.Context, key string, dest interface{})
 }
 
 func (r *cacheRepository) Delete(ctx context.Context, key string) error {
-	return r.client.Del(ctx, key).Err()
+	return r.client.Del(ctx, r.key(key)).Err()
 }
 
 func (r *cacheRepository) Exists(ctx context.Context, key string) (bool, error) {
-	count, err := r.client.Exists(ctx, key).Result()
+	count, err := r.client.Exists(ctx, r.key(key)).Result()
 	return count > 0, err
 }
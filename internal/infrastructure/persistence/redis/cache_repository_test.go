@@ -1,7 +1,11 @@
 package redis
 
 import (
+	"context"
 	"testing"
+	"time"
+	"webpage-analyzer/pkg/config"
+	"webpage-analyzer/pkg/logger"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -21,3 +25,31 @@ func TestCacheRepositoryMethods(t *testing.T) {
 	assert.NotNil(t, repo)
 	assert.True(t, true)
 }
+
+func TestNewCacheRepositoryUnreachable(t *testing.T) {
+	log, err := logger.New("error", false)
+	assert.NoError(t, err)
+
+	start := time.Now()
+	repo := NewCacheRepository(&config.RedisConfig{
+		Host:        "127.0.0.1",
+		Port:        "1",
+		DialTimeout: 200 * time.Millisecond,
+	}, log)
+
+	assert.NotNil(t, repo)
+	assert.Less(t, time.Since(start), 3*time.Second)
+
+	ctx := context.Background()
+	assert.Error(t, repo.Ping(ctx))
+	assert.Error(t, repo.Set(ctx, "key", "value", 60))
+
+	var dest string
+	assert.Error(t, repo.Get(ctx, "key", &dest))
+}
+
+func TestCacheKeyPrefix(t *testing.T) {
+	assert.Equal(t, "analysis:https://example.com", (&cacheRepository{}).key("analysis:https://example.com"))
+	assert.Equal(t, "staging:analysis:https://example.com", (&cacheRepository{prefix: "staging"}).key("analysis:https://example.com"))
+	assert.Equal(t, "staging:analysis:https://example.com", (&cacheRepository{prefix: "staging:"}).key("analysis:https://example.com"))
+}
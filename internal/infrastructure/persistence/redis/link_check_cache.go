@@ -0,0 +1,46 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+	"webpage-analyzer/internal/domain/repositories"
+	"webpage-analyzer/internal/domain/services"
+)
+
+// DefaultLinkCheckTTL applies when no shared link-check TTL is configured.
+const DefaultLinkCheckTTL = 10 * time.Minute
+
+type linkCheckCache struct {
+	cache repositories.CacheRepository
+	ttl   time.Duration
+}
+
+// NewLinkCheckCache stores link checks as cache entries that expire after
+// ttl, so every analyzer sharing the cache reuses them until then.
+func NewLinkCheckCache(cache repositories.CacheRepository, ttl time.Duration) services.LinkCheckCache {
+	if ttl <= 0 {
+		ttl = DefaultLinkCheckTTL
+	}
+	return &linkCheckCache{cache: cache, ttl: ttl}
+}
+
+// LinkCheckCacheKey is the cache key for a resolved link URL.
+func LinkCheckCacheKey(url string) string {
+	return "link_check:" + url
+}
+
+func (c *linkCheckCache) Get(ctx context.Context, url string) (services.LinkCheck, bool, error) {
+	var check services.LinkCheck
+	if err := c.cache.Get(ctx, LinkCheckCacheKey(url), &check); err != nil {
+		if errors.Is(err, errKeyNotFound) {
+			return services.LinkCheck{}, false, nil
+		}
+		return services.LinkCheck{}, false, err
+	}
+	return check, true, nil
+}
+
+func (c *linkCheckCache) Set(ctx context.Context, url string, check services.LinkCheck) error {
+	return c.cache.Set(ctx, LinkCheckCacheKey(url), check, int(c.ttl.Seconds()))
+}
@@ -0,0 +1,70 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+	"webpage-analyzer/internal/domain/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkCheckCacheRoundTrip(t *testing.T) {
+	cache := newMemoryCache()
+	links := NewLinkCheckCache(cache, 2*time.Minute)
+
+	_, found, err := links.Get(context.Background(), "https://example.com/a")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	check := services.LinkCheck{Accessible: false, StatusCode: http.StatusNotFound}
+	require.NoError(t, links.Set(context.Background(), "https://example.com/a", check))
+	assert.Equal(t, 120, cache.ttls[LinkCheckCacheKey("https://example.com/a")])
+
+	got, found, err := links.Get(context.Background(), "https://example.com/a")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, check, got)
+}
+
+func TestLinkCheckCacheDefaultTTL(t *testing.T) {
+	cache := newMemoryCache()
+	require.NoError(t, NewLinkCheckCache(cache, 0).Set(context.Background(), "https://example.com", services.LinkCheck{Accessible: true}))
+	assert.Equal(t, int(DefaultLinkCheckTTL.Seconds()), cache.ttls[LinkCheckCacheKey("https://example.com")])
+}
+
+func TestLinkCheckCacheReportsBackendErrors(t *testing.T) {
+	cache := newMemoryCache()
+	cache.err = errors.New("connection refused")
+
+	_, found, err := NewLinkCheckCache(cache, time.Minute).Get(context.Background(), "https://example.com")
+	assert.Error(t, err)
+	assert.False(t, found)
+}
+
+func TestLinkCheckCacheSharedAcrossParsers(t *testing.T) {
+	var checks atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/target" {
+			checks.Add(1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	links := NewLinkCheckCache(newMemoryCache(), time.Minute)
+	for i := 0; i < 2; i++ {
+		client := services.NewHTTPClient(services.NewSharedHTTPClient(5*time.Second, services.TransportConfig{}))
+		parser := services.NewHTMLParser(client)
+		parser.SetLinkCheckCache(links)
+
+		assert.True(t, parser.CheckLink(context.Background(), "/target", server.URL).Accessible)
+	}
+
+	assert.Equal(t, int32(1), checks.Load())
+}
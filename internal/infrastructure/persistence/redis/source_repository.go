@@ -0,0 +1,52 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"webpage-analyzer/internal/domain/entities"
+	"webpage-analyzer/internal/domain/repositories"
+
+	"github.com/google/uuid"
+)
+
+// DefaultSourceTTL applies when no source capture TTL is configured.
+const DefaultSourceTTL = time.Hour
+
+type sourceRepository struct {
+	cache repositories.CacheRepository
+	ttl   time.Duration
+}
+
+// NewSourceRepository stores captured sources as cache entries that expire
+// after ttl, so debugging captures never accumulate.
+func NewSourceRepository(cache repositories.CacheRepository, ttl time.Duration) repositories.SourceRepository {
+	if ttl <= 0 {
+		ttl = DefaultSourceTTL
+	}
+	return &sourceRepository{cache: cache, ttl: ttl}
+}
+
+// SourceCacheKey is the cache key for an analysis's captured source.
+func SourceCacheKey(analysisID uuid.UUID) string {
+	return "analysis_source:" + analysisID.String()
+}
+
+func (r *sourceRepository) SaveSource(ctx context.Context, source *entities.AnalysisSource) error {
+	if err := r.cache.Set(ctx, SourceCacheKey(source.AnalysisID), source, int(r.ttl.Seconds())); err != nil {
+		return fmt.Errorf("failed to save analysis source: %w", err)
+	}
+	return nil
+}
+
+func (r *sourceRepository) GetSource(ctx context.Context, analysisID uuid.UUID) (*entities.AnalysisSource, error) {
+	var source entities.AnalysisSource
+	if err := r.cache.Get(ctx, SourceCacheKey(analysisID), &source); err != nil {
+		if errors.Is(err, errKeyNotFound) {
+			return nil, repositories.ErrSourceNotFound
+		}
+		return nil, fmt.Errorf("failed to get analysis source: %w", err)
+	}
+	return &source, nil
+}
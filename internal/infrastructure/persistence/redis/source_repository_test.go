@@ -0,0 +1,92 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+	"webpage-analyzer/internal/domain/entities"
+	"webpage-analyzer/internal/domain/repositories"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryCache mimics cacheRepository's JSON round trip and miss error.
+type memoryCache struct {
+	repositories.CacheRepository
+	data map[string][]byte
+	ttls map[string]int
+	err  error
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{data: make(map[string][]byte), ttls: make(map[string]int)}
+}
+
+func (c *memoryCache) Set(ctx context.Context, key string, value interface{}, ttl int) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	c.data[key] = data
+	c.ttls[key] = ttl
+	return nil
+}
+
+func (c *memoryCache) Get(ctx context.Context, key string, dest interface{}) error {
+	if c.err != nil {
+		return c.err
+	}
+	data, ok := c.data[key]
+	if !ok {
+		return errKeyNotFound
+	}
+	return json.Unmarshal(data, dest)
+}
+
+func TestSourceRepositoryRoundTrip(t *testing.T) {
+	cache := newMemoryCache()
+	repo := NewSourceRepository(cache, 10*time.Minute)
+
+	source := &entities.AnalysisSource{
+		AnalysisID: uuid.New(),
+		URL:        "https://example.com",
+		Content:    []byte("<html><body>\xff raw bytes</body></html>"),
+		Truncated:  true,
+		CapturedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	assert.NoError(t, repo.SaveSource(context.Background(), source))
+	assert.Equal(t, 600, cache.ttls[SourceCacheKey(source.AnalysisID)])
+
+	got, err := repo.GetSource(context.Background(), source.AnalysisID)
+	assert.NoError(t, err)
+	assert.Equal(t, source, got)
+}
+
+func TestSourceRepositoryDefaultTTL(t *testing.T) {
+	cache := newMemoryCache()
+	repo := NewSourceRepository(cache, 0)
+
+	id := uuid.New()
+	assert.NoError(t, repo.SaveSource(context.Background(), &entities.AnalysisSource{AnalysisID: id}))
+	assert.Equal(t, int(DefaultSourceTTL.Seconds()), cache.ttls[SourceCacheKey(id)])
+}
+
+func TestSourceRepositoryNotFound(t *testing.T) {
+	repo := NewSourceRepository(newMemoryCache(), time.Minute)
+
+	_, err := repo.GetSource(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, repositories.ErrSourceNotFound)
+}
+
+func TestSourceRepositoryCacheError(t *testing.T) {
+	cache := newMemoryCache()
+	cache.err = errors.New("connection refused")
+	repo := NewSourceRepository(cache, time.Minute)
+
+	_, err := repo.GetSource(context.Background(), uuid.New())
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, repositories.ErrSourceNotFound)
+}
@@ -1,12 +1,21 @@
 package handlers
 
 import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 	"webpage-analyzer/internal/application/usecases"
 	"webpage-analyzer/internal/domain/entities"
 	"webpage-analyzer/internal/domain/repositories"
+	"webpage-analyzer/internal/domain/services"
 	"webpage-analyzer/pkg/logger"
+	"webpage-analyzer/pkg/version"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -14,118 +23,305 @@ import (
 )
 
 const (
-	DefaultUserID        = "anonymous"
+	DefaultUserID        = entities.AnonymousUserID
 	DefaultCorrelationID = "unknown"
+	MetadataQueryPrefix  = "meta."
+	RetryAfterSeconds    = 5
+	ContentTypeCSV       = "text/csv"
+	MaxBulkIDs           = 100
+	ReadinessPingTimeout = time.Second
+	// StatusClientClosedRequest is the non-standard 499 used when the
+	// client disconnects before a response is written.
+	StatusClientClosedRequest = 499
 )
 
+// listSortFields are the sort_by values GET /analyses accepts.
+var listSortFields = []string{"created_at", "updated_at", "status", "priority"}
+
 type AnalysisHandler struct {
 	analysisUC usecases.AnalysisUseCase
 	logger     logger.Logger
 }
 
+// AnalyzeRequest is the body of POST /analyze. Priority may be omitted to
+// inherit analysis.default_priority. CaptureSource keeps the fetched HTML
+// for GET /analysis/:id/source when source capture is configured.
 type AnalyzeRequest struct {
-	URL      string `json:"url" binding:"required"`
-	Priority int    `json:"priority,omitempty"`
-	Async    bool   `json:"async,omitempty"`
+	URL           string            `json:"url" binding:"required"`
+	Priority      int               `json:"priority,omitempty"`
+	Async         bool              `json:"async,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	CaptureSource bool              `json:"capture_source,omitempty"`
 }
 
 type AnalyzeResponse struct {
-	ID            string      `json:"id"`
-	AnalysisID    string      `json:"analysis_id,omitempty"`
-	URL           string      `json:"url"`
-	Status        string      `json:"status"`
-	Result        interface{} `json:"result,omitempty"`
-	Error         string      `json:"error,omitempty"`
-	CorrelationID string      `json:"correlation_id"`
+	ID            string            `json:"id"`
+	AnalysisID    string            `json:"analysis_id,omitempty"`
+	URL           string            `json:"url"`
+	Status        string            `json:"status"`
+	Result        interface{}       `json:"result,omitempty"`
+	Error         string            `json:"error,omitempty"`
+	CorrelationID string            `json:"correlation_id"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	RetryCount    int               `json:"retry_count"`
+	CreatedAt     *time.Time        `json:"created_at,omitempty"`
+	CompletedAt   *time.Time        `json:"completed_at,omitempty"`
+	// Reused is set when an async request was answered with a fresh
+	// existing analysis instead of a new job.
+	Reused bool `json:"reused,omitempty"`
+}
+
+type ValidateRequest struct {
+	URL string `json:"url"`
+}
+
+type ValidateResponse struct {
+	Valid  bool   `json:"valid"`
+	Reason string `json:"reason,omitempty"`
 }
 
-func NewAnalysisHandler(analysisUC usecases.AnalysisUseCase, logger logger.Logger) *AnalysisHandler {
+func NewAnalysisHandler(analysisUC usecases.AnalysisUseCase, log logger.Logger) *AnalysisHandler {
+	if log == nil {
+		log = logger.NewNop()
+	}
 	return &AnalysisHandler{
 		analysisUC: analysisUC,
-		logger:     logger,
+		logger:     log,
 	}
 }
 
 func (h *AnalysisHandler) AnalyzeURL(c *gin.Context) {
+	if req, ok := bindAnalyzeRequest(c); ok {
+		h.analyze(c, req)
+	}
+}
+
+// bindAnalyzeRequest decodes and validates a POST /analyze body, writing a
+// 400 response when it is unusable.
+func bindAnalyzeRequest(c *gin.Context) (AnalyzeRequest, bool) {
 	var req AnalyzeRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if !bindJSON(c, &req) {
+		return req, false
+	}
+
+	if err := entities.ValidateMetadata(req.Metadata); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid metadata",
+			"details": err.Error(),
+		})
+		return req, false
+	}
+
+	return req, true
+}
+
+// AnalyzeURLQuery runs a synchronous analysis for GET /analyze?url=..., so
+// results can be shared as plain links.
+func (h *AnalysisHandler) AnalyzeURLQuery(c *gin.Context) {
+	targetURL := strings.TrimSpace(c.Query("url"))
+	if targetURL == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Invalid request format",
+			"details": "url query parameter is required",
+		})
+		return
+	}
+
+	if err := h.analysisUC.ValidateURL(c.Request.Context(), targetURL); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrDomainNotAllowed) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{
+			"error":   "Invalid URL",
 			"details": err.Error(),
 		})
 		return
 	}
 
-	userID, ok := c.Request.Context().Value(string(logger.UserIDKey)).(string)
+	h.analyze(c, AnalyzeRequest{URL: targetURL})
+}
+
+func (h *AnalysisHandler) analyze(c *gin.Context, req AnalyzeRequest) {
+	job, analysis, correlationID, ok := h.runAnalysis(c, req)
+	if !ok {
+		return
+	}
+
+	if job != nil {
+		c.JSON(http.StatusAccepted, AnalyzeResponse{
+			ID:            job.ID.String(),
+			AnalysisID:    analysis.ID.String(),
+			URL:           req.URL,
+			Status:        "pending",
+			CorrelationID: correlationID,
+			Metadata:      analysis.Metadata,
+		})
+		return
+	}
+
+	response := newAnalyzeResponse(analysis)
+	response.CorrelationID = correlationID
+	response.Reused = req.Async
+	c.JSON(analysisResponseStatus(analysis), response)
+}
+
+// runAnalysis starts an async job or runs a sync analysis for req; job is
+// nil for sync runs and for async requests answered by a fresh existing
+// analysis. It writes the error response itself and returns false
+// when the analysis could not be started or failed outright.
+func (h *AnalysisHandler) runAnalysis(c *gin.Context, req AnalyzeRequest) (*entities.AnalysisJob, *entities.Analysis, string, bool) {
+	userID, ok := c.Request.Context().Value(logger.UserIDKey).(string)
 	if !ok {
 		userID = DefaultUserID
 	}
-	correlationID, ok := c.Request.Context().Value(string(logger.CorrelationIDKey)).(string)
+	correlationID, ok := c.Request.Context().Value(logger.CorrelationIDKey).(string)
 	if !ok {
 		correlationID = DefaultCorrelationID
 	}
 
 	log := h.logger.WithContext(c.Request.Context()).With(
-		zap.String(string(logger.URLKey), req.URL),
+		logger.URL(req.URL),
 		zap.Bool("async", req.Async),
 		zap.Int("priority", req.Priority),
 	)
 
+	ctx := c.Request.Context()
+	if req.CaptureSource {
+		ctx = usecases.WithSourceCapture(ctx)
+	}
+
 	if req.Async {
-		job, analysis, err := h.analysisUC.SubmitAnalysisJob(c.Request.Context(), req.URL, userID, req.Priority)
+		job, analysis, err := h.analysisUC.SubmitAnalysisJob(ctx, req.URL, userID, req.Priority, req.Metadata)
+		if errors.Is(err, usecases.ErrTooManyAnalyses) || errors.Is(err, repositories.ErrDatabaseBusy) {
+			h.respondBusy(c, correlationID)
+			return nil, nil, correlationID, false
+		}
+		if errors.Is(err, usecases.ErrUserLimitExceeded) {
+			h.respondUserLimited(c, correlationID)
+			return nil, nil, correlationID, false
+		}
 		if err != nil {
 			log.Error("Failed to submit analysis job", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, gin.H{
+			c.JSON(analysisErrorStatus(err), gin.H{
 				"error":          "Failed to submit analysis job",
 				"details":        err.Error(),
 				"correlation_id": correlationID,
 			})
-			return
+			return nil, nil, correlationID, false
 		}
 
-		c.JSON(http.StatusAccepted, AnalyzeResponse{
-			ID:            job.ID.String(),
-			AnalysisID:    analysis.ID.String(),
-			URL:           req.URL,
-			Status:        "pending",
-			CorrelationID: correlationID,
-		})
-	} else {
-		analysis, err := h.analysisUC.AnalyzeURL(c.Request.Context(), req.URL, userID)
-		if err != nil {
-			log.Error("Analysis failed", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":          "Analysis failed",
-				"details":        err.Error(),
-				"correlation_id": correlationID,
-			})
-			return
-		}
+		return job, analysis, correlationID, true
+	}
 
-		response := AnalyzeResponse{
-			ID:            analysis.ID.String(),
-			URL:           analysis.URL,
-			Status:        string(analysis.Status),
-			CorrelationID: correlationID,
-		}
+	analysis, err := h.analysisUC.AnalyzeURL(ctx, req.URL, userID, req.Metadata)
+	if errors.Is(err, context.Canceled) {
+		log.Info("Request cancelled by client")
+		c.AbortWithStatus(StatusClientClosedRequest)
+		return nil, nil, correlationID, false
+	}
+	if errors.Is(err, usecases.ErrTooManyAnalyses) || errors.Is(err, repositories.ErrDatabaseBusy) {
+		h.respondBusy(c, correlationID)
+		return nil, nil, correlationID, false
+	}
+	if errors.Is(err, usecases.ErrUserLimitExceeded) {
+		h.respondUserLimited(c, correlationID)
+		return nil, nil, correlationID, false
+	}
+	if err != nil {
+		log.Error("Analysis failed", zap.Error(err))
+		respondAnalysisFailed(c, err, correlationID)
+		return nil, nil, correlationID, false
+	}
 
-		if analysis.Result != nil {
-			response.Result = analysis.Result
-		}
+	return nil, analysis, correlationID, true
+}
 
-		if analysis.Error != "" {
-			response.Error = analysis.Error
-		}
+// respondAnalysisFailed writes the error response for a sync analysis that
+// failed, including the target's status code when it answered with an error.
+func respondAnalysisFailed(c *gin.Context, err error, correlationID string) {
+	body := gin.H{
+		"error":          "Analysis failed",
+		"details":        err.Error(),
+		"correlation_id": correlationID,
+	}
+	var statusErr *services.TargetStatusError
+	if errors.As(err, &statusErr) {
+		body["target_status_code"] = statusErr.StatusCode
+	}
+	c.JSON(analysisErrorStatus(err), body)
+}
 
-		statusCode := http.StatusOK
-		if analysis.Status == "failed" {
-			statusCode = http.StatusUnprocessableEntity
-		}
+// analysisResponseStatus is 422 for a sync analysis that completed as failed,
+// 499 for one that was cancelled and 410 for one that expired.
+func analysisResponseStatus(analysis *entities.Analysis) int {
+	switch analysis.Status {
+	case entities.StatusFailed:
+		return http.StatusUnprocessableEntity
+	case entities.StatusCancelled:
+		return StatusClientClosedRequest
+	case entities.StatusExpired:
+		return http.StatusGone
+	}
+	return http.StatusOK
+}
+
+// storedAnalysisStatus is 410 for an expired analysis, whose result will
+// never be available, and 200 otherwise; the body still describes it.
+func storedAnalysisStatus(analysis *entities.Analysis) int {
+	if analysis.Status == entities.StatusExpired {
+		return http.StatusGone
+	}
+	return http.StatusOK
+}
 
-		c.JSON(statusCode, response)
+// analysisErrorStatus maps analysis failures to HTTP status codes: bad input
+// is the client's fault, while problems reaching the target are reported as
+// gateway errors. Hosts outside the configured allowlist are forbidden, and
+// pages too large to parse are unprocessable.
+func analysisErrorStatus(err error) int {
+	var statusErr *services.TargetStatusError
+	var nodeLimitErr *services.NodeLimitExceededError
+
+	switch {
+	case errors.Is(err, services.ErrDomainNotAllowed):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrInvalidURL):
+		return http.StatusBadRequest
+	case errors.As(err, &nodeLimitErr):
+		return http.StatusUnprocessableEntity
+	case errors.As(err, &statusErr):
+		if statusErr.StatusCode >= 400 && statusErr.StatusCode < 500 {
+			return http.StatusUnprocessableEntity
+		}
+		return http.StatusBadGateway
+	case errors.Is(err, services.ErrTargetTimeout), errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, services.ErrTargetUnreachable):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
 	}
 }
 
+func (h *AnalysisHandler) respondUserLimited(c *gin.Context, correlationID string) {
+	c.Header("Retry-After", strconv.Itoa(RetryAfterSeconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":          "Too many concurrent analyses for user",
+		"details":        "Wait for your in-flight analyses to finish before starting more",
+		"correlation_id": correlationID,
+	})
+}
+
+func (h *AnalysisHandler) respondBusy(c *gin.Context, correlationID string) {
+	c.Header("Retry-After", strconv.Itoa(RetryAfterSeconds))
+	c.JSON(http.StatusServiceUnavailable, gin.H{
+		"error":          "Too many concurrent analyses",
+		"details":        "Server is at capacity, please retry later",
+		"correlation_id": correlationID,
+	})
+}
+
 func (h *AnalysisHandler) GetAnalysis(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -149,25 +345,66 @@ func (h *AnalysisHandler) GetAnalysis(c *gin.Context) {
 		return
 	}
 
-	response := AnalyzeResponse{
-		ID:            analysis.ID.String(),
-		URL:           analysis.URL,
-		Status:        string(analysis.Status),
-		CorrelationID: analysis.CorrelationID,
+	c.JSON(storedAnalysisStatus(analysis), newAnalyzeResponse(analysis))
+}
+
+func (h *AnalysisHandler) ListAnalyses(c *gin.Context) {
+	if idsParam := c.Query("ids"); idsParam != "" {
+		h.listAnalysesByIDs(c, idsParam)
+		return
 	}
 
-	if analysis.Result != nil {
-		response.Result = analysis.Result
+	filters, ok := parseListFilters(c)
+	if !ok {
+		return
 	}
 
-	if analysis.Error != "" {
-		response.Error = analysis.Error
+	if WantsCSV(c) {
+		h.exportAnalysesCSV(c, filters)
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	log := h.logger.WithContext(c.Request.Context()).With(
+		zap.String("status", string(filters.Status)),
+		zap.String("user_id", filters.UserID),
+		zap.Int("limit", filters.Limit),
+		zap.Int("offset", filters.Offset),
+	)
+
+	analyses, err := h.analysisUC.ListAnalyses(c.Request.Context(), filters)
+	if err != nil {
+		log.Error("Failed to list analyses", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve analyses",
+		})
+		return
+	}
+
+	responses := make([]AnalyzeResponse, len(analyses))
+	for i, analysis := range analyses {
+		responses[i] = newAnalyzeResponse(analysis)
+	}
+
+	body := gin.H{
+		"analyses": responses,
+		"total":    len(responses),
+		"limit":    filters.Limit,
+		"offset":   filters.Offset,
+	}
+
+	if filters.Cursor != nil {
+		delete(body, "offset")
+		if len(analyses) == filters.Limit {
+			body["next_cursor"] = repositories.NewListCursor(analyses[len(analyses)-1]).Encode()
+		}
+	}
+
+	c.JSON(http.StatusOK, body)
 }
 
-func (h *AnalysisHandler) ListAnalyses(c *gin.Context) {
+// parseListFilters reads the list query parameters shared by every API
+// version, writing a 400 response for an invalid cursor or metadata filter.
+func parseListFilters(c *gin.Context) (repositories.AnalysisFilters, bool) {
 	filters := repositories.AnalysisFilters{
 		Status: entities.AnalysisStatus(c.Query("status")),
 		UserID: c.Query("user_id"),
@@ -188,52 +425,335 @@ func (h *AnalysisHandler) ListAnalyses(c *gin.Context) {
 		}
 	}
 
-	log := h.logger.WithContext(c.Request.Context()).With(
-		zap.String("status", string(filters.Status)),
-		zap.String("user_id", filters.UserID),
-		zap.Int("limit", filters.Limit),
-		zap.Int("offset", filters.Offset),
-	)
+	if filters.Status != "" && !filters.Status.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid status filter",
+			"details": fmt.Sprintf("unknown status %q", filters.Status),
+		})
+		return filters, false
+	}
 
-	analyses, err := h.analysisUC.ListAnalyses(c.Request.Context(), filters)
+	if sortBy := c.Query("sort_by"); sortBy != "" {
+		if !slices.Contains(listSortFields, sortBy) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid sort_by",
+				"details": fmt.Sprintf("sort_by must be one of %v", listSortFields),
+			})
+			return filters, false
+		}
+		filters.SortBy = sortBy
+		filters.SortOrder = strings.ToLower(c.DefaultQuery("sort_order", "desc"))
+		if filters.SortOrder != "asc" && filters.SortOrder != "desc" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid sort_order",
+				"details": "sort_order must be asc or desc",
+			})
+			return filters, false
+		}
+	}
+
+	if cursorStr, ok := c.GetQuery("cursor"); ok {
+		cursor, err := repositories.DecodeListCursor(cursorStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid cursor",
+				"details": err.Error(),
+			})
+			return filters, false
+		}
+		filters.Cursor = cursor
+		filters.Offset = 0
+	}
+
+	for key, values := range c.Request.URL.Query() {
+		if !strings.HasPrefix(key, MetadataQueryPrefix) || len(values) == 0 {
+			continue
+		}
+		if filters.Metadata == nil {
+			filters.Metadata = make(map[string]string)
+		}
+		filters.Metadata[strings.TrimPrefix(key, MetadataQueryPrefix)] = values[0]
+	}
+
+	if err := entities.ValidateMetadata(filters.Metadata); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid metadata filter",
+			"details": err.Error(),
+		})
+		return filters, false
+	}
+
+	return filters, true
+}
+
+// listAnalysesByIDs serves GET /analyses?ids=a,b,c with a single repository
+// lookup. Results keep the requested order; unknown IDs are listed under
+// "missing".
+func (h *AnalysisHandler) listAnalysesByIDs(c *gin.Context, idsParam string) {
+	parts := strings.Split(idsParam, ",")
+	if len(parts) > MaxBulkIDs {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Too many IDs",
+			"max_ids": MaxBulkIDs,
+		})
+		return
+	}
+
+	ids := make([]uuid.UUID, 0, len(parts))
+	for _, part := range parts {
+		id, err := uuid.Parse(strings.TrimSpace(part))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid analysis ID format",
+				"id":    part,
+			})
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	analyses, err := h.analysisUC.GetAnalysesByIDs(c.Request.Context(), ids)
 	if err != nil {
-		log.Error("Failed to list analyses", zap.Error(err))
+		h.logger.WithContext(c.Request.Context()).Error("Failed to get analyses by IDs", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to retrieve analyses",
 		})
 		return
 	}
 
+	found := make(map[uuid.UUID]bool, len(analyses))
 	responses := make([]AnalyzeResponse, len(analyses))
 	for i, analysis := range analyses {
-		responses[i] = AnalyzeResponse{
-			ID:            analysis.ID.String(),
-			URL:           analysis.URL,
-			Status:        string(analysis.Status),
-			CorrelationID: analysis.CorrelationID,
-		}
-
-		if analysis.Result != nil {
-			responses[i].Result = analysis.Result
-		}
+		found[analysis.ID] = true
+		responses[i] = newAnalyzeResponse(analysis)
+	}
 
-		if analysis.Error != "" {
-			responses[i].Error = analysis.Error
+	missing := make([]string, 0)
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id.String())
 		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"analyses": responses,
 		"total":    len(responses),
-		"limit":    filters.Limit,
-		"offset":   filters.Offset,
+		"missing":  missing,
 	})
 }
 
+// newAnalyzeResponse maps an analysis to the shape shared by the get, list
+// and sync analyze endpoints.
+func newAnalyzeResponse(analysis *entities.Analysis) AnalyzeResponse {
+	response := AnalyzeResponse{
+		ID:            analysis.ID.String(),
+		URL:           analysis.URL,
+		Status:        string(analysis.Status),
+		CorrelationID: analysis.CorrelationID,
+		Metadata:      analysis.Metadata,
+		RetryCount:    analysis.RetryCount,
+		CompletedAt:   analysis.CompletedAt,
+	}
+
+	if !analysis.CreatedAt.IsZero() {
+		createdAt := analysis.CreatedAt
+		response.CreatedAt = &createdAt
+	}
+
+	if analysis.Result != nil {
+		response.Result = analysis.Result
+	}
+
+	if analysis.Error != "" {
+		response.Error = analysis.Error
+	}
+
+	return response
+}
+
+func (h *AnalysisHandler) ValidateURL(c *gin.Context) {
+	targetURL := c.Query("url")
+	if c.Request.Method == http.MethodPost {
+		var req ValidateRequest
+		if !bindJSON(c, &req) {
+			return
+		}
+		targetURL = req.URL
+	}
+
+	if err := h.analysisUC.ValidateURL(c.Request.Context(), targetURL); err != nil {
+		c.JSON(http.StatusOK, ValidateResponse{
+			Valid:  false,
+			Reason: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, ValidateResponse{Valid: true})
+}
+
+// WantsCSV reports whether a listing asks for a CSV export, by format query
+// parameter or Accept header.
+func WantsCSV(c *gin.Context) bool {
+	if format := c.Query("format"); format != "" {
+		return strings.EqualFold(format, "csv")
+	}
+	return strings.Contains(c.GetHeader("Accept"), ContentTypeCSV)
+}
+
+// csvExportPageSize is how many analyses a CSV export reads per query.
+const csvExportPageSize = 500
+
+// exportAnalysesCSV streams every analysis matching filters, paging through
+// the repository by cursor so large exports are neither capped by the list
+// limit nor buffered in memory. It starts at the request's cursor, if any,
+// and stops at the first empty page, since the repository may clamp each
+// page below csvExportPageSize.
+func (h *AnalysisHandler) exportAnalysesCSV(c *gin.Context, filters repositories.AnalysisFilters) {
+	log := h.logger.WithContext(c.Request.Context())
+
+	filters.Limit = csvExportPageSize
+	filters.Offset = 0
+	if filters.Cursor == nil {
+		filters.Cursor = &repositories.ListCursor{}
+	}
+
+	analyses, err := h.analysisUC.ListAnalyses(c.Request.Context(), filters)
+	if err != nil {
+		log.Error("Failed to list analyses", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve analyses",
+		})
+		return
+	}
+
+	c.Header("Content-Type", ContentTypeCSV+"; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="analyses.csv"`)
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	_ = writer.Write([]string{"id", "url", "status", "created_at", "title", "internal_links", "external_links", "inaccessible_links"})
+
+	for len(analyses) > 0 {
+		for _, analysis := range analyses {
+			if err := writer.Write(analysisCSVRow(analysis)); err != nil {
+				log.Error("Failed to write CSV row", zap.Error(err))
+				return
+			}
+		}
+		writer.Flush()
+
+		// the status line is already sent, so a failure can only cut the
+		// file short
+		filters.Cursor = repositories.NewListCursor(analyses[len(analyses)-1])
+		if analyses, err = h.analysisUC.ListAnalyses(c.Request.Context(), filters); err != nil {
+			log.Error("Failed to list analyses for CSV export", zap.Error(err))
+			return
+		}
+	}
+	writer.Flush()
+}
+
+func analysisCSVRow(analysis *entities.Analysis) []string {
+	var title, internal, external, inaccessible string
+	if analysis.Result != nil {
+		title = analysis.Result.Title
+		internal = strconv.Itoa(analysis.Result.Links.Internal)
+		external = strconv.Itoa(analysis.Result.Links.External)
+		inaccessible = strconv.Itoa(analysis.Result.Links.Inaccessible)
+	}
+
+	return []string{
+		analysis.ID.String(),
+		csvText(analysis.URL),
+		string(analysis.Status),
+		analysis.CreatedAt.UTC().Format(time.RFC3339),
+		csvText(title),
+		internal,
+		external,
+		inaccessible,
+	}
+}
+
+// csvText neutralises text taken from analysed pages so spreadsheets do not
+// evaluate it as a formula, e.g. a title of =HYPERLINK(...).
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// Pinger is implemented by dependencies checked for readiness.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// PingerFunc adapts a function such as (*sql.DB).PingContext to Pinger.
+type PingerFunc func(ctx context.Context) error
+
+func (f PingerFunc) Ping(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Readiness states. Degraded still serves traffic, just without an optional
+// dependency such as the cache.
+const (
+	ReadinessReady    = "ready"
+	ReadinessDegraded = "degraded"
+	ReadinessDown     = "down"
+)
+
+// ReadinessCheck reports the database and cache states. The database is
+// required, so an unreachable database fails the check with 503; the cache
+// is optional, so an unreachable cache only degrades it. A nil Pinger is
+// not checked.
+func ReadinessCheck(database, cache Pinger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body := gin.H{"status": ReadinessReady}
+		status := http.StatusOK
+
+		if database != nil {
+			body["database"] = pingDependency(c.Request.Context(), database)
+			if body["database"] != "ok" {
+				body["status"] = ReadinessDown
+				status = http.StatusServiceUnavailable
+			}
+		}
+
+		if cache != nil {
+			body["cache"] = pingDependency(c.Request.Context(), cache)
+			if body["cache"] != "ok" && status == http.StatusOK {
+				body["status"] = ReadinessDegraded
+			}
+		}
+
+		c.JSON(status, body)
+	}
+}
+
+// pingDependency is "ok" or "unavailable", bounded by ReadinessPingTimeout.
+func pingDependency(ctx context.Context, dependency Pinger) string {
+	ctx, cancel := context.WithTimeout(ctx, ReadinessPingTimeout)
+	defer cancel()
+
+	if err := dependency.Ping(ctx); err != nil {
+		return "unavailable"
+	}
+	return "ok"
+}
+
 func (h *AnalysisHandler) HealthCheck(c *gin.Context) {
+	uptime := version.Uptime()
+
 	c.JSON(http.StatusOK, gin.H{
-		"status":    "healthy",
-		"service":   "webpage-analyzer",
-		"timestamp": c.Request.Context().Value("timestamp"),
+		"status":         "healthy",
+		"service":        "webpage-analyzer",
+		"version":        version.Version,
+		"commit":         version.Commit,
+		"start_time":     version.StartTime().UTC().Format(time.RFC3339),
+		"uptime":         uptime.Round(time.Second).String(),
+		"uptime_seconds": uptime.Seconds(),
+		"timestamp":      time.Now().UTC().Format(time.RFC3339),
 	})
 }
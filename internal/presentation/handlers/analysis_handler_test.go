@@ -2,12 +2,27 @@ package handlers
 
 import (
 	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+	"webpage-analyzer/internal/application/usecases"
+	"webpage-analyzer/internal/domain/entities"
+	"webpage-analyzer/internal/domain/repositories"
+	"webpage-analyzer/internal/domain/services"
+	"webpage-analyzer/pkg/logger"
+	"webpage-analyzer/pkg/version"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -172,3 +187,780 @@ func TestAnalyzeURLHandlerWithEmptyURL(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
+
+// newValidateRouter serves the handlers on a real use case, so validation
+// runs the analyzer's checks.
+func newValidateRouter() *gin.Engine {
+	httpClient := services.NewHTTPClient(services.NewSharedHTTPClient(services.DefaultRequestTimeout, services.TransportConfig{}))
+	parser := services.NewHTMLParser(httpClient)
+	analyzer := services.NewAnalyzerService(httpClient, parser, &services.AnalyzerConfig{
+		LinkCheckTimeout:        5 * time.Second,
+		MaxLinksToCheck:         50,
+		MaxConcurrentLinkChecks: 10,
+		MaxHTMLDepth:            100,
+		MaxURLLength:            64,
+	})
+	return newStubRouter(usecases.NewAnalysisUseCase(nil, nil, analyzer, logger.NewNop(), 300, nil))
+}
+
+func TestValidateURLHandlerValid(t *testing.T) {
+	router := newValidateRouter()
+
+	jsonBody, _ := json.Marshal(map[string]string{"url": "https://example.com"})
+	req := httptest.NewRequest("POST", "/validate", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp ValidateResponse
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.True(t, resp.Valid)
+	assert.Empty(t, resp.Reason)
+}
+
+func TestValidateURLHandlerGetQuery(t *testing.T) {
+	router := newValidateRouter()
+
+	req := httptest.NewRequest("GET", "/validate?url="+url.QueryEscape("https://example.com/path"), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp ValidateResponse
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.True(t, resp.Valid)
+}
+
+func TestValidateURLHandlerInvalid(t *testing.T) {
+	router := newValidateRouter()
+
+	tests := []struct {
+		name   string
+		url    string
+		reason string
+	}{
+		{"empty", "", "URL cannot be empty"},
+		{"too long", "https://example.com/" + strings.Repeat("a", 64), "URL too long"},
+		{"bad scheme", "ftp://example.com", "schemes are supported"},
+		{"no host", "https://", "URL must include scheme and host"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			jsonBody, _ := json.Marshal(map[string]string{"url": test.url})
+			req := httptest.NewRequest("POST", "/validate", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			var resp ValidateResponse
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.False(t, resp.Valid)
+			assert.Contains(t, resp.Reason, test.reason)
+		})
+	}
+}
+
+func TestHealthCheckReportsVersionAndUptime(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewAnalysisHandler(nil, nil)
+
+	router := gin.New()
+	router.GET("/health", handler.HealthCheck)
+
+	getHealth := func() map[string]interface{} {
+		req := httptest.NewRequest("GET", "/health", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var body map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body
+	}
+
+	first := getHealth()
+	for _, field := range []string{"status", "version", "commit", "start_time", "uptime", "uptime_seconds", "timestamp"} {
+		assert.Contains(t, first, field)
+	}
+	assert.Equal(t, version.Version, first["version"])
+	assert.NotNil(t, first["timestamp"])
+
+	time.Sleep(5 * time.Millisecond)
+	second := getHealth()
+	assert.Greater(t, second["uptime_seconds"].(float64), first["uptime_seconds"].(float64))
+}
+
+// stubAnalysisUseCase is the use case behind the handler tests: it serves
+// analyses, sources, stats and monitors from its fields and records what the
+// handlers asked for. Tests needing other behaviour embed it.
+type stubAnalysisUseCase struct {
+	usecases.AnalysisUseCase
+	analyzeErr error
+	analyses   []*entities.Analysis
+	// reused, when set, answers async submissions without a job.
+	reused    *entities.Analysis
+	retryErr  error
+	source    *entities.AnalysisSource
+	sourceErr error
+	stats     *entities.AnalysisStats
+	statsErr  error
+	monitors  map[uuid.UUID]*entities.MonitoredURL
+
+	// recorded from the last call
+	async         bool
+	captureSource bool
+}
+
+func (s *stubAnalysisUseCase) AnalyzeURL(ctx context.Context, url, userID string, metadata map[string]string) (*entities.Analysis, error) {
+	s.captureSource = usecases.SourceCaptureRequested(ctx)
+	if s.analyzeErr != nil {
+		return nil, s.analyzeErr
+	}
+	analysis := entities.NewAnalysis(url, userID, "test-correlation-id")
+	analysis.MarkAsCompleted(&entities.AnalysisResult{Title: "Test Page", StatusCode: http.StatusOK})
+	return analysis, nil
+}
+
+func (s *stubAnalysisUseCase) SubmitAnalysisJob(ctx context.Context, url, userID string, priority int, metadata map[string]string) (*entities.AnalysisJob, *entities.Analysis, error) {
+	if s.analyzeErr != nil {
+		return nil, nil, s.analyzeErr
+	}
+	if s.reused != nil {
+		return nil, s.reused, nil
+	}
+	return entities.NewAnalysisJob(url, userID, "test-correlation-id", priority), entities.NewAnalysis(url, userID, "test-correlation-id"), nil
+}
+
+func (s *stubAnalysisUseCase) RetryAnalysis(ctx context.Context, analysis *entities.Analysis, async bool) (*entities.Analysis, error) {
+	s.async = async
+	if s.retryErr != nil {
+		return nil, s.retryErr
+	}
+	retried := *analysis
+	retried.MarkAsRetrying()
+	if !async {
+		retried.MarkAsCompleted(&entities.AnalysisResult{Title: "Test Page", StatusCode: http.StatusOK})
+	}
+	return &retried, nil
+}
+
+func (s *stubAnalysisUseCase) GetAnalysisSource(ctx context.Context, id uuid.UUID) (*entities.AnalysisSource, error) {
+	if s.sourceErr != nil {
+		return nil, s.sourceErr
+	}
+	if s.source == nil || s.source.AnalysisID != id {
+		return nil, fmt.Errorf("failed to get analysis source: %w", repositories.ErrSourceNotFound)
+	}
+	return s.source, nil
+}
+
+func (s *stubAnalysisUseCase) GetStats(ctx context.Context) (*entities.AnalysisStats, error) {
+	return s.stats, s.statsErr
+}
+
+func (s *stubAnalysisUseCase) CreateMonitor(ctx context.Context, url, userID string, interval time.Duration) (*entities.MonitoredURL, error) {
+	if interval < time.Hour {
+		return nil, fmt.Errorf("%w: minimum is 1h0m0s", usecases.ErrInvalidMonitorInterval)
+	}
+	if url == "not-a-url" {
+		return nil, fmt.Errorf("invalid URL: %w", services.ErrInvalidURL)
+	}
+	if s.monitors == nil {
+		s.monitors = make(map[uuid.UUID]*entities.MonitoredURL)
+	}
+	monitor := entities.NewMonitoredURL(url, userID, interval)
+	s.monitors[monitor.ID] = monitor
+	return monitor, nil
+}
+
+func (s *stubAnalysisUseCase) GetMonitor(ctx context.Context, id uuid.UUID) (*entities.MonitoredURL, error) {
+	monitor, ok := s.monitors[id]
+	if !ok {
+		return nil, fmt.Errorf("failed to get monitor: %w", repositories.ErrMonitorNotFound)
+	}
+	return monitor, nil
+}
+
+// newStubRouter serves every handler route on uc at the paths the tests use.
+// Monitor routes are added when uc also implements usecases.MonitorUseCase.
+func newStubRouter(uc usecases.AnalysisUseCase) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := NewAnalysisHandler(uc, logger.NewNop())
+
+	router := gin.New()
+	router.POST("/analyze", handler.AnalyzeURL)
+	router.GET("/analyze", handler.AnalyzeURLQuery)
+	router.GET("/analysis/:id", handler.GetAnalysis)
+	router.POST("/analysis/:id/retry", handler.RetryAnalysis)
+	router.GET("/analysis/:id/report", handler.GetAnalysisReport)
+	router.GET("/analysis/:id/source", handler.GetAnalysisSource)
+	router.GET("/analyses", handler.ListAnalyses)
+	router.GET("/stats", handler.GetStats)
+	router.POST("/validate", handler.ValidateURL)
+	router.GET("/validate", handler.ValidateURL)
+	router.POST("/api/v2/analyze", handler.AnalyzeURLV2)
+	router.GET("/api/v2/analysis/:id", handler.GetAnalysisV2)
+	router.GET("/api/v2/analyses", handler.ListAnalysesV2)
+
+	if monitors, ok := uc.(usecases.MonitorUseCase); ok {
+		monitorHandler := NewMonitorHandler(monitors, logger.NewNop())
+		router.POST("/monitors", monitorHandler.CreateMonitor)
+		router.GET("/monitors/:id", monitorHandler.GetMonitor)
+	}
+	return router
+}
+
+func TestAnalyzeURLHandlerAtCapacity(t *testing.T) {
+	router := newStubRouter(&stubAnalysisUseCase{analyzeErr: usecases.ErrTooManyAnalyses})
+
+	for _, async := range []bool{false, true} {
+		jsonBody, _ := json.Marshal(map[string]interface{}{"url": "https://example.com", "async": async})
+		req := httptest.NewRequest("POST", "/analyze", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, strconv.Itoa(RetryAfterSeconds), w.Header().Get("Retry-After"))
+	}
+}
+
+func TestAnalyzeURLHandlerUserLimit(t *testing.T) {
+	router := newStubRouter(&stubAnalysisUseCase{analyzeErr: usecases.ErrUserLimitExceeded})
+
+	for _, async := range []bool{false, true} {
+		jsonBody, _ := json.Marshal(map[string]interface{}{"url": "https://example.com", "async": async})
+		req := httptest.NewRequest("POST", "/analyze", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, strconv.Itoa(RetryAfterSeconds), w.Header().Get("Retry-After"))
+	}
+}
+
+// ListAnalyses returns every analysis as a single page; a cursor past the
+// start reads the empty page after it.
+func (s *stubAnalysisUseCase) ListAnalyses(ctx context.Context, filters repositories.AnalysisFilters) ([]*entities.Analysis, error) {
+	if filters.Cursor != nil && !filters.Cursor.IsStart() {
+		return nil, nil
+	}
+	return s.analyses, nil
+}
+
+func TestListAnalysesCSV(t *testing.T) {
+	completed := entities.NewAnalysis("https://example.com", "user1", "corr1")
+	completed.MarkAsCompleted(&entities.AnalysisResult{
+		Title: `Title, with "quotes"`,
+		Links: entities.LinkAnalysis{Internal: 3, External: 2, Inaccessible: 1},
+	})
+	pending := entities.NewAnalysis("https://example.org", "user1", "corr2")
+
+	uc := &stubAnalysisUseCase{analyses: []*entities.Analysis{completed, pending}}
+	router := newStubRouter(uc)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest("GET", "/analyses?format=csv", nil),
+		func() *http.Request {
+			r := httptest.NewRequest("GET", "/analyses", nil)
+			r.Header.Set("Accept", "text/csv")
+			return r
+		}(),
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/csv")
+
+		records, err := csv.NewReader(w.Body).ReadAll()
+		assert.NoError(t, err)
+		assert.Len(t, records, 3)
+		assert.Equal(t, []string{"id", "url", "status", "created_at", "title", "internal_links", "external_links", "inaccessible_links"}, records[0])
+		assert.Equal(t, []string{completed.ID.String(), "https://example.com", "completed"}, records[1][:3])
+		assert.Equal(t, []string{`Title, with "quotes"`, "3", "2", "1"}, records[1][4:])
+		assert.Equal(t, []string{"", "", "", ""}, records[2][4:])
+	}
+}
+
+func TestListAnalysesCSVNeutralisesFormulas(t *testing.T) {
+	analysis := entities.NewAnalysis("https://example.com", "user1", "corr1")
+	analysis.MarkAsCompleted(&entities.AnalysisResult{Title: `=HYPERLINK("https://evil.example","click")`})
+	uc := &stubAnalysisUseCase{analyses: []*entities.Analysis{analysis}}
+	router := newStubRouter(uc)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/analyses?format=csv", nil))
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	assert.NoError(t, err)
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, `'=HYPERLINK("https://evil.example","click")`, records[1][4])
+	}
+
+	for _, value := range []string{"+1", "-1", "@SUM(A1)", "\tx", "\rx"} {
+		assert.Equal(t, "'"+value, csvText(value))
+	}
+	assert.Equal(t, "Plain title", csvText("Plain title"))
+}
+
+// pagingStubUseCase serves ListAnalyses by cursor, newest first, like the
+// repository does, clamping each page to maxLimit when it is set.
+type pagingStubUseCase struct {
+	stubAnalysisUseCase
+	maxLimit int
+	calls    int
+}
+
+func (s *pagingStubUseCase) ListAnalyses(ctx context.Context, filters repositories.AnalysisFilters) ([]*entities.Analysis, error) {
+	s.calls++
+	start := 0
+	if filters.Cursor != nil && !filters.Cursor.IsStart() {
+		for i, analysis := range s.analyses {
+			if analysis.ID == filters.Cursor.ID {
+				start = i + 1
+			}
+		}
+	}
+	limit := filters.Limit
+	if s.maxLimit > 0 {
+		limit = min(limit, s.maxLimit)
+	}
+	end := min(start+limit, len(s.analyses))
+	return s.analyses[start:end], nil
+}
+
+func TestListAnalysesCSVExportsEveryPage(t *testing.T) {
+	tests := []struct {
+		name      string
+		total     int
+		maxLimit  int
+		wantCalls int
+	}{
+		{"full pages", 2*csvExportPageSize + 1, 0, 4},
+		{"pages clamped below the export size", 250, 100, 4},
+		{"no analyses", 0, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &pagingStubUseCase{maxLimit: tt.maxLimit}
+			for i := 0; i < tt.total; i++ {
+				uc.analyses = append(uc.analyses, entities.NewAnalysis(fmt.Sprintf("https://example.com/%d", i), "user1", "corr"))
+			}
+			router := newStubRouter(uc)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/analyses?format=csv&limit=10", nil))
+
+			records, err := csv.NewReader(w.Body).ReadAll()
+			assert.NoError(t, err)
+			assert.Len(t, records, tt.total+1)
+			assert.Equal(t, tt.wantCalls, uc.calls)
+		})
+	}
+}
+
+type filterRecordingUseCase struct {
+	stubAnalysisUseCase
+	filters repositories.AnalysisFilters
+}
+
+func (s *filterRecordingUseCase) ListAnalyses(ctx context.Context, filters repositories.AnalysisFilters) ([]*entities.Analysis, error) {
+	s.filters = filters
+	return nil, nil
+}
+
+func TestListAnalysesSortBy(t *testing.T) {
+	tests := []struct {
+		query     string
+		wantCode  int
+		wantSort  string
+		wantOrder string
+	}{
+		{"sort_by=priority", http.StatusOK, "priority", "desc"},
+		{"sort_by=priority&sort_order=ASC", http.StatusOK, "priority", "asc"},
+		{"", http.StatusOK, "", ""},
+		{"sort_by=title", http.StatusBadRequest, "", ""},
+		{"sort_by=priority&sort_order=up", http.StatusBadRequest, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			uc := &filterRecordingUseCase{}
+			router := newStubRouter(uc)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/analyses?"+tt.query, nil))
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantSort, uc.filters.SortBy)
+			assert.Equal(t, tt.wantOrder, uc.filters.SortOrder)
+		})
+	}
+}
+
+func TestListAnalysesDefaultsToJSON(t *testing.T) {
+	uc := &stubAnalysisUseCase{analyses: []*entities.Analysis{entities.NewAnalysis("https://example.com", "user1", "corr1")}}
+	router := newStubRouter(uc)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/analyses", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+}
+
+func (s *stubAnalysisUseCase) ValidateURL(ctx context.Context, url string) error {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return fmt.Errorf("only [http https] schemes are supported")
+	}
+	return nil
+}
+
+func TestAnalyzeURLQueryMatchesPost(t *testing.T) {
+	router := newStubRouter(&stubAnalysisUseCase{})
+	target := "https://example.com/path?a=1&b=2"
+
+	jsonBody, _ := json.Marshal(map[string]string{"url": target})
+	postReq := httptest.NewRequest("POST", "/analyze", bytes.NewBuffer(jsonBody))
+	postReq.Header.Set("Content-Type", "application/json")
+	postW := httptest.NewRecorder()
+	router.ServeHTTP(postW, postReq)
+
+	getW := httptest.NewRecorder()
+	router.ServeHTTP(getW, httptest.NewRequest("GET", "/analyze?url="+url.QueryEscape(target), nil))
+
+	var postResp, getResp AnalyzeResponse
+	assert.Equal(t, http.StatusOK, postW.Code)
+	assert.Equal(t, http.StatusOK, getW.Code)
+	assert.NoError(t, json.Unmarshal(postW.Body.Bytes(), &postResp))
+	assert.NoError(t, json.Unmarshal(getW.Body.Bytes(), &getResp))
+
+	assert.Equal(t, target, getResp.URL)
+	assert.Equal(t, postResp.URL, getResp.URL)
+	assert.Equal(t, postResp.Status, getResp.Status)
+	assert.Equal(t, postResp.Result, getResp.Result)
+}
+
+func TestAnalyzeURLQueryInvalid(t *testing.T) {
+	router := newStubRouter(&stubAnalysisUseCase{})
+
+	for _, target := range []string{"/analyze", "/analyze?url=", "/analyze?url=" + url.QueryEscape("ftp://example.com")} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, target)
+	}
+}
+
+func TestAnalyzeURLDomainNotAllowed(t *testing.T) {
+	router := newStubRouter(&stubAnalysisUseCase{
+		analyzeErr: fmt.Errorf("%w: host example.net is not in the allowed domains", services.ErrDomainNotAllowed),
+	})
+
+	body, _ := json.Marshal(map[string]string{"url": "https://example.net"})
+	req := httptest.NewRequest("POST", "/analyze", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func (s *stubAnalysisUseCase) GetAnalysesByIDs(ctx context.Context, ids []uuid.UUID) ([]*entities.Analysis, error) {
+	byID := make(map[uuid.UUID]*entities.Analysis)
+	for _, analysis := range s.analyses {
+		byID[analysis.ID] = analysis
+	}
+	found := make([]*entities.Analysis, 0, len(ids))
+	for _, id := range ids {
+		if analysis, ok := byID[id]; ok {
+			found = append(found, analysis)
+		}
+	}
+	return found, nil
+}
+
+func TestListAnalysesByIDs(t *testing.T) {
+	first := entities.NewAnalysis("https://example.com", "user1", "corr1")
+	second := entities.NewAnalysis("https://example.org", "user1", "corr2")
+	missing := uuid.New()
+
+	router := newStubRouter(&stubAnalysisUseCase{analyses: []*entities.Analysis{first, second}})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/analyses?ids="+second.ID.String()+","+missing.String()+","+first.ID.String(), nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var body struct {
+		Analyses []AnalyzeResponse `json:"analyses"`
+		Total    int               `json:"total"`
+		Missing  []string          `json:"missing"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, 2, body.Total)
+	assert.Equal(t, second.ID.String(), body.Analyses[0].ID)
+	assert.Equal(t, first.ID.String(), body.Analyses[1].ID)
+	assert.Equal(t, []string{missing.String()}, body.Missing)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/analyses?ids=not-a-uuid", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func (s *stubAnalysisUseCase) GetAnalysis(ctx context.Context, id uuid.UUID) (*entities.Analysis, error) {
+	for _, analysis := range s.analyses {
+		if analysis.ID == id {
+			return analysis, nil
+		}
+	}
+	return nil, fmt.Errorf("analysis not found")
+}
+
+func TestResponsesIncludeTimestampsAndRetryCount(t *testing.T) {
+	analysis := entities.NewAnalysis("https://example.com", "user1", "corr1")
+	analysis.RetryCount = 2
+	analysis.MarkAsCompleted(&entities.AnalysisResult{Title: "Test Page"})
+
+	router := newStubRouter(&stubAnalysisUseCase{analyses: []*entities.Analysis{analysis}})
+
+	assertFields := func(t *testing.T, raw json.RawMessage) {
+		var fields map[string]interface{}
+		assert.NoError(t, json.Unmarshal(raw, &fields))
+		assert.Contains(t, fields, "retry_count")
+		assert.Contains(t, fields, "created_at")
+		assert.Contains(t, fields, "completed_at")
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/analysis/"+analysis.ID.String(), nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assertFields(t, w.Body.Bytes())
+
+	var got AnalyzeResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, 2, got.RetryCount)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/analyses", nil))
+	var list struct {
+		Analyses []json.RawMessage `json:"analyses"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Len(t, list.Analyses, 1)
+	assertFields(t, list.Analyses[0])
+
+	req := httptest.NewRequest("POST", "/analyze", strings.NewReader(`{"url":"https://example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assertFields(t, w.Body.Bytes())
+}
+
+func TestAnalyzeURLMapsAnalysisErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"invalid url", fmt.Errorf("analysis failed: %w", services.ErrInvalidURL), http.StatusBadRequest},
+		{"target 404", fmt.Errorf("analysis failed: %w", &services.TargetStatusError{StatusCode: 404, Message: "Not Found"}), http.StatusUnprocessableEntity},
+		{"target 403", fmt.Errorf("analysis failed: %w", &services.TargetStatusError{StatusCode: 403, Message: "Forbidden"}), http.StatusUnprocessableEntity},
+		{"target 503", fmt.Errorf("analysis failed: %w", &services.TargetStatusError{StatusCode: 503, Message: "Service Unavailable"}), http.StatusBadGateway},
+		{"node limit", fmt.Errorf("analysis failed: failed to parse HTML: %w", &services.NodeLimitExceededError{Limit: 100}), http.StatusUnprocessableEntity},
+		{"unreachable", fmt.Errorf("analysis failed: %w", services.ErrTargetUnreachable), http.StatusBadGateway},
+		{"timeout", fmt.Errorf("analysis failed: %w", services.ErrTargetTimeout), http.StatusGatewayTimeout},
+		{"unknown", fmt.Errorf("failed to create analysis: boom"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newStubRouter(&stubAnalysisUseCase{analyzeErr: tt.err})
+
+			req := httptest.NewRequest("POST", "/analyze", strings.NewReader(`{"url":"https://example.com"}`))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expected, w.Code)
+		})
+	}
+}
+
+func TestAnalyzeURLIncludesTargetStatusCode(t *testing.T) {
+	router := newStubRouter(&stubAnalysisUseCase{
+		analyzeErr: fmt.Errorf("analysis failed: %w", &services.TargetStatusError{StatusCode: 404, Message: "Not Found"}),
+	})
+
+	req := httptest.NewRequest("POST", "/analyze", strings.NewReader(`{"url":"https://example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var body map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, float64(404), body["target_status_code"])
+}
+
+type stubPinger struct {
+	err error
+}
+
+func (p *stubPinger) Ping(ctx context.Context) error {
+	return p.err
+}
+
+func TestReadinessCheckStates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	up := &stubPinger{}
+	down := &stubPinger{err: fmt.Errorf("connection refused")}
+
+	for _, tt := range []struct {
+		name     string
+		database Pinger
+		cache    Pinger
+		code     int
+		expected map[string]string
+	}{
+		{"all up", up, up, http.StatusOK, map[string]string{"status": "ready", "database": "ok", "cache": "ok"}},
+		{"cache down", up, down, http.StatusOK, map[string]string{"status": "degraded", "database": "ok", "cache": "unavailable"}},
+		{"database down", down, up, http.StatusServiceUnavailable, map[string]string{"status": "down", "database": "unavailable", "cache": "ok"}},
+		{"all down", down, down, http.StatusServiceUnavailable, map[string]string{"status": "down", "database": "unavailable", "cache": "unavailable"}},
+		{"nothing configured", nil, nil, http.StatusOK, map[string]string{"status": "ready"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/health/ready", ReadinessCheck(tt.database, tt.cache))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/health/ready", nil))
+
+			assert.Equal(t, tt.code, w.Code)
+			var body map[string]string
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.expected, body)
+		})
+	}
+}
+
+func TestPingerFuncAdaptsPingContext(t *testing.T) {
+	var pinged bool
+	pinger := PingerFunc(func(ctx context.Context) error {
+		_, hasDeadline := ctx.Deadline()
+		pinged = hasDeadline
+		return nil
+	})
+
+	assert.Equal(t, "ok", pingDependency(context.Background(), pinger))
+	assert.True(t, pinged)
+}
+
+func TestHandlerWithNilLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewAnalysisHandler(&stubAnalysisUseCase{analyzeErr: errors.New("boom")}, nil)
+	router := gin.New()
+	router.POST("/analyze", handler.AnalyzeURL)
+
+	req := httptest.NewRequest("POST", "/analyze", strings.NewReader(`{"url":"https://example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	assert.NotPanics(t, func() { router.ServeHTTP(w, req) })
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestAnalysisResponseStatusByOutcome(t *testing.T) {
+	tests := []struct {
+		status   entities.AnalysisStatus
+		expected int
+	}{
+		{entities.StatusCompleted, http.StatusOK},
+		{entities.StatusFailed, http.StatusUnprocessableEntity},
+		{entities.StatusCancelled, StatusClientClosedRequest},
+		{entities.StatusExpired, http.StatusGone},
+	}
+
+	for _, tt := range tests {
+		analysis := entities.NewAnalysis("https://example.com", "user1", "corr1")
+		analysis.Status = tt.status
+		assert.Equal(t, tt.expected, analysisResponseStatus(analysis), tt.status)
+	}
+}
+
+func TestGetAnalysisExpiredIsGone(t *testing.T) {
+	expired := entities.NewAnalysis("https://example.com", "user1", "corr1")
+	expired.MarkAsExpired("not processed in time")
+	cancelled := entities.NewAnalysis("https://example.org", "user1", "corr2")
+	cancelled.MarkAsCancelled("request cancelled")
+
+	uc := &stubAnalysisUseCase{analyses: []*entities.Analysis{expired, cancelled}}
+	router := newStubRouter(uc)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/analysis/"+expired.ID.String(), nil))
+	assert.Equal(t, http.StatusGone, w.Code)
+
+	var response AnalyzeResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "expired", response.Status)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/analysis/"+cancelled.ID.String(), nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"cancelled"`)
+}
+
+func TestListAnalysesStatusFilter(t *testing.T) {
+	uc := &stubAnalysisUseCase{}
+	router := newStubRouter(uc)
+
+	for _, status := range []string{"cancelled", "expired", "completed"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/analyses?status="+status, nil))
+		assert.Equal(t, http.StatusOK, w.Code, status)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/analyses?status=archived", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid status filter")
+}
+
+func TestAnalyzeURLAsyncReusesFreshAnalysis(t *testing.T) {
+	existing := entities.NewAnalysis("https://example.com", "user1", "corr1")
+	existing.MarkAsCompleted(&entities.AnalysisResult{Title: "Earlier"})
+	uc := &stubAnalysisUseCase{reused: existing}
+	router := newStubRouter(uc)
+
+	jsonBody, _ := json.Marshal(map[string]interface{}{"url": "https://example.com", "async": true})
+	req := httptest.NewRequest("POST", "/analyze", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response AnalyzeResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, existing.ID.String(), response.ID)
+	assert.Equal(t, "completed", response.Status)
+	assert.True(t, response.Reused)
+
+	w = postV2Analyze(newStubRouter(uc), map[string]interface{}{"url": "https://example.com", "async": true})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var responseV2 AnalysisResponseV2
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &responseV2))
+	assert.Equal(t, existing.ID.String(), responseV2.ID)
+	assert.True(t, responseV2.Meta.Reused)
+	assert.Empty(t, responseV2.Meta.JobID)
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError explains why one field of a request body was rejected. Field
+// is the JSON name the client sent.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// bindJSON decodes the request body into dest, writing a 400 that names the
+// offending fields, or says the JSON is malformed, when it cannot.
+func bindJSON(c *gin.Context, dest interface{}) bool {
+	if err := c.ShouldBindJSON(dest); err != nil {
+		c.JSON(http.StatusBadRequest, bindingErrorBody(err, dest))
+		return false
+	}
+	return true
+}
+
+func bindingErrorBody(err error, dest interface{}) gin.H {
+	body := gin.H{"error": "Invalid request format"}
+
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &validationErrs):
+		fields := make([]FieldError, 0, len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			fields = append(fields, FieldError{
+				Field:   jsonFieldName(dest, fieldErr.StructField()),
+				Message: validationMessage(fieldErr),
+			})
+		}
+		body["details"] = "one or more fields are invalid"
+		body["fields"] = fields
+	case errors.As(err, &typeErr):
+		body["details"] = "a field has the wrong type"
+		body["fields"] = []FieldError{{
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("must be %s, got %s", jsonTypeName(typeErr.Type), typeErr.Value),
+		}}
+	case errors.Is(err, io.EOF):
+		body["details"] = "request body is empty"
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		body["details"] = "malformed JSON"
+	default:
+		body["details"] = err.Error()
+	}
+	return body
+}
+
+func validationMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		return "must be at least " + fieldErr.Param()
+	case "max", "lte":
+		return "must be at most " + fieldErr.Param()
+	case "oneof":
+		return "must be one of: " + fieldErr.Param()
+	default:
+		return fmt.Sprintf("failed the %q check", fieldErr.Tag())
+	}
+}
+
+// jsonFieldName maps a struct field of dest to its JSON key.
+func jsonFieldName(dest interface{}, structField string) string {
+	t := reflect.TypeOf(dest)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return structField
+	}
+	field, ok := t.FieldByName(structField)
+	if !ok {
+		return structField
+	}
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	return structField
+}
+
+// jsonTypeName describes a Go type the way a JSON client would.
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return t.String()
+	}
+}
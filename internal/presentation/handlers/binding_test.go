@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bindingErrorResponse struct {
+	Error   string       `json:"error"`
+	Details string       `json:"details"`
+	Fields  []FieldError `json:"fields"`
+}
+
+func postAnalyze(t *testing.T, body string) bindingErrorResponse {
+	router := newStubRouter(&stubAnalysisUseCase{})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/analyze", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	var resp bindingErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "Invalid request format", resp.Error)
+	return resp
+}
+
+func TestBindJSONSyntaxError(t *testing.T) {
+	for _, body := range []string{`{"url": x}`, `{"url": "https://example.com"`} {
+		resp := postAnalyze(t, body)
+		assert.Equal(t, "malformed JSON", resp.Details, body)
+		assert.Empty(t, resp.Fields, body)
+	}
+}
+
+func TestBindJSONMissingRequiredField(t *testing.T) {
+	resp := postAnalyze(t, `{"priority": 3}`)
+
+	assert.Equal(t, "one or more fields are invalid", resp.Details)
+	assert.Equal(t, []FieldError{{Field: "url", Message: "is required"}}, resp.Fields)
+}
+
+func TestBindJSONWrongType(t *testing.T) {
+	resp := postAnalyze(t, `{"url": "https://example.com", "priority": "high"}`)
+
+	assert.Equal(t, "a field has the wrong type", resp.Details)
+	assert.Equal(t, []FieldError{{Field: "priority", Message: "must be a number, got string"}}, resp.Fields)
+}
+
+func TestBindJSONEmptyBody(t *testing.T) {
+	resp := postAnalyze(t, "")
+
+	assert.Equal(t, "request body is empty", resp.Details)
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"webpage-analyzer/internal/application/usecases"
+	"webpage-analyzer/internal/domain/entities"
+	"webpage-analyzer/internal/domain/repositories"
+	"webpage-analyzer/internal/domain/services"
+	"webpage-analyzer/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type MonitorHandler struct {
+	monitorUC usecases.MonitorUseCase
+	logger    logger.Logger
+}
+
+func NewMonitorHandler(monitorUC usecases.MonitorUseCase, logger logger.Logger) *MonitorHandler {
+	return &MonitorHandler{
+		monitorUC: monitorUC,
+		logger:    logger,
+	}
+}
+
+// CreateMonitorRequest is the body of POST /monitors. Interval is a Go
+// duration string such as "24h".
+type CreateMonitorRequest struct {
+	URL      string `json:"url" binding:"required"`
+	Interval string `json:"interval" binding:"required"`
+}
+
+type MonitorResponse struct {
+	ID             string     `json:"id"`
+	URL            string     `json:"url"`
+	Interval       string     `json:"interval"`
+	NextRunAt      time.Time  `json:"next_run_at"`
+	LastRunAt      *time.Time `json:"last_run_at,omitempty"`
+	LastAnalysisID string     `json:"last_analysis_id,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func newMonitorResponse(monitor *entities.MonitoredURL) MonitorResponse {
+	response := MonitorResponse{
+		ID:        monitor.ID.String(),
+		URL:       monitor.URL,
+		Interval:  monitor.Interval.String(),
+		NextRunAt: monitor.NextRunAt,
+		LastRunAt: monitor.LastRunAt,
+		CreatedAt: monitor.CreatedAt,
+	}
+	if monitor.LastAnalysisID != nil {
+		response.LastAnalysisID = monitor.LastAnalysisID.String()
+	}
+	return response
+}
+
+// CreateMonitor is POST /api/v1/monitors. The first analysis is enqueued on
+// the scheduler's next poll.
+func (h *MonitorHandler) CreateMonitor(c *gin.Context) {
+	var req CreateMonitorRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	interval, err := time.ParseDuration(req.Interval)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid interval",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	userID, ok := c.Request.Context().Value(logger.UserIDKey).(string)
+	if !ok {
+		userID = DefaultUserID
+	}
+
+	monitor, err := h.monitorUC.CreateMonitor(c.Request.Context(), req.URL, userID, interval)
+	if err != nil {
+		if errors.Is(err, services.ErrDomainNotAllowed) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "Invalid monitor",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, usecases.ErrInvalidMonitorInterval) || errors.Is(err, services.ErrInvalidURL) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid monitor",
+				"details": err.Error(),
+			})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("Failed to create monitor", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create monitor",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, newMonitorResponse(monitor))
+}
+
+// GetMonitor is GET /api/v1/monitors/:id.
+func (h *MonitorHandler) GetMonitor(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid monitor ID format",
+		})
+		return
+	}
+
+	monitor, err := h.monitorUC.GetMonitor(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrMonitorNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Monitor not found",
+			})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("Failed to get monitor",
+			zap.String("monitor_id", id.String()),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get monitor",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, newMonitorResponse(monitor))
+}
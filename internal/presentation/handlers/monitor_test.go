@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func postMonitor(router *gin.Engine, body map[string]interface{}) *httptest.ResponseRecorder {
+	jsonBody, _ := json.Marshal(body)
+	req := httptest.NewRequest("POST", "/monitors", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateMonitor(t *testing.T) {
+	router := newStubRouter(&stubAnalysisUseCase{})
+
+	w := postMonitor(router, map[string]interface{}{"url": "https://example.com", "interval": "24h"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response MonitorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "https://example.com", response.URL)
+	assert.Equal(t, "24h0m0s", response.Interval)
+	assert.Empty(t, response.LastAnalysisID)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/monitors/"+response.ID, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestCreateMonitorRejectsInvalidRequests(t *testing.T) {
+	router := newStubRouter(&stubAnalysisUseCase{})
+
+	tests := []struct {
+		name string
+		body map[string]interface{}
+	}{
+		{"missing interval", map[string]interface{}{"url": "https://example.com"}},
+		{"unparseable interval", map[string]interface{}{"url": "https://example.com", "interval": "daily"}},
+		{"interval too short", map[string]interface{}{"url": "https://example.com", "interval": "1m"}},
+		{"invalid URL", map[string]interface{}{"url": "not-a-url", "interval": "24h"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postMonitor(router, tt.body)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func TestGetMonitorNotFound(t *testing.T) {
+	router := newStubRouter(&stubAnalysisUseCase{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/monitors/"+uuid.NewString(), nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/monitors/not-a-uuid", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
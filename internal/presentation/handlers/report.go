@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"webpage-analyzer/internal/domain/entities"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var headingOrder = []string{"h1", "h2", "h3", "h4", "h5", "h6"}
+
+// reportTemplate relies on html/template contextual escaping for every value
+// that originates from the analyzed page or the request.
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Analysis report - {{.URL}}</title>
+<style>
+body { font-family: sans-serif; margin: 2rem; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5rem; }
+th, td { border: 1px solid #ccc; padding: 0.4rem 0.8rem; text-align: left; }
+.error { color: #b00020; }
+</style>
+</head>
+<body>
+<h1>Web Page Analysis Report</h1>
+<table>
+<tr><th>URL</th><td>{{.URL}}</td></tr>
+<tr><th>Status</th><td>{{.Status}}</td></tr>
+<tr><th>Analyzed at</th><td>{{.CreatedAt}}</td></tr>
+</table>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+{{with .Result}}
+<h2>Page</h2>
+<table>
+<tr><th>Title</th><td>{{.Title}}</td></tr>
+<tr><th>HTML version</th><td>{{.HTMLVersion}}</td></tr>
+<tr><th>Login form</th><td>{{if .HasLoginForm}}Yes{{else}}No{{end}}</td></tr>
+</table>
+<h2>Headings</h2>
+<table>
+<tr><th>Level</th><th>Count</th></tr>
+{{range .Headings}}<tr><td>{{.Level}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>
+<h2>Links</h2>
+<table>
+<tr><th>Internal</th><td>{{.Links.Internal}}</td></tr>
+<tr><th>External</th><td>{{.Links.External}}</td></tr>
+<tr><th>Inaccessible</th><td>{{.Links.Inaccessible}}</td></tr>
+</table>
+{{if .Links.BrokenLinks}}<h3>Broken links</h3>
+<ul>
+{{range .Links.BrokenLinks}}<li>{{.}}</li>
+{{end}}</ul>{{end}}
+{{end}}
+</body>
+</html>
+`))
+
+type reportHeading struct {
+	Level string
+	Count int
+}
+
+type reportResult struct {
+	Title        string
+	HTMLVersion  string
+	HasLoginForm bool
+	Headings     []reportHeading
+	Links        entities.LinkAnalysis
+}
+
+type reportData struct {
+	URL       string
+	Status    string
+	CreatedAt string
+	Error     string
+	Result    *reportResult
+}
+
+func newReportData(analysis *entities.Analysis) reportData {
+	data := reportData{
+		URL:       analysis.URL,
+		Status:    string(analysis.Status),
+		CreatedAt: analysis.CreatedAt.UTC().Format("2006-01-02 15:04:05 MST"),
+		Error:     analysis.Error,
+	}
+
+	if analysis.Result != nil {
+		headings := make([]reportHeading, 0, len(headingOrder))
+		for _, level := range headingOrder {
+			headings = append(headings, reportHeading{Level: level, Count: analysis.Result.Headings[level]})
+		}
+
+		data.Result = &reportResult{
+			Title:        analysis.Result.Title,
+			HTMLVersion:  analysis.Result.HTMLVersion,
+			HasLoginForm: analysis.Result.HasLoginForm,
+			Headings:     headings,
+			Links:        analysis.Result.Links,
+		}
+	}
+
+	return data
+}
+
+func (h *AnalysisHandler) GetAnalysisReport(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid analysis ID format",
+		})
+		return
+	}
+
+	log := h.logger.WithContext(c.Request.Context()).With(
+		zap.String("analysis_id", id.String()),
+	)
+
+	analysis, err := h.analysisUC.GetAnalysis(c.Request.Context(), id)
+	if err != nil {
+		log.Error("Failed to get analysis", zap.Error(err))
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Analysis not found",
+		})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := reportTemplate.Execute(&buf, newReportData(analysis)); err != nil {
+		log.Error("Failed to render analysis report", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to render report",
+		})
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"webpage-analyzer/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAnalysisReport(t *testing.T) {
+	analysis := entities.NewAnalysis("https://example.com", "user1", "corr1")
+	analysis.MarkAsCompleted(&entities.AnalysisResult{
+		Title:        "Example <script>alert('x')</script>",
+		HTMLVersion:  "HTML5",
+		Headings:     map[string]int{"h1": 1, "h2": 4},
+		HasLoginForm: true,
+		Links: entities.LinkAnalysis{
+			Internal:     7,
+			External:     3,
+			Inaccessible: 1,
+			BrokenLinks:  []string{"https://example.com/missing"},
+		},
+	})
+
+	router := newStubRouter(&stubAnalysisUseCase{analyses: []*entities.Analysis{analysis}})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/analysis/"+analysis.ID.String()+"/report", nil))
+
+	body := w.Body.String()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, body, "HTML5")
+	assert.Contains(t, body, "<td>h2</td><td>4</td>")
+	assert.Contains(t, body, "<tr><th>Internal</th><td>7</td></tr>")
+	assert.Contains(t, body, "https://example.com/missing")
+	assert.Contains(t, body, "<tr><th>Login form</th><td>Yes</td></tr>")
+	assert.Contains(t, body, "Example &lt;script&gt;")
+	assert.NotContains(t, body, "<script>alert")
+}
+
+func TestGetAnalysisReportNotFound(t *testing.T) {
+	router := newStubRouter(&stubAnalysisUseCase{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/analysis/"+uuid.New().String()+"/report", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/analysis/not-a-uuid/report", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"webpage-analyzer/internal/application/usecases"
+	"webpage-analyzer/internal/domain/repositories"
+	"webpage-analyzer/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// RetryAnalysis re-runs a failed analysis under its existing ID. It runs
+// synchronously unless ?async=true, in which case it answers 202 with the
+// analysis in the retrying state. Only failed analyses with retries left
+// can be retried; anything else is a 409.
+func (h *AnalysisHandler) RetryAnalysis(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid analysis ID format",
+		})
+		return
+	}
+
+	async := false
+	if asyncParam := c.Query("async"); asyncParam != "" {
+		async, err = strconv.ParseBool(asyncParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request format",
+				"details": "async must be true or false",
+			})
+			return
+		}
+	}
+
+	correlationID, ok := c.Request.Context().Value(logger.CorrelationIDKey).(string)
+	if !ok {
+		correlationID = DefaultCorrelationID
+	}
+	log := h.logger.WithContext(c.Request.Context()).With(
+		zap.String("analysis_id", id.String()),
+		zap.Bool("async", async),
+	)
+
+	analysis, err := h.analysisUC.GetAnalysis(c.Request.Context(), id)
+	if err != nil {
+		log.Error("Failed to get analysis", zap.Error(err))
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Analysis not found",
+		})
+		return
+	}
+
+	retried, err := h.analysisUC.RetryAnalysis(c.Request.Context(), analysis, async)
+	switch {
+	case errors.Is(err, usecases.ErrAnalysisNotRetryable), errors.Is(err, usecases.ErrRetriesExhausted):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":          "Analysis cannot be retried",
+			"details":        err.Error(),
+			"status":         string(analysis.Status),
+			"retry_count":    analysis.RetryCount,
+			"correlation_id": correlationID,
+		})
+		return
+	case errors.Is(err, repositories.ErrVersionConflict):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":          "Analysis is already being retried",
+			"correlation_id": correlationID,
+		})
+		return
+	case errors.Is(err, usecases.ErrTooManyAnalyses), errors.Is(err, repositories.ErrDatabaseBusy):
+		h.respondBusy(c, correlationID)
+		return
+	case errors.Is(err, usecases.ErrUserLimitExceeded):
+		h.respondUserLimited(c, correlationID)
+		return
+	case errors.Is(err, context.Canceled):
+		log.Info("Request cancelled by client")
+		c.AbortWithStatus(StatusClientClosedRequest)
+		return
+	case err != nil:
+		log.Error("Retry failed", zap.Error(err))
+		respondAnalysisFailed(c, err, correlationID)
+		return
+	}
+
+	if async {
+		c.JSON(http.StatusAccepted, newAnalyzeResponse(retried))
+		return
+	}
+	c.JSON(analysisResponseStatus(retried), newAnalyzeResponse(retried))
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"webpage-analyzer/internal/application/usecases"
+	"webpage-analyzer/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveRetry(uc *stubAnalysisUseCase, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	newStubRouter(uc).ServeHTTP(w, httptest.NewRequest(http.MethodPost, target, nil))
+	return w
+}
+
+// newRetryStub returns a use case holding one failed analysis.
+func newRetryStub() (*stubAnalysisUseCase, *entities.Analysis) {
+	analysis := entities.NewAnalysis("https://example.com", "user1", "corr1")
+	analysis.MarkAsFailed("connection reset")
+	return &stubAnalysisUseCase{analyses: []*entities.Analysis{analysis}}, analysis
+}
+
+func TestRetryAnalysisSync(t *testing.T) {
+	uc, stored := newRetryStub()
+
+	w := serveRetry(uc, retryPath(stored.ID))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, uc.async)
+	var body AnalyzeResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, stored.ID.String(), body.ID)
+	assert.Equal(t, string(entities.StatusCompleted), body.Status)
+	assert.Equal(t, 1, body.RetryCount)
+}
+
+func TestRetryAnalysisAsync(t *testing.T) {
+	uc, stored := newRetryStub()
+
+	w := serveRetry(uc, retryPath(stored.ID)+"?async=true")
+
+	require.Equal(t, http.StatusAccepted, w.Code)
+	assert.True(t, uc.async)
+	var body AnalyzeResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, stored.ID.String(), body.ID)
+	assert.Equal(t, string(entities.StatusRetrying), body.Status)
+	assert.Equal(t, 1, body.RetryCount)
+}
+
+func TestRetryAnalysisRejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   func(id uuid.UUID) string
+		retryErr error
+		want     int
+	}{
+		{"not failed", retryPath, usecases.ErrAnalysisNotRetryable, http.StatusConflict},
+		{"retries exhausted", retryPath, usecases.ErrRetriesExhausted, http.StatusConflict},
+		{"at capacity", retryPath, usecases.ErrTooManyAnalyses, http.StatusServiceUnavailable},
+		{"unknown analysis", func(uuid.UUID) string { return retryPath(uuid.New()) }, nil, http.StatusNotFound},
+		{"invalid ID", func(uuid.UUID) string { return "/analysis/not-a-uuid/retry" }, nil, http.StatusBadRequest},
+		{"invalid async", func(id uuid.UUID) string { return retryPath(id) + "?async=maybe" }, nil, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, stored := newRetryStub()
+			uc.retryErr = tt.retryErr
+
+			w := serveRetry(uc, tt.target(stored.ID))
+
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func retryPath(id uuid.UUID) string {
+	return "/analysis/" + id.String() + "/retry"
+}
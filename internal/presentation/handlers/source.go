@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"webpage-analyzer/internal/domain/repositories"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// GetAnalysisSource returns the raw HTML captured for an analysis exactly as
+// fetched. It is served as plain text so browsers never render it.
+func (h *AnalysisHandler) GetAnalysisSource(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid analysis ID format",
+		})
+		return
+	}
+
+	source, err := h.analysisUC.GetAnalysisSource(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrSourceNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Analysis source not found",
+			})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("Failed to get analysis source",
+			zap.String("analysis_id", id.String()),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get analysis source",
+		})
+		return
+	}
+
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("X-Source-Truncated", strconv.FormatBool(source.Truncated))
+	c.Data(http.StatusOK, "text/plain", source.Content)
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"webpage-analyzer/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAnalysisSource(t *testing.T) {
+	source := &entities.AnalysisSource{
+		AnalysisID: uuid.New(),
+		URL:        "https://example.com",
+		Content:    []byte("<html><script>alert(1)</script></html>"),
+		Truncated:  true,
+	}
+	router := newStubRouter(&stubAnalysisUseCase{source: source})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/analysis/"+source.AnalysisID.String()+"/source", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "true", w.Header().Get("X-Source-Truncated"))
+	assert.Equal(t, string(source.Content), w.Body.String())
+}
+
+func TestGetAnalysisSourceErrors(t *testing.T) {
+	router := newStubRouter(&stubAnalysisUseCase{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/analysis/"+uuid.New().String()+"/source", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/analysis/not-a-uuid/source", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	router = newStubRouter(&stubAnalysisUseCase{sourceErr: errors.New("redis down")})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/analysis/"+uuid.New().String()+"/source", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestAnalyzeURLCaptureSourceFlag(t *testing.T) {
+	for _, capture := range []bool{false, true} {
+		uc := &stubAnalysisUseCase{}
+		router := newStubRouter(uc)
+
+		body := fmt.Sprintf(`{"url":"https://example.com","capture_source":%t}`, capture)
+		req := httptest.NewRequest("POST", "/analyze", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, capture, uc.captureSource)
+	}
+}
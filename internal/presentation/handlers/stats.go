@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetStats returns aggregate statistics over all stored analyses for
+// dashboards. The figures may be up to usecases.StatsCacheTTL seconds old.
+func (h *AnalysisHandler) GetStats(c *gin.Context) {
+	stats, err := h.analysisUC.GetStats(c.Request.Context())
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to get stats", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve statistics",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
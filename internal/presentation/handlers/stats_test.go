@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"webpage-analyzer/internal/domain/entities"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveStats(uc *stubAnalysisUseCase) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	newStubRouter(uc).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	return w
+}
+
+func TestGetStats(t *testing.T) {
+	w := serveStats(&stubAnalysisUseCase{stats: &entities.AnalysisStats{
+		Total:           3,
+		ByStatus:        map[entities.AnalysisStatus]int{entities.StatusCompleted: 2, entities.StatusFailed: 1},
+		AverageLoadTime: 250 * time.Millisecond,
+		HTMLVersions:    []entities.StatCount{{Value: "HTML5", Count: 2}},
+		BrokenLinkHosts: []entities.StatCount{{Value: "dead.example.com", Count: 4}},
+	}})
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, float64(3), body["total"])
+	assert.Equal(t, map[string]interface{}{"completed": float64(2), "failed": float64(1)}, body["by_status"])
+	assert.Equal(t, float64(250*time.Millisecond), body["average_load_time"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"value": "HTML5", "count": float64(2)}}, body["html_versions"])
+	assert.Len(t, body["broken_link_hosts"], 1)
+}
+
+func TestGetStatsFailure(t *testing.T) {
+	w := serveStats(&stubAnalysisUseCase{statsErr: errors.New("database down")})
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "Failed to retrieve statistics")
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+	"webpage-analyzer/internal/domain/entities"
+	"webpage-analyzer/internal/domain/repositories"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// AnalysisResponseV2 is the /api/v2 representation of an analysis. ID is
+// always the analysis ID, whether the analysis ran sync or was queued;
+// timestamps and request bookkeeping are grouped under Timing and Meta.
+type AnalysisResponseV2 struct {
+	ID     string                   `json:"id"`
+	URL    string                   `json:"url"`
+	Status string                   `json:"status"`
+	Result *entities.AnalysisResult `json:"result,omitempty"`
+	Error  string                   `json:"error,omitempty"`
+	Timing TimingV2                 `json:"timing"`
+	Meta   MetaV2                   `json:"meta"`
+}
+
+// TimingV2 holds when an analysis ran. DurationMS spans creation to
+// completion; LoadTimeMS is how long the page fetch and parse took.
+type TimingV2 struct {
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	DurationMS  int64      `json:"duration_ms,omitempty"`
+	LoadTimeMS  int64      `json:"load_time_ms,omitempty"`
+}
+
+// MetaV2 carries request bookkeeping. JobID is set only on the response to
+// an async submission, and Reused when that submission was answered with a
+// fresh existing analysis instead.
+type MetaV2 struct {
+	CorrelationID string            `json:"correlation_id"`
+	JobID         string            `json:"job_id,omitempty"`
+	Reused        bool              `json:"reused,omitempty"`
+	Priority      int               `json:"priority"`
+	RetryCount    int               `json:"retry_count"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+}
+
+// AnalysisListResponseV2 wraps a page of analyses with its paging state.
+type AnalysisListResponseV2 struct {
+	Analyses []AnalysisResponseV2 `json:"analyses"`
+	Page     PageV2               `json:"page"`
+}
+
+// PageV2 describes a list page. Offset is omitted for cursor paging, and
+// NextCursor is set while more cursor pages may follow.
+type PageV2 struct {
+	Count      int    `json:"count"`
+	Limit      int    `json:"limit"`
+	Offset     *int   `json:"offset,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func newAnalysisResponseV2(analysis *entities.Analysis) AnalysisResponseV2 {
+	response := AnalysisResponseV2{
+		ID:     analysis.ID.String(),
+		URL:    analysis.URL,
+		Status: string(analysis.Status),
+		Result: analysis.Result,
+		Error:  analysis.Error,
+		Timing: TimingV2{CompletedAt: analysis.CompletedAt},
+		Meta: MetaV2{
+			CorrelationID: analysis.CorrelationID,
+			Priority:      analysis.Priority,
+			RetryCount:    analysis.RetryCount,
+			Metadata:      analysis.Metadata,
+		},
+	}
+
+	if !analysis.CreatedAt.IsZero() {
+		createdAt := analysis.CreatedAt
+		response.Timing.CreatedAt = &createdAt
+		if analysis.CompletedAt != nil {
+			response.Timing.DurationMS = analysis.CompletedAt.Sub(createdAt).Milliseconds()
+		}
+	}
+
+	if analysis.Result != nil {
+		response.Timing.LoadTimeMS = analysis.Result.LoadTime.Milliseconds()
+	}
+
+	return response
+}
+
+// AnalyzeURLV2 is POST /api/v2/analyze. It accepts the v1 request body and
+// differs only in the response shape.
+func (h *AnalysisHandler) AnalyzeURLV2(c *gin.Context) {
+	req, ok := bindAnalyzeRequest(c)
+	if !ok {
+		return
+	}
+
+	job, analysis, correlationID, ok := h.runAnalysis(c, req)
+	if !ok {
+		return
+	}
+
+	response := newAnalysisResponseV2(analysis)
+	response.Meta.CorrelationID = correlationID
+
+	if job != nil {
+		response.Meta.JobID = job.ID.String()
+		c.JSON(http.StatusAccepted, response)
+		return
+	}
+	response.Meta.Reused = req.Async
+
+	c.JSON(analysisResponseStatus(analysis), response)
+}
+
+// GetAnalysisV2 is GET /api/v2/analysis/:id.
+func (h *AnalysisHandler) GetAnalysisV2(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid analysis ID format",
+		})
+		return
+	}
+
+	analysis, err := h.analysisUC.GetAnalysis(c.Request.Context(), id)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to get analysis",
+			zap.String("analysis_id", id.String()),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Analysis not found",
+		})
+		return
+	}
+
+	c.JSON(storedAnalysisStatus(analysis), newAnalysisResponseV2(analysis))
+}
+
+// ListAnalysesV2 is GET /api/v2/analyses. It takes the v1 filters and
+// paging parameters and always responds with JSON.
+func (h *AnalysisHandler) ListAnalysesV2(c *gin.Context) {
+	filters, ok := parseListFilters(c)
+	if !ok {
+		return
+	}
+
+	analyses, err := h.analysisUC.ListAnalyses(c.Request.Context(), filters)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to list analyses", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve analyses",
+		})
+		return
+	}
+
+	response := AnalysisListResponseV2{
+		Analyses: make([]AnalysisResponseV2, len(analyses)),
+		Page:     PageV2{Count: len(analyses), Limit: filters.Limit},
+	}
+	for i, analysis := range analyses {
+		response.Analyses[i] = newAnalysisResponseV2(analysis)
+	}
+
+	if filters.Cursor != nil {
+		if len(analyses) == filters.Limit {
+			response.Page.NextCursor = repositories.NewListCursor(analyses[len(analyses)-1]).Encode()
+		}
+	} else {
+		offset := filters.Offset
+		response.Page.Offset = &offset
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"webpage-analyzer/internal/domain/entities"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func postV2Analyze(router *gin.Engine, body map[string]interface{}) *httptest.ResponseRecorder {
+	jsonBody, _ := json.Marshal(body)
+	req := httptest.NewRequest("POST", "/api/v2/analyze", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAnalyzeURLV2Sync(t *testing.T) {
+	router := newStubRouter(&stubAnalysisUseCase{})
+
+	w := postV2Analyze(router, map[string]interface{}{"url": "https://example.com"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var raw map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &raw))
+	assert.NotContains(t, raw, "analysis_id")
+	assert.NotContains(t, raw, "correlation_id")
+	assert.NotContains(t, raw, "created_at")
+	assert.Contains(t, raw, "timing")
+	assert.Contains(t, raw, "meta")
+
+	var response AnalysisResponseV2
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.NotEmpty(t, response.ID)
+	assert.Equal(t, "completed", response.Status)
+	assert.Equal(t, "Test Page", response.Result.Title)
+	assert.NotNil(t, response.Timing.CreatedAt)
+	assert.NotNil(t, response.Timing.CompletedAt)
+	assert.Empty(t, response.Meta.JobID)
+}
+
+func TestAnalyzeURLV2AsyncUsesAnalysisID(t *testing.T) {
+	router := newStubRouter(&stubAnalysisUseCase{})
+
+	w := postV2Analyze(router, map[string]interface{}{"url": "https://example.com", "async": true})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	var response AnalysisResponseV2
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "pending", response.Status)
+	assert.NotEmpty(t, response.Meta.JobID)
+	assert.NotEqual(t, response.Meta.JobID, response.ID)
+}
+
+func TestGetAnalysisV2(t *testing.T) {
+	analysis := entities.NewAnalysis("https://example.com", "user1", "corr1")
+	analysis.Priority = 3
+	analysis.RetryCount = 1
+	analysis.Metadata = map[string]string{"team": "seo"}
+	analysis.CreatedAt = time.Now().Add(-2 * time.Second)
+	analysis.MarkAsCompleted(&entities.AnalysisResult{Title: "Test Page", LoadTime: 1500 * time.Millisecond})
+	router := newStubRouter(&stubAnalysisUseCase{analyses: []*entities.Analysis{analysis}})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v2/analysis/"+analysis.ID.String(), nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response AnalysisResponseV2
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, analysis.ID.String(), response.ID)
+	assert.Equal(t, MetaV2{
+		CorrelationID: "corr1",
+		Priority:      3,
+		RetryCount:    1,
+		Metadata:      map[string]string{"team": "seo"},
+	}, response.Meta)
+	assert.Equal(t, int64(1500), response.Timing.LoadTimeMS)
+	assert.GreaterOrEqual(t, response.Timing.DurationMS, int64(2000))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v2/analysis/not-a-uuid", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestListAnalysesV2(t *testing.T) {
+	analyses := []*entities.Analysis{
+		entities.NewAnalysis("https://example.com/a", "user1", "corr1"),
+		entities.NewAnalysis("https://example.com/b", "user1", "corr2"),
+	}
+	router := newStubRouter(&stubAnalysisUseCase{analyses: analyses})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v2/analyses?limit=2&offset=4", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response AnalysisListResponseV2
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.Analyses, 2)
+	assert.Equal(t, analyses[0].ID.String(), response.Analyses[0].ID)
+	assert.Equal(t, 2, response.Page.Count)
+	assert.Equal(t, 2, response.Page.Limit)
+	if assert.NotNil(t, response.Page.Offset) {
+		assert.Equal(t, 4, *response.Page.Offset)
+	}
+	assert.Empty(t, response.Page.NextCursor)
+}
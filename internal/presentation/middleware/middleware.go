@@ -1,13 +1,24 @@
 package middleware
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"regexp"
+	"runtime/debug"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 	"webpage-analyzer/internal/infrastructure/monitoring"
 	"webpage-analyzer/pkg/logger"
+	"webpage-analyzer/pkg/tracing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -95,26 +106,54 @@ func (rl *RateLimiter) Allow(ip string) bool {
 	return true
 }
 
-func CorrelationIDMiddleware() gin.HandlerFunc {
+// DefaultCorrelationIDHeader carries the correlation ID when no other
+// header is configured.
+const DefaultCorrelationIDHeader = "X-Correlation-ID"
+
+// CorrelationIDMiddleware reuses an incoming ID from the first of headers
+// that is present, or generates one, and echoes it on the first header.
+// With no headers, DefaultCorrelationIDHeader is used. It also adopts the
+// W3C traceparent, starting a new trace when none is valid, so outbound
+// fetches and logs carry the trace ID.
+func CorrelationIDMiddleware(headers ...string) gin.HandlerFunc {
+	if len(headers) == 0 {
+		headers = []string{DefaultCorrelationIDHeader}
+	}
 	return func(c *gin.Context) {
-		correlationID := c.GetHeader("X-Correlation-ID")
+		correlationID := ""
+		for _, header := range headers {
+			if correlationID = c.GetHeader(header); correlationID != "" {
+				break
+			}
+		}
 		if correlationID == "" {
 			correlationID = uuid.New().String()
 		}
 
+		traceparent, ok := tracing.Parse(c.GetHeader(tracing.Header))
+		if !ok {
+			traceparent = tracing.New()
+		}
+
 		ctx := context.WithValue(c.Request.Context(), logger.CorrelationIDKey, correlationID)
+		ctx = context.WithValue(ctx, logger.TraceIDKey, traceparent.TraceID)
+		ctx = tracing.WithTraceparent(ctx, traceparent)
 		c.Request = c.Request.WithContext(ctx)
-		c.Header("X-Correlation-ID", correlationID)
+		c.Header(headers[0], correlationID)
 
 		c.Next()
 	}
 }
 
 func LoggingMiddleware(log logger.Logger) gin.HandlerFunc {
+	if log == nil {
+		log = logger.NewNop()
+	}
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
-		raw := c.Request.URL.RawQuery
+		// query parameters can carry URLs with credentials, e.g. /validate
+		raw := logger.SanitizeQuery(c.Request.URL.RawQuery)
 
 		c.Next()
 
@@ -155,8 +194,23 @@ func LoggingMiddleware(log logger.Logger) gin.HandlerFunc {
 	}
 }
 
-func RateLimitMiddleware(rateLimiter *RateLimiter) gin.HandlerFunc {
+// DefaultRateLimitExemptPaths keeps probes and scrapes of metricsPath from
+// being throttled.
+func DefaultRateLimitExemptPaths(metricsPath string) []string {
+	return []string{"/health", metricsPath}
+}
+
+// RateLimitMiddleware throttles requests per client IP. Requests whose path
+// starts with one of exemptPrefixes are never limited.
+func RateLimitMiddleware(rateLimiter *RateLimiter, exemptPrefixes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		for _, prefix := range exemptPrefixes {
+			if strings.HasPrefix(c.Request.URL.Path, prefix) {
+				c.Next()
+				return
+			}
+		}
+
 		clientIP := c.ClientIP()
 
 		if !rateLimiter.Allow(clientIP) {
@@ -174,20 +228,25 @@ func RateLimitMiddleware(rateLimiter *RateLimiter) gin.HandlerFunc {
 }
 
 func ErrorHandlingMiddleware(log logger.Logger) gin.HandlerFunc {
+	if log == nil {
+		log = logger.NewNop()
+	}
 	return func(c *gin.Context) {
 		defer func() {
 			if err := recover(); err != nil {
-				correlationID := c.Request.Context().Value(string(logger.CorrelationIDKey))
+				correlationID, _ := c.Request.Context().Value(logger.CorrelationIDKey).(string)
 
+				// the stack is logged only; the response never includes it
 				fields := []zap.Field{
 					zap.Any("panic", err),
 					zap.String("method", c.Request.Method),
 					zap.String("path", c.Request.URL.Path),
 					zap.String("client_ip", c.ClientIP()),
+					zap.ByteString("stack", debug.Stack()),
 				}
 
-				if correlationID != nil {
-					fields = append(fields, zap.String("correlation_id", correlationID.(string)))
+				if correlationID != "" {
+					fields = append(fields, zap.String("correlation_id", correlationID))
 				}
 
 				log.Error("Panic recovered", fields...)
@@ -204,12 +263,18 @@ func ErrorHandlingMiddleware(log logger.Logger) gin.HandlerFunc {
 	}
 }
 
-func CORSMiddleware() gin.HandlerFunc {
+// CORSMiddleware allows cross-origin calls; correlationHeader overrides the
+// header browsers may send and read for the correlation ID.
+func CORSMiddleware(correlationHeader ...string) gin.HandlerFunc {
+	header := DefaultCorrelationIDHeader
+	if len(correlationHeader) > 0 && correlationHeader[0] != "" {
+		header = correlationHeader[0]
+	}
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization, X-Correlation-ID")
-		c.Header("Access-Control-Expose-Headers", "X-Correlation-ID")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization, "+tracing.Header+", "+AnalysisTimeoutHeader+", "+header)
+		c.Header("Access-Control-Expose-Headers", header+", "+AnalysisTimeoutHeader)
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(http.StatusNoContent)
@@ -236,6 +301,87 @@ func RequestSizeLimitMiddleware(maxSize int64) gin.HandlerFunc {
 	}
 }
 
+// AnalysisTimeoutHeader lets a client ask for a different deadline on
+// routes that allow it, as seconds ("90") or a duration ("90s").
+const AnalysisTimeoutHeader = "X-Analysis-Timeout"
+
+// timeoutWriteGrace keeps the connection's write deadline open a little past
+// an overridden request deadline, so the timeout response can be written.
+const timeoutWriteGrace = 5 * time.Second
+
+// TimeoutMiddleware puts a deadline of timeout on the request context, so
+// handlers and the use case calls beneath them give up once it passes. If
+// the handler returns without writing after the deadline, it responds 504.
+// timeout <= 0 disables the deadline.
+func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return OverridableTimeoutMiddleware(timeout, 0)
+}
+
+// OverridableTimeoutMiddleware is TimeoutMiddleware, except that a request
+// may replace timeout through AnalysisTimeoutHeader. The requested value is
+// clamped to maxTimeout and echoed in the response header; an unparsable or
+// non-positive value is rejected with 400. maxTimeout <= 0 ignores the
+// header.
+func OverridableTimeoutMiddleware(timeout, maxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		deadline := timeout
+		if value := c.GetHeader(AnalysisTimeoutHeader); value != "" && maxTimeout > 0 {
+			requested, err := parseRequestTimeout(value)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+					"error":   "Invalid " + AnalysisTimeoutHeader + " header",
+					"details": err.Error(),
+				})
+				return
+			}
+			deadline = min(requested, maxTimeout)
+			c.Header(AnalysisTimeoutHeader, deadline.String())
+
+			// the server's write timeout would otherwise cut off a longer
+			// window; writers that cannot extend it keep the server default
+			_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(deadline + timeoutWriteGrace))
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), deadline)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{
+				"error": "Request timed out",
+			})
+		}
+	}
+}
+
+// parseRequestTimeout reads a timeout given as whole seconds or as a Go
+// duration string.
+func parseRequestTimeout(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0, fmt.Errorf("timeout must be positive, got %q", value)
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("timeout must be seconds or a duration such as 90s, got %q", value)
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("timeout must be positive, got %q", value)
+	}
+	return timeout, nil
+}
+
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID := c.GetHeader("X-User-ID")
@@ -249,3 +395,282 @@ func AuthMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+const redactedValue = "[REDACTED]"
+
+var sensitiveBodyKeys = []string{
+	"password", "passwd", "pwd", "secret", "token", "authorization", "api_key", "apikey", "credential",
+}
+
+// sensitivePairPattern catches sensitive values in bodies that are not valid
+// JSON, e.g. form posts or truncated payloads.
+var sensitivePairPattern = regexp.MustCompile(
+	`(?i)("?[a-z_\-]*(?:` + strings.Join(sensitiveBodyKeys, "|") + `)[a-z_\-]*"?\s*[:=]\s*)("[^"]*"|[^&\s,}]*)`,
+)
+
+type bodyLogWriter struct {
+	gin.ResponseWriter
+	body    *bytes.Buffer
+	maxSize int
+}
+
+func (w *bodyLogWriter) Write(b []byte) (int, error) {
+	if remaining := w.maxSize - w.body.Len(); remaining > 0 {
+		if len(b) > remaining {
+			w.body.Write(b[:remaining])
+		} else {
+			w.body.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// prefixedBody replays bytes already read from a request body ahead of the
+// unread remainder.
+type prefixedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// BodyLoggingMiddleware logs request and response bodies at debug level with
+// sensitive fields redacted and each body capped at maxSize bytes. It is
+// meant for debugging client integrations and should stay off in production.
+func BodyLoggingMiddleware(log logger.Logger, maxSize int) gin.HandlerFunc {
+	if log == nil {
+		log = logger.NewNop()
+	}
+	return func(c *gin.Context) {
+		var requestBody []byte
+		if original := c.Request.Body; original != nil {
+			// read only what can be logged, plus one byte to detect
+			// truncation; the rest is left for the handler to stream
+			body, err := io.ReadAll(io.LimitReader(original, int64(maxSize)+1))
+			if err == nil {
+				requestBody = body
+			}
+			c.Request.Body = prefixedBody{Reader: io.MultiReader(bytes.NewReader(body), original), Closer: original}
+		}
+
+		// capture one extra byte so RedactBody can tell the body was truncated
+		writer := &bodyLogWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}, maxSize: maxSize + 1}
+		c.Writer = writer
+
+		c.Next()
+
+		log.WithContext(c.Request.Context()).Debug("HTTP request/response bodies",
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+			zap.Int("status_code", c.Writer.Status()),
+			zap.String("request_body", RedactBody(requestBody, maxSize)),
+			zap.String("response_body", RedactBody(writer.body.Bytes(), maxSize)),
+		)
+	}
+}
+
+// DefaultCompressionMinSize is the smallest response body worth gzipping.
+const DefaultCompressionMinSize = 1024
+
+// compressibleTypes are the media types CompressionMiddleware gzips; other
+// types, such as captured page source, are passed through untouched.
+var compressibleTypes = []string{"application/json", "text/html", "text/csv"}
+
+// gzipWriter holds back the first minSize bytes of a response so small
+// bodies are sent as-is, then switches to gzip once the body proves large.
+type gzipWriter struct {
+	gin.ResponseWriter
+	minSize int
+	buf     []byte
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (w *gzipWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.minSize {
+			return len(b), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if w.gz != nil {
+		return w.gz.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *gzipWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written and Size count held-back bytes, so middleware checking whether
+// the handler responded, such as the timeout middlewares, sees the response
+// before it reaches the underlying writer.
+func (w *gzipWriter) Written() bool {
+	return len(w.buf) > 0 || w.ResponseWriter.Written()
+}
+
+func (w *gzipWriter) Size() int {
+	if !w.decided && len(w.buf) > 0 {
+		return len(w.buf)
+	}
+	return w.ResponseWriter.Size()
+}
+
+// Flush sends buffered data now, which means a body that has not reached
+// minSize yet goes out uncompressed.
+func (w *gzipWriter) Flush() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide picks gzip or identity encoding and writes out the held-back bytes.
+func (w *gzipWriter) decide(large bool) error {
+	w.decided = true
+	header := w.Header()
+	if large && header.Get("Content-Encoding") == "" && isCompressible(header.Get("Content-Type")) {
+		header.Set("Content-Encoding", "gzip")
+		header.Add("Vary", "Accept-Encoding")
+		header.Del("Content-Length")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+		_, err := w.gz.Write(w.buf)
+		w.buf = nil
+		return err
+	}
+	_, err := w.ResponseWriter.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+func (w *gzipWriter) close() error {
+	if !w.decided {
+		if len(w.buf) == 0 {
+			return nil
+		}
+		if err := w.decide(false); err != nil {
+			return err
+		}
+	}
+	if w.gz != nil {
+		return w.gz.Close()
+	}
+	return nil
+}
+
+func isCompressible(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	for _, compressible := range compressibleTypes {
+		if mediaType == compressible {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsGzip reports whether the Accept-Encoding header allows gzip.
+func acceptsGzip(acceptEncoding string) bool {
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			weight, err := strconv.ParseFloat(q, 64)
+			return err == nil && weight > 0
+		}
+		return true
+	}
+	return false
+}
+
+// CompressionMiddleware gzips JSON, HTML and CSV responses of at least
+// minSize bytes for clients that accept it. Responses that already carry a
+// Content-Encoding, such as Prometheus metrics, are left alone.
+func CompressionMiddleware(minSize int) gin.HandlerFunc {
+	if minSize <= 0 {
+		minSize = DefaultCompressionMinSize
+	}
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodHead || !acceptsGzip(c.GetHeader("Accept-Encoding")) {
+			c.Next()
+			return
+		}
+
+		writer := &gzipWriter{ResponseWriter: c.Writer, minSize: minSize}
+		c.Writer = writer
+		defer func() {
+			_ = writer.close()
+			c.Writer = writer.ResponseWriter
+		}()
+
+		c.Next()
+	}
+}
+
+// RedactBody masks sensitive fields in body and truncates it to at most
+// maxSize bytes, backing off to a rune boundary so the log stays valid UTF-8.
+func RedactBody(body []byte, maxSize int) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var redacted string
+	var payload interface{}
+	if err := json.Unmarshal(body, &payload); err == nil {
+		if out, err := json.Marshal(redactValue(payload)); err == nil {
+			redacted = string(out)
+		}
+	}
+	if redacted == "" {
+		redacted = sensitivePairPattern.ReplaceAllString(string(body), "${1}"+redactedValue)
+	}
+
+	if maxSize > 0 && len(redacted) > maxSize {
+		cut := maxSize
+		for cut > 0 && !utf8.RuneStart(redacted[cut]) {
+			cut--
+		}
+		return redacted[:cut] + "...(truncated)"
+	}
+	return redacted
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range v {
+			if isSensitiveKey(key) {
+				v[key] = redactedValue
+			} else {
+				v[key] = redactValue(nested)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, nested := range v {
+			v[i] = redactValue(nested)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveBodyKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,10 @@
 package routes
 
 import (
+	"fmt"
+	"net/http"
+	"time"
+
 	"webpage-analyzer/internal/application/usecases"
 	"webpage-analyzer/internal/presentation/handlers"
 	"webpage-analyzer/internal/presentation/middleware"
@@ -10,6 +14,77 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// Options holds optional route-level behaviour; a nil *Options keeps defaults.
+type Options struct {
+	// LogBodies enables debug logging of redacted request/response bodies.
+	LogBodies      bool
+	MaxBodyLogSize int
+	// RateLimitExemptPaths are path prefixes that bypass rate limiting;
+	// empty falls back to middleware.DefaultRateLimitExemptPaths for
+	// MetricsPath.
+	RateLimitExemptPaths []string
+	// Database and Cache, when set, are pinged by the readiness check; a
+	// down database fails it, a down cache only degrades it.
+	Database handlers.Pinger
+	Cache    handlers.Pinger
+	// MetricsPath is where Prometheus metrics are served; empty means
+	// DefaultMetricsPath.
+	MetricsPath string
+	// SeparateMetrics leaves metrics off the main router because they are
+	// served on a dedicated listener via NewMetricsHandler.
+	SeparateMetrics bool
+	// CorrelationIDHeader names the header carrying the correlation ID;
+	// empty means middleware.DefaultCorrelationIDHeader. An incoming
+	// X-Correlation-ID is still honoured when a custom header is absent.
+	CorrelationIDHeader string
+	// Compression gzips large JSON, HTML and CSV responses for clients
+	// that accept it; CompressionMinSize <= 0 means the middleware default.
+	Compression        bool
+	CompressionMinSize int
+	// ReadRouteTimeout bounds the routes that only touch stored data; <= 0
+	// means DefaultReadRouteTimeout. Analysis routes use SetupRoutes'
+	// requestTimeout instead.
+	ReadRouteTimeout time.Duration
+	// MaxAnalysisTimeout caps the middleware.AnalysisTimeoutHeader clients
+	// may send on analysis routes; <= 0 ignores the header.
+	MaxAnalysisTimeout time.Duration
+	// Monitors, when set, serves the /api/v1/monitors routes.
+	Monitors usecases.MonitorUseCase
+}
+
+const (
+	// DefaultMetricsPath is the metrics route when none is configured.
+	DefaultMetricsPath = "/metrics"
+	// DefaultReadRouteTimeout is the deadline for read routes when none is
+	// configured.
+	DefaultReadRouteTimeout = 5 * time.Second
+)
+
+// NewMetricsHandler serves Prometheus metrics on path only, for running
+// them on a listener separate from the public API.
+func NewMetricsHandler(path string) http.Handler {
+	if path == "" {
+		path = DefaultMetricsPath
+	}
+	mux := http.NewServeMux()
+	mux.Handle(path, promhttp.Handler())
+	return mux
+}
+
+// NewRouter creates the engine, trusting X-Forwarded-For and X-Real-IP only
+// from trustedProxies (IPs or CIDRs). With none, the client IP is always the
+// connection's remote address, so rate limiting cannot be spoofed.
+func NewRouter(trustedProxies []string) (*gin.Engine, error) {
+	router := gin.New()
+	if len(trustedProxies) == 0 {
+		trustedProxies = nil
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+	return router, nil
+}
+
 func SetupRoutes(
 	router *gin.Engine,
 	analysisUC usecases.AnalysisUseCase,
@@ -17,33 +92,100 @@ func SetupRoutes(
 	rateLimiter *middleware.RateLimiter,
 	maxContentLength int64,
 	requestTimeout int,
+	opts *Options,
 ) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
 	analysisHandler := handlers.NewAnalysisHandler(analysisUC, logger)
 
+	correlationHeaders := []string{middleware.DefaultCorrelationIDHeader}
+	if opts.CorrelationIDHeader != "" && opts.CorrelationIDHeader != middleware.DefaultCorrelationIDHeader {
+		correlationHeaders = append([]string{opts.CorrelationIDHeader}, correlationHeaders...)
+	}
+
 	router.Use(middleware.ErrorHandlingMiddleware(logger))
-	router.Use(middleware.CORSMiddleware())
-	router.Use(middleware.CorrelationIDMiddleware())
+	if opts.Compression {
+		router.Use(middleware.CompressionMiddleware(opts.CompressionMinSize))
+	}
+	router.Use(middleware.CORSMiddleware(correlationHeaders[0]))
+	router.Use(middleware.CorrelationIDMiddleware(correlationHeaders...))
 	router.Use(middleware.AuthMiddleware())
 	router.Use(middleware.LoggingMiddleware(logger))
-	router.Use(middleware.RateLimitMiddleware(rateLimiter))
+	if opts.LogBodies {
+		router.Use(middleware.BodyLoggingMiddleware(logger, opts.MaxBodyLogSize))
+	}
+	metricsPath := opts.MetricsPath
+	if metricsPath == "" {
+		metricsPath = DefaultMetricsPath
+	}
+	exemptPaths := opts.RateLimitExemptPaths
+	if len(exemptPaths) == 0 {
+		exemptPaths = middleware.DefaultRateLimitExemptPaths(metricsPath)
+	}
+	router.Use(middleware.RateLimitMiddleware(rateLimiter, exemptPaths...))
 	router.Use(middleware.RequestSizeLimitMiddleware(maxContentLength))
 
 	router.GET("/health", analysisHandler.HealthCheck)
 	router.GET("/health/live", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "alive"})
 	})
-	router.GET("/health/ready", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ready"})
-	})
+	router.GET("/health/ready", handlers.ReadinessCheck(opts.Database, opts.Cache))
+
+	if !opts.SeparateMetrics {
+		router.GET(metricsPath, gin.WrapH(promhttp.Handler()))
+	}
 
-	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	// analysis routes may fetch the target page, so they get the analysis
+	// request timeout; routes that only touch stored data get a short one
+	analyzeTimeout := middleware.OverridableTimeoutMiddleware(time.Duration(requestTimeout)*time.Second, opts.MaxAnalysisTimeout)
+	readTimeout := opts.ReadRouteTimeout
+	if readTimeout <= 0 {
+		readTimeout = DefaultReadRouteTimeout
+	}
+	storeOnly := middleware.TimeoutMiddleware(readTimeout)
 
 	v1 := router.Group("/api/v1")
 	{
-		v1.POST("/analyze", analysisHandler.AnalyzeURL)
-		v1.GET("/analysis/:id", analysisHandler.GetAnalysis)
-		v1.GET("/analyses", analysisHandler.ListAnalyses)
+		v1.POST("/analyze", analyzeTimeout, analysisHandler.AnalyzeURL)
+		v1.GET("/analyze", analyzeTimeout, analysisHandler.AnalyzeURLQuery)
+		v1.GET("/analysis/:id", storeOnly, analysisHandler.GetAnalysis)
+		v1.POST("/analysis/:id/retry", analyzeTimeout, analysisHandler.RetryAnalysis)
+		v1.GET("/analysis/:id/report", storeOnly, analysisHandler.GetAnalysisReport)
+		v1.GET("/analysis/:id/source", storeOnly, analysisHandler.GetAnalysisSource)
+		v1.GET("/analyses", unlessCSV(storeOnly), analysisHandler.ListAnalyses)
+		v1.GET("/stats", storeOnly, analysisHandler.GetStats)
+		v1.POST("/validate", storeOnly, analysisHandler.ValidateURL)
+		v1.GET("/validate", storeOnly, analysisHandler.ValidateURL)
+
+		if opts.Monitors != nil {
+			monitorHandler := handlers.NewMonitorHandler(opts.Monitors, logger)
+			v1.POST("/monitors", storeOnly, monitorHandler.CreateMonitor)
+			v1.GET("/monitors/:id", storeOnly, monitorHandler.GetMonitor)
+		}
+	}
+
+	// v2 shares the use case and only changes the response shape
+	v2 := router.Group("/api/v2")
+	{
+		v2.POST("/analyze", analyzeTimeout, analysisHandler.AnalyzeURLV2)
+		v2.GET("/analysis/:id", storeOnly, analysisHandler.GetAnalysisV2)
+		v2.GET("/analyses", storeOnly, analysisHandler.ListAnalysesV2)
 	}
 
-	router.POST("/api/analyze", analysisHandler.AnalyzeURL)
+	router.POST("/api/analyze", analyzeTimeout, analysisHandler.AnalyzeURL)
+}
+
+// unlessCSV applies timeout except to CSV exports, which stream every
+// matching analysis and would otherwise be cut short after the status line
+// is sent, leaving a truncated file that looks complete.
+func unlessCSV(timeout gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if handlers.WantsCSV(c) {
+			c.Next()
+			return
+		}
+		timeout(c)
+	}
 }
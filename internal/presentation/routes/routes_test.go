@@ -1,14 +1,22 @@
 package routes
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"webpage-analyzer/internal/application/usecases"
+	"webpage-analyzer/internal/domain/entities"
+	"webpage-analyzer/internal/domain/repositories"
 	"webpage-analyzer/internal/presentation/middleware"
 	"webpage-analyzer/pkg/logger"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -16,10 +24,10 @@ func TestSetupRoutes(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 	var uc usecases.AnalysisUseCase
-	var log logger.Logger
+	log := logger.NewNop()
 	rateLimiter := middleware.NewRateLimiter(100, time.Minute)
 
-	SetupRoutes(router, uc, log, rateLimiter, 1024*1024, 30)
+	SetupRoutes(router, uc, log, rateLimiter, 1024*1024, 30, nil)
 
 	assert.NotNil(t, router)
 }
@@ -28,10 +36,10 @@ func TestSetupRoutesWithDifferentConfigs(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 	var uc usecases.AnalysisUseCase
-	var log logger.Logger
+	log := logger.NewNop()
 	rateLimiter := middleware.NewRateLimiter(50, time.Second)
 
-	SetupRoutes(router, uc, log, rateLimiter, 512*1024, 60)
+	SetupRoutes(router, uc, log, rateLimiter, 512*1024, 60, nil)
 
 	assert.NotNil(t, router)
 }
@@ -40,10 +48,188 @@ func TestSetupRoutesWithZeroLimits(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 	var uc usecases.AnalysisUseCase
-	var log logger.Logger
+	log := logger.NewNop()
 	rateLimiter := middleware.NewRateLimiter(0, time.Second)
 
-	SetupRoutes(router, uc, log, rateLimiter, 0, 0)
+	SetupRoutes(router, uc, log, rateLimiter, 0, 0, nil)
 
 	assert.NotNil(t, router)
 }
+
+func TestSetupRoutesServesMetricsOnConfiguredPath(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	rateLimiter := middleware.NewRateLimiter(100, time.Minute)
+
+	SetupRoutes(router, nil, logger.NewNop(), rateLimiter, 1024*1024, 30, &Options{MetricsPath: "/internal/metrics"})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/internal/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "# HELP")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestSetupRoutesExemptsConfiguredMetricsPathFromRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	rateLimiter := middleware.NewRateLimiter(1, time.Minute)
+
+	SetupRoutes(router, nil, logger.NewNop(), rateLimiter, 1024*1024, 30, &Options{MetricsPath: "/internal/metrics"})
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/internal/metrics", nil)
+		req.RemoteAddr = "192.168.1.1:12345"
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+}
+
+func TestSetupRoutesSeparateMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	rateLimiter := middleware.NewRateLimiter(100, time.Minute)
+
+	SetupRoutes(router, nil, logger.NewNop(), rateLimiter, 1024*1024, 30, &Options{SeparateMetrics: true})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	metrics := NewMetricsHandler("/ops/metrics")
+
+	w = httptest.NewRecorder()
+	metrics.ServeHTTP(w, httptest.NewRequest("GET", "/ops/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	metrics.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/analyses", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestNewRouterTrustedProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		proxies    []string
+		remoteAddr string
+		want       string
+	}{
+		{"trusted proxy", []string{"10.0.0.0/8"}, "10.1.2.3:4567", "203.0.113.7"},
+		{"untrusted proxy", []string{"10.0.0.0/8"}, "192.0.2.9:4567", "192.0.2.9"},
+		{"no trusted proxies", nil, "10.1.2.3:4567", "10.1.2.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, err := NewRouter(tt.proxies)
+			assert.NoError(t, err)
+			router.GET("/ip", func(c *gin.Context) {
+				c.String(http.StatusOK, c.ClientIP())
+			})
+
+			req := httptest.NewRequest("GET", "/ip", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want, w.Body.String())
+		})
+	}
+}
+
+func TestNewRouterRejectsInvalidProxy(t *testing.T) {
+	_, err := NewRouter([]string{"not-an-ip"})
+	assert.Error(t, err)
+}
+
+// deadlineRecorder records how long each use case call was given.
+type deadlineRecorder struct {
+	usecases.AnalysisUseCase
+	budgets map[string]time.Duration
+}
+
+func (r *deadlineRecorder) record(ctx context.Context, name string) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		r.budgets[name] = time.Until(deadline)
+	}
+	return errors.New("not found")
+}
+
+func (r *deadlineRecorder) AnalyzeURL(ctx context.Context, url, userID string, metadata map[string]string) (*entities.Analysis, error) {
+	return nil, r.record(ctx, "analyze")
+}
+
+func (r *deadlineRecorder) GetAnalysis(ctx context.Context, id uuid.UUID) (*entities.Analysis, error) {
+	return nil, r.record(ctx, "get")
+}
+
+func (r *deadlineRecorder) ValidateURL(ctx context.Context, url string) error {
+	return r.record(ctx, "validate")
+}
+
+func (r *deadlineRecorder) ListAnalyses(ctx context.Context, filters repositories.AnalysisFilters) ([]*entities.Analysis, error) {
+	name := "list"
+	if filters.Cursor != nil {
+		name = "export"
+	}
+	return nil, r.record(ctx, name)
+}
+
+func TestSetupRoutesPerRouteTimeouts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	uc := &deadlineRecorder{budgets: make(map[string]time.Duration)}
+	rateLimiter := middleware.NewRateLimiter(100, time.Minute)
+
+	SetupRoutes(router, uc, logger.NewNop(), rateLimiter, 1024*1024, 30, &Options{ReadRouteTimeout: 2 * time.Second})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/api/v1/analyze", strings.NewReader(`{"url":"https://example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/analysis/"+uuid.NewString(), nil))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/analyses", nil))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/analyses?format=csv", nil))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/validate?url=https://example.com", nil))
+
+	assert.InDelta(t, 30*time.Second, uc.budgets["analyze"], float64(time.Second))
+	assert.InDelta(t, 2*time.Second, uc.budgets["get"], float64(time.Second))
+	assert.InDelta(t, 2*time.Second, uc.budgets["list"], float64(time.Second))
+	// validation never fetches the page
+	assert.InDelta(t, 2*time.Second, uc.budgets["validate"], float64(time.Second))
+	// CSV exports stream every page, so they run without the read deadline
+	assert.NotContains(t, uc.budgets, "export")
+}
+
+func TestSetupRoutesMonitorsOnlyWhenConfigured(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rateLimiter := middleware.NewRateLimiter(100, time.Minute)
+
+	router := gin.New()
+	SetupRoutes(router, nil, logger.NewNop(), rateLimiter, 1024*1024, 30, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/monitors/not-a-uuid", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	router = gin.New()
+	monitors := usecases.NewMonitorUseCase(nil, nil, logger.NewNop(), 0)
+	SetupRoutes(router, nil, logger.NewNop(), rateLimiter, 1024*1024, 30, &Options{Monitors: monitors})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/monitors/not-a-uuid", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
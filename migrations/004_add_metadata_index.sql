@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_analyses_metadata ON analyses USING GIN (metadata);
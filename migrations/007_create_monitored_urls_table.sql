@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS monitored_urls (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    url TEXT NOT NULL,
+    user_id VARCHAR(255),
+    interval_seconds BIGINT NOT NULL,
+    next_run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    last_analysis_id UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_monitored_urls_next_run_at ON monitored_urls(next_run_at);
+
+DROP TRIGGER IF EXISTS update_monitored_urls_updated_at ON monitored_urls;
+CREATE TRIGGER update_monitored_urls_updated_at
+    BEFORE UPDATE ON monitored_urls
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
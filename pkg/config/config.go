@@ -19,6 +19,28 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
 	WriteTimeout time.Duration `mapstructure:"write_timeout"`
 	IdleTimeout  time.Duration `mapstructure:"idle_timeout"`
+	MetricsPath  string        `mapstructure:"metrics_path"`
+	// MetricsPort serves metrics on a dedicated listener; empty keeps them
+	// on the main router.
+	MetricsPort string `mapstructure:"metrics_port"`
+	// CorrelationIDHeader names the request ID header, e.g. X-Request-ID.
+	CorrelationIDHeader string `mapstructure:"correlation_id_header"`
+	// TrustedProxies lists proxy IPs or CIDRs whose forwarding headers are
+	// believed; empty trusts none.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// ShutdownTimeout bounds the HTTP server shutdown and the drain of
+	// background analyses together.
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+	// CompressionEnabled gzips responses of at least CompressionMinSize
+	// bytes when the client accepts it.
+	CompressionEnabled bool `mapstructure:"compression_enabled"`
+	CompressionMinSize int  `mapstructure:"compression_min_size"`
+	// ReadRouteTimeout bounds requests to routes that only read stored
+	// analyses; analysis routes use analysis.request_timeout.
+	ReadRouteTimeout time.Duration `mapstructure:"read_route_timeout"`
+	// MaxAnalysisTimeout caps the X-Analysis-Timeout header clients may
+	// send to extend analysis routes; 0 ignores the header.
+	MaxAnalysisTimeout time.Duration `mapstructure:"max_analysis_timeout"`
 }
 
 type DatabaseConfig struct {
@@ -31,6 +53,15 @@ type DatabaseConfig struct {
 	MaxConnections  int           `mapstructure:"max_connections"`
 	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
 	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
+	// SlowQueryThreshold logs a warning for queries slower than this; 0 disables it.
+	SlowQueryThreshold time.Duration `mapstructure:"slow_query_threshold"`
+	// MaxListLimit is the most rows a single list query may return.
+	MaxListLimit int `mapstructure:"max_list_limit"`
+	// MaxConcurrentWrites caps in-flight analysis writes so bursts queue
+	// instead of exhausting the pool; 0 disables the cap. WriteWaitTimeout
+	// is how long a write waits for a slot before failing as busy.
+	MaxConcurrentWrites int           `mapstructure:"max_concurrent_writes"`
+	WriteWaitTimeout    time.Duration `mapstructure:"write_wait_timeout"`
 }
 
 type RedisConfig struct {
@@ -43,25 +74,100 @@ type RedisConfig struct {
 	DialTimeout  time.Duration `mapstructure:"dial_timeout"`
 	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
 	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+	// KeyPrefix namespaces every key so environments can share one Redis.
+	KeyPrefix string `mapstructure:"key_prefix"`
 }
 
 type LoggerConfig struct {
-	Level       string `mapstructure:"level"`
-	Development bool   `mapstructure:"development"`
+	Level          string `mapstructure:"level"`
+	Development    bool   `mapstructure:"development"`
+	LogBodies      bool   `mapstructure:"log_bodies"`
+	MaxBodyLogSize int    `mapstructure:"max_body_log_size"`
+	// RedactURLCredentials strips user:pass@ from URLs before they are logged.
+	RedactURLCredentials bool `mapstructure:"redact_url_credentials"`
 }
 
 type AnalysisConfig struct {
-	RequestTimeout          time.Duration `mapstructure:"request_timeout"`
-	MaxContentLength        int64         `mapstructure:"max_content_length"`
-	CacheTTL                time.Duration `mapstructure:"cache_ttl"`
-	RateLimitPerIP          int           `mapstructure:"rate_limit_per_ip"`
-	RateLimitWindow         time.Duration `mapstructure:"rate_limit_window"`
-	MaxConcurrentJobs       int           `mapstructure:"max_concurrent_jobs"`
-	LinkCheckTimeout        time.Duration `mapstructure:"link_check_timeout"`
-	MaxLinksToCheck         int           `mapstructure:"max_links_to_check"`
-	MaxConcurrentLinkChecks int           `mapstructure:"max_concurrent_link_checks"`
-	MaxHTMLDepth            int           `mapstructure:"max_html_depth"`
-	MaxURLLength            int           `mapstructure:"max_url_length"`
+	RequestTimeout            time.Duration `mapstructure:"request_timeout"`
+	MaxContentLength          int64         `mapstructure:"max_content_length"`
+	CacheTTL                  time.Duration `mapstructure:"cache_ttl"`
+	RateLimitPerIP            int           `mapstructure:"rate_limit_per_ip"`
+	RateLimitWindow           time.Duration `mapstructure:"rate_limit_window"`
+	MaxConcurrentJobs         int           `mapstructure:"max_concurrent_jobs"`
+	LinkCheckTimeout          time.Duration `mapstructure:"link_check_timeout"`
+	MaxLinksToCheck           int           `mapstructure:"max_links_to_check"`
+	MaxConcurrentLinkChecks   int           `mapstructure:"max_concurrent_link_checks"`
+	AdaptiveLinkChecks        bool          `mapstructure:"adaptive_link_checks"`
+	MinConcurrentLinkChecks   int           `mapstructure:"min_concurrent_link_checks"`
+	MaxHTMLDepth              int           `mapstructure:"max_html_depth"`
+	MaxURLLength              int           `mapstructure:"max_url_length"`
+	AllowedSchemes            []string      `mapstructure:"allowed_schemes"`
+	MaxFetchRetries           int           `mapstructure:"max_fetch_retries"`
+	MaxJobRetries             int           `mapstructure:"max_job_retries"`
+	MaxResultListItems        int           `mapstructure:"max_result_list_items"`
+	MonitorPollInterval       time.Duration `mapstructure:"monitor_poll_interval"`
+	MonitorMinInterval        time.Duration `mapstructure:"monitor_min_interval"`
+	HTMLParser                string        `mapstructure:"html_parser"`
+	TreatSubdomainsAsInternal bool          `mapstructure:"treat_subdomains_as_internal"`
+	// RateLimitExemptPaths bypass rate limiting; empty exempts /health and
+	// Server.MetricsPath.
+	RateLimitExemptPaths []string `mapstructure:"rate_limit_exempt_paths"`
+	MaxHTMLNodes         int      `mapstructure:"max_html_nodes"`
+	// Retention is how long analyses are kept; 0 keeps them forever.
+	Retention                time.Duration `mapstructure:"retention"`
+	RetentionCleanupInterval time.Duration `mapstructure:"retention_cleanup_interval"`
+	DefaultPriority          int           `mapstructure:"default_priority"`
+	MaxTitleLength           int           `mapstructure:"max_title_length"`
+	MaxHeadings              int           `mapstructure:"max_headings"`
+	MaxConcurrentPerUser     int           `mapstructure:"max_concurrent_per_user"`
+	MaxStoredPerUser         int           `mapstructure:"max_stored_per_user"`
+	LinkCheckSkipHosts       []string      `mapstructure:"link_check_skip_hosts"`
+	LinkCheckMaxRedirects    int           `mapstructure:"link_check_max_redirects"`
+	AllowDataURLs            bool          `mapstructure:"allow_data_urls"`
+	// SourceCaptureMaxBytes enables per-request raw HTML capture, capped at
+	// this size; 0 disables it.
+	SourceCaptureMaxBytes int           `mapstructure:"source_capture_max_bytes"`
+	SourceCaptureTTL      time.Duration `mapstructure:"source_capture_ttl"`
+	// ForceHTTP1 disables HTTP/2 negotiation for fetches and link checks.
+	ForceHTTP1 bool `mapstructure:"force_http1"`
+	// AcceptLanguage is sent with page fetches, and with link checks when
+	// LinkCheckAcceptLanguage is set.
+	AcceptLanguage          string `mapstructure:"accept_language"`
+	LinkCheckAcceptLanguage bool   `mapstructure:"link_check_accept_language"`
+	// LinkCheckBudget time-boxes link checking per analysis; 0 disables it.
+	LinkCheckBudget time.Duration `mapstructure:"link_check_budget"`
+	// SharedLinkCache keeps link checks in Redis for LinkCacheTTL so they
+	// are reused across analyses and replicas.
+	SharedLinkCache bool          `mapstructure:"shared_link_cache"`
+	LinkCacheTTL    time.Duration `mapstructure:"link_cache_ttl"`
+	// DNSServer (host:port) replaces the system resolver for fetches and
+	// link checks; DNSTimeout > 0 bounds each lookup.
+	DNSServer  string        `mapstructure:"dns_server"`
+	DNSTimeout time.Duration `mapstructure:"dns_timeout"`
+	// CaptureResponseHeaders lists page response headers recorded in the
+	// result metadata, e.g. Server or Strict-Transport-Security.
+	CaptureResponseHeaders []string `mapstructure:"capture_response_headers"`
+	// AllowedDomains limits analysis to these hosts and their subdomains;
+	// empty allows any host.
+	AllowedDomains []string `mapstructure:"allowed_domains"`
+	// MinTLSVersion is the oldest TLS version negotiated with targets,
+	// "1.2" or "1.3".
+	MinTLSVersion string `mapstructure:"min_tls_version"`
+	// CacheTTLOverrides replace CacheTTL for matching URLs; the first match
+	// wins.
+	CacheTTLOverrides []CacheTTLOverride `mapstructure:"cache_ttl_overrides"`
+	// SyncQueueSize sync analyses may wait up to SyncQueueWait for a slot
+	// once max_concurrent_jobs are running; 0 rejects them at once.
+	SyncQueueSize int           `mapstructure:"sync_queue_size"`
+	SyncQueueWait time.Duration `mapstructure:"sync_queue_wait"`
+}
+
+// CacheTTLOverride sets the cache TTL for URLs on Host (or its subdomains)
+// or matching the Pattern regular expression; set exactly one of them.
+type CacheTTLOverride struct {
+	Host    string        `mapstructure:"host"`
+	Pattern string        `mapstructure:"pattern"`
+	TTL     time.Duration `mapstructure:"ttl"`
 }
 
 func Load(configPath string) (*Config, error) {
@@ -89,6 +195,15 @@ func setDefaults() {
 	viper.SetDefault("server.read_timeout", "30s")
 	viper.SetDefault("server.write_timeout", "30s")
 	viper.SetDefault("server.idle_timeout", "120s")
+	viper.SetDefault("server.metrics_path", "/metrics")
+	viper.SetDefault("server.metrics_port", "")
+	viper.SetDefault("server.correlation_id_header", "X-Correlation-ID")
+	viper.SetDefault("server.trusted_proxies", []string{})
+	viper.SetDefault("server.shutdown_timeout", "30s")
+	viper.SetDefault("server.compression_enabled", true)
+	viper.SetDefault("server.compression_min_size", 1024)
+	viper.SetDefault("server.read_route_timeout", "5s")
+	viper.SetDefault("server.max_analysis_timeout", "2m")
 
 	viper.SetDefault("database.host", "localhost")
 	viper.SetDefault("database.port", "5432")
@@ -98,6 +213,10 @@ func setDefaults() {
 	viper.SetDefault("database.max_connections", 50)
 	viper.SetDefault("database.max_idle_conns", 10)
 	viper.SetDefault("database.conn_max_lifetime", "1h")
+	viper.SetDefault("database.slow_query_threshold", "200ms")
+	viper.SetDefault("database.max_list_limit", 1000)
+	viper.SetDefault("database.max_concurrent_writes", 40)
+	viper.SetDefault("database.write_wait_timeout", "2s")
 
 	viper.SetDefault("redis.host", "localhost")
 	viper.SetDefault("redis.port", "6379")
@@ -107,9 +226,13 @@ func setDefaults() {
 	viper.SetDefault("redis.dial_timeout", "5s")
 	viper.SetDefault("redis.read_timeout", "3s")
 	viper.SetDefault("redis.write_timeout", "3s")
+	viper.SetDefault("redis.key_prefix", "")
 
 	viper.SetDefault("logger.level", "info")
 	viper.SetDefault("logger.development", false)
+	viper.SetDefault("logger.log_bodies", false)
+	viper.SetDefault("logger.max_body_log_size", 4096)
+	viper.SetDefault("logger.redact_url_credentials", true)
 
 	viper.SetDefault("analysis.request_timeout", "30s")
 	viper.SetDefault("analysis.max_content_length", 10485760)
@@ -120,10 +243,56 @@ func setDefaults() {
 	viper.SetDefault("analysis.link_check_timeout", "5s")
 	viper.SetDefault("analysis.max_links_to_check", 50)
 	viper.SetDefault("analysis.max_concurrent_link_checks", 10)
+	viper.SetDefault("analysis.adaptive_link_checks", false)
+	viper.SetDefault("analysis.min_concurrent_link_checks", 2)
 	viper.SetDefault("analysis.max_html_depth", 100)
 	viper.SetDefault("analysis.max_url_length", 2048)
+	viper.SetDefault("analysis.allowed_schemes", []string{"http", "https"})
+	viper.SetDefault("analysis.max_fetch_retries", 2)
+	viper.SetDefault("analysis.max_job_retries", 3)
+	viper.SetDefault("analysis.max_result_list_items", 1000)
+	viper.SetDefault("analysis.monitor_poll_interval", "1m")
+	viper.SetDefault("analysis.monitor_min_interval", "5m")
+	viper.SetDefault("analysis.html_parser", "tree")
+	viper.SetDefault("analysis.treat_subdomains_as_internal", false)
+	viper.SetDefault("analysis.rate_limit_exempt_paths", []string{})
+	viper.SetDefault("analysis.max_html_nodes", 200000)
+	viper.SetDefault("analysis.retention", "0s")
+	viper.SetDefault("analysis.retention_cleanup_interval", "1h")
+	viper.SetDefault("analysis.default_priority", 1)
+	viper.SetDefault("analysis.max_title_length", 512)
+	viper.SetDefault("analysis.max_headings", 10000)
+	viper.SetDefault("analysis.max_concurrent_per_user", 0)
+	viper.SetDefault("analysis.max_stored_per_user", 0)
+	viper.SetDefault("analysis.link_check_skip_hosts", []string{})
+	viper.SetDefault("analysis.link_check_max_redirects", 0)
+	viper.SetDefault("analysis.allow_data_urls", false)
+	viper.SetDefault("analysis.source_capture_max_bytes", 0)
+	viper.SetDefault("analysis.source_capture_ttl", "1h")
+	viper.SetDefault("analysis.force_http1", false)
+	viper.SetDefault("analysis.accept_language", "")
+	viper.SetDefault("analysis.link_check_accept_language", false)
+	viper.SetDefault("analysis.link_check_budget", "0s")
+	viper.SetDefault("analysis.shared_link_cache", false)
+	viper.SetDefault("analysis.link_cache_ttl", "10m")
+	viper.SetDefault("analysis.dns_server", "")
+	viper.SetDefault("analysis.dns_timeout", "0s")
+	viper.SetDefault("analysis.capture_response_headers", []string{})
+	viper.SetDefault("analysis.allowed_domains", []string{})
+	viper.SetDefault("analysis.min_tls_version", "1.2")
+	viper.SetDefault("analysis.sync_queue_size", 0)
+	viper.SetDefault("analysis.sync_queue_wait", "5s")
 
 	_ = viper.BindEnv("server.port", "PORT")
+	_ = viper.BindEnv("server.metrics_path", "METRICS_PATH")
+	_ = viper.BindEnv("server.metrics_port", "METRICS_PORT")
+	_ = viper.BindEnv("server.correlation_id_header", "CORRELATION_ID_HEADER")
+	_ = viper.BindEnv("server.trusted_proxies", "TRUSTED_PROXIES")
+	_ = viper.BindEnv("server.shutdown_timeout", "SHUTDOWN_TIMEOUT")
+	_ = viper.BindEnv("server.compression_enabled", "COMPRESSION_ENABLED")
+	_ = viper.BindEnv("server.compression_min_size", "COMPRESSION_MIN_SIZE")
+	_ = viper.BindEnv("server.read_route_timeout", "READ_ROUTE_TIMEOUT")
+	_ = viper.BindEnv("server.max_analysis_timeout", "MAX_ANALYSIS_TIMEOUT")
 	_ = viper.BindEnv("database.host", "DB_HOST")
 	_ = viper.BindEnv("database.port", "DB_PORT")
 	_ = viper.BindEnv("database.user", "DB_USER")
@@ -133,6 +302,10 @@ func setDefaults() {
 	_ = viper.BindEnv("database.max_connections", "DB_MAX_CONNECTIONS")
 	_ = viper.BindEnv("database.max_idle_conns", "DB_MAX_IDLE_CONNS")
 	_ = viper.BindEnv("database.conn_max_lifetime", "DB_CONN_MAX_LIFETIME")
+	_ = viper.BindEnv("database.slow_query_threshold", "DB_SLOW_QUERY_THRESHOLD")
+	_ = viper.BindEnv("database.max_list_limit", "DB_MAX_LIST_LIMIT")
+	_ = viper.BindEnv("database.max_concurrent_writes", "DB_MAX_CONCURRENT_WRITES")
+	_ = viper.BindEnv("database.write_wait_timeout", "DB_WRITE_WAIT_TIMEOUT")
 
 	_ = viper.BindEnv("redis.host", "REDIS_HOST")
 	_ = viper.BindEnv("redis.port", "REDIS_PORT")
@@ -143,9 +316,13 @@ func setDefaults() {
 	_ = viper.BindEnv("redis.dial_timeout", "REDIS_DIAL_TIMEOUT")
 	_ = viper.BindEnv("redis.read_timeout", "REDIS_READ_TIMEOUT")
 	_ = viper.BindEnv("redis.write_timeout", "REDIS_WRITE_TIMEOUT")
+	_ = viper.BindEnv("redis.key_prefix", "REDIS_KEY_PREFIX")
 
 	_ = viper.BindEnv("logger.level", "LOG_LEVEL")
 	_ = viper.BindEnv("logger.development", "LOG_DEVELOPMENT")
+	_ = viper.BindEnv("logger.log_bodies", "LOG_BODIES")
+	_ = viper.BindEnv("logger.max_body_log_size", "LOG_MAX_BODY_SIZE")
+	_ = viper.BindEnv("logger.redact_url_credentials", "LOG_REDACT_URL_CREDENTIALS")
 
 	_ = viper.BindEnv("analysis.request_timeout", "ANALYSIS_REQUEST_TIMEOUT")
 	_ = viper.BindEnv("analysis.max_content_length", "ANALYSIS_MAX_CONTENT_LENGTH")
@@ -153,4 +330,41 @@ func setDefaults() {
 	_ = viper.BindEnv("analysis.rate_limit_per_ip", "ANALYSIS_RATE_LIMIT_PER_IP")
 	_ = viper.BindEnv("analysis.rate_limit_window", "ANALYSIS_RATE_LIMIT_WINDOW")
 	_ = viper.BindEnv("analysis.max_concurrent_jobs", "ANALYSIS_MAX_CONCURRENT_JOBS")
+	_ = viper.BindEnv("analysis.adaptive_link_checks", "ANALYSIS_ADAPTIVE_LINK_CHECKS")
+	_ = viper.BindEnv("analysis.min_concurrent_link_checks", "ANALYSIS_MIN_CONCURRENT_LINK_CHECKS")
+	_ = viper.BindEnv("analysis.allowed_schemes", "ANALYSIS_ALLOWED_SCHEMES")
+	_ = viper.BindEnv("analysis.max_fetch_retries", "ANALYSIS_MAX_FETCH_RETRIES")
+	_ = viper.BindEnv("analysis.max_job_retries", "ANALYSIS_MAX_JOB_RETRIES")
+	_ = viper.BindEnv("analysis.max_result_list_items", "ANALYSIS_MAX_RESULT_LIST_ITEMS")
+	_ = viper.BindEnv("analysis.monitor_poll_interval", "ANALYSIS_MONITOR_POLL_INTERVAL")
+	_ = viper.BindEnv("analysis.monitor_min_interval", "ANALYSIS_MONITOR_MIN_INTERVAL")
+	_ = viper.BindEnv("analysis.html_parser", "ANALYSIS_HTML_PARSER")
+	_ = viper.BindEnv("analysis.treat_subdomains_as_internal", "ANALYSIS_TREAT_SUBDOMAINS_AS_INTERNAL")
+	_ = viper.BindEnv("analysis.rate_limit_exempt_paths", "ANALYSIS_RATE_LIMIT_EXEMPT_PATHS")
+	_ = viper.BindEnv("analysis.max_html_nodes", "ANALYSIS_MAX_HTML_NODES")
+	_ = viper.BindEnv("analysis.retention", "ANALYSIS_RETENTION")
+	_ = viper.BindEnv("analysis.retention_cleanup_interval", "ANALYSIS_RETENTION_CLEANUP_INTERVAL")
+	_ = viper.BindEnv("analysis.default_priority", "ANALYSIS_DEFAULT_PRIORITY")
+	_ = viper.BindEnv("analysis.max_title_length", "ANALYSIS_MAX_TITLE_LENGTH")
+	_ = viper.BindEnv("analysis.max_headings", "ANALYSIS_MAX_HEADINGS")
+	_ = viper.BindEnv("analysis.max_concurrent_per_user", "ANALYSIS_MAX_CONCURRENT_PER_USER")
+	_ = viper.BindEnv("analysis.max_stored_per_user", "ANALYSIS_MAX_STORED_PER_USER")
+	_ = viper.BindEnv("analysis.link_check_skip_hosts", "ANALYSIS_LINK_CHECK_SKIP_HOSTS")
+	_ = viper.BindEnv("analysis.link_check_max_redirects", "ANALYSIS_LINK_CHECK_MAX_REDIRECTS")
+	_ = viper.BindEnv("analysis.allow_data_urls", "ANALYSIS_ALLOW_DATA_URLS")
+	_ = viper.BindEnv("analysis.source_capture_max_bytes", "ANALYSIS_SOURCE_CAPTURE_MAX_BYTES")
+	_ = viper.BindEnv("analysis.source_capture_ttl", "ANALYSIS_SOURCE_CAPTURE_TTL")
+	_ = viper.BindEnv("analysis.force_http1", "ANALYSIS_FORCE_HTTP1")
+	_ = viper.BindEnv("analysis.accept_language", "ANALYSIS_ACCEPT_LANGUAGE")
+	_ = viper.BindEnv("analysis.link_check_accept_language", "ANALYSIS_LINK_CHECK_ACCEPT_LANGUAGE")
+	_ = viper.BindEnv("analysis.link_check_budget", "ANALYSIS_LINK_CHECK_BUDGET")
+	_ = viper.BindEnv("analysis.shared_link_cache", "ANALYSIS_SHARED_LINK_CACHE")
+	_ = viper.BindEnv("analysis.link_cache_ttl", "ANALYSIS_LINK_CACHE_TTL")
+	_ = viper.BindEnv("analysis.dns_server", "ANALYSIS_DNS_SERVER")
+	_ = viper.BindEnv("analysis.dns_timeout", "ANALYSIS_DNS_TIMEOUT")
+	_ = viper.BindEnv("analysis.capture_response_headers", "ANALYSIS_CAPTURE_RESPONSE_HEADERS")
+	_ = viper.BindEnv("analysis.allowed_domains", "ANALYSIS_ALLOWED_DOMAINS")
+	_ = viper.BindEnv("analysis.min_tls_version", "ANALYSIS_MIN_TLS_VERSION")
+	_ = viper.BindEnv("analysis.sync_queue_size", "ANALYSIS_SYNC_QUEUE_SIZE")
+	_ = viper.BindEnv("analysis.sync_queue_wait", "ANALYSIS_SYNC_QUEUE_WAIT")
 }
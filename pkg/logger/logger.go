@@ -26,6 +26,7 @@ type contextKey string
 const (
 	CorrelationIDKey contextKey = "correlation_id"
 	UserIDKey        contextKey = "user_id"
+	TraceIDKey       contextKey = "trace_id"
 	URLKey           contextKey = "url"
 	DurationKey      contextKey = "duration"
 	StatusCodeKey    contextKey = "status_code"
@@ -60,6 +61,12 @@ func New(level string, isDevelopment bool) (Logger, error) {
 	return &logger{zap: zapLogger}, nil
 }
 
+// NewNop returns a Logger that discards everything. It stands in wherever a
+// nil Logger would otherwise be dereferenced. Fatal still exits the process.
+func NewNop() Logger {
+	return &logger{zap: zap.NewNop()}
+}
+
 func (l *logger) Debug(msg string, fields ...zap.Field) {
 	l.zap.Debug(msg, fields...)
 }
@@ -99,5 +106,9 @@ func (l *logger) WithContext(ctx context.Context) Logger {
 		}
 	}
 
+	if traceID, ok := ctx.Value(TraceIDKey).(string); ok && traceID != "" {
+		fields = append(fields, zap.String(string(TraceIDKey), traceID))
+	}
+
 	return l.With(fields...)
 }
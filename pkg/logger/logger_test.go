@@ -1,9 +1,12 @@
 package logger
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
 )
 
 func TestNewLogger(t *testing.T) {
@@ -40,3 +43,22 @@ func TestNewLoggerWithFatal(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, logger)
 }
+
+// Fatal is left out: it exits the process even on a no-op logger.
+func TestNewNop(t *testing.T) {
+	log := NewNop()
+	assert.NotNil(t, log)
+
+	assert.NotPanics(t, func() {
+		log.Debug("debug", zap.String("key", "value"))
+		log.Info("info")
+		log.Warn("warn")
+		log.Error("error", zap.Error(errors.New("boom")))
+
+		ctx := context.WithValue(context.Background(), CorrelationIDKey, "corr-1")
+		ctx = context.WithValue(ctx, UserIDKey, "user-1")
+		scoped := log.With(zap.String("component", "test")).WithContext(ctx)
+		assert.NotNil(t, scoped)
+		scoped.Info("scoped")
+	})
+}
@@ -0,0 +1,111 @@
+// Package tracing implements the W3C Trace Context traceparent header so
+// requests can be correlated with OpenTelemetry-based tracing.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// Header is the W3C Trace Context request header.
+const Header = "traceparent"
+
+const (
+	version        = "00"
+	traceIDLength  = 32
+	parentIDLength = 16
+)
+
+// Traceparent is a parsed traceparent header; all fields are lowercase hex.
+type Traceparent struct {
+	TraceID  string
+	ParentID string
+	Flags    string
+}
+
+// Parse reads a traceparent header value, rejecting malformed values and
+// all-zero IDs as the specification requires.
+func Parse(value string) (Traceparent, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 {
+		return Traceparent{}, false
+	}
+	ver, traceID, parentID, flags := parts[0], parts[1], parts[2], parts[3]
+	// future versions may append fields, version 00 may not
+	if !isHex(ver, 2) || ver == "ff" || (ver == version && len(parts) != 4) {
+		return Traceparent{}, false
+	}
+	if !isHex(traceID, traceIDLength) || isZero(traceID) {
+		return Traceparent{}, false
+	}
+	if !isHex(parentID, parentIDLength) || isZero(parentID) {
+		return Traceparent{}, false
+	}
+	if !isHex(flags, 2) {
+		return Traceparent{}, false
+	}
+	return Traceparent{TraceID: traceID, ParentID: parentID, Flags: flags}, true
+}
+
+// New starts a trace with random IDs. The sampled flag is left unset since
+// this service does not record spans itself.
+func New() Traceparent {
+	return Traceparent{
+		TraceID:  randomHex(traceIDLength / 2),
+		ParentID: randomHex(parentIDLength / 2),
+		Flags:    "00",
+	}
+}
+
+// Child keeps the trace ID and flags but takes a new parent ID, for
+// propagating the trace on an outbound request.
+func (t Traceparent) Child() Traceparent {
+	t.ParentID = randomHex(parentIDLength / 2)
+	return t
+}
+
+func (t Traceparent) String() string {
+	return version + "-" + t.TraceID + "-" + t.ParentID + "-" + t.Flags
+}
+
+type contextKey struct{}
+
+// WithTraceparent stores t in ctx.
+func WithTraceparent(ctx context.Context, t Traceparent) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext returns the traceparent stored by WithTraceparent.
+func FromContext(ctx context.Context) (Traceparent, bool) {
+	t, ok := ctx.Value(contextKey{}).(Traceparent)
+	return t, ok
+}
+
+func isHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isZero(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
+
+func randomHex(n int) string {
+	for {
+		b := make([]byte, n)
+		// crypto/rand.Read does not fail on supported platforms
+		_, _ = rand.Read(b)
+		if s := hex.EncodeToString(b); !isZero(s) {
+			return s
+		}
+	}
+}
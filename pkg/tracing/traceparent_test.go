@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		valid bool
+	}{
+		{"valid", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{"future version with extra field", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true},
+		{"version 00 with extra field", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false},
+		{"invalid version", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"uppercase", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00F067AA0BA902B7-01", false},
+		{"zero trace id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+		{"zero parent id", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false},
+		{"short trace id", "00-4bf92f3577b34da6-00f067aa0ba902b7-01", false},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok := Parse(tt.value)
+			assert.Equal(t, tt.valid, ok)
+		})
+	}
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	value := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	tp, ok := Parse(value)
+	assert.True(t, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", tp.TraceID)
+	assert.Equal(t, value, tp.String())
+}
+
+func TestNewIsValid(t *testing.T) {
+	tp := New()
+
+	parsed, ok := Parse(tp.String())
+	assert.True(t, ok)
+	assert.Equal(t, tp, parsed)
+	assert.NotEqual(t, New().TraceID, tp.TraceID)
+}
+
+func TestChildKeepsTraceID(t *testing.T) {
+	parent, _ := Parse("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	child := parent.Child()
+
+	assert.Equal(t, parent.TraceID, child.TraceID)
+	assert.Equal(t, parent.Flags, child.Flags)
+	assert.NotEqual(t, parent.ParentID, child.ParentID)
+}
+
+func TestContext(t *testing.T) {
+	_, ok := FromContext(context.Background())
+	assert.False(t, ok)
+
+	tp := New()
+	got, ok := FromContext(WithTraceparent(context.Background(), tp))
+	assert.True(t, ok)
+	assert.Equal(t, tp, got)
+}
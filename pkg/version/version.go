@@ -0,0 +1,20 @@
+package version
+
+import "time"
+
+// Version and Commit are overridden at build time via
+// -ldflags "-X webpage-analyzer/pkg/version.Version=... -X webpage-analyzer/pkg/version.Commit=..."
+var (
+	Version = "1.0.0"
+	Commit  = "unknown"
+)
+
+var startTime = time.Now()
+
+func StartTime() time.Time {
+	return startTime
+}
+
+func Uptime() time.Duration {
+	return time.Since(startTime)
+}
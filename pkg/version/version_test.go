@@ -0,0 +1,21 @@
+package version
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaults(t *testing.T) {
+	assert.NotEmpty(t, Version)
+	assert.NotEmpty(t, Commit)
+}
+
+func TestUptime(t *testing.T) {
+	first := Uptime()
+	time.Sleep(5 * time.Millisecond)
+
+	assert.Greater(t, Uptime(), first)
+	assert.False(t, StartTime().After(time.Now()))
+}